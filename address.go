@@ -0,0 +1,57 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+
+	"github.com/zondax/ledger-avalanche-go/address"
+	"github.com/zondax/ledger-avalanche-go/serialize"
+)
+
+// ChainAlias returns the alias of chainid used as address prefix ("P", "X" or "C"),
+// or chainid itself when it is not a primary network chain. An empty chainid is the P-chain.
+func ChainAlias(chainid string) string {
+	return serialize.ChainAlias(chainid)
+}
+
+// GetAddress returns the formatted address at path (e.g "P-avax1..."), checking that the
+// hash returned by the device belongs to the returned public key. With show set the address
+// is returned only once the user confirmed it on the device.
+func (ledger *LedgerAvalanche) GetAddress(path string, hrp string, chainid string, show bool) (string, error) {
+	publicKey, hash, err := ledger.GetPubKey(path, show, hrp, chainid)
+	if err != nil {
+		return "", err
+	}
+
+	if len(hash) < 20 {
+		return "", ErrMalformedResponse
+	}
+	if !bytes.Equal(AddressHash(publicKey), hash[:20]) {
+		return "", ErrAddressMismatch
+	}
+
+	if hrp == "" {
+		hrp = DefaultHRP
+	}
+	encoded, err := encodeBech32(hrp, hash[:20])
+	if err != nil {
+		return "", err
+	}
+
+	return address.FormatChainAddress(ChainAlias(chainid), encoded), nil
+}
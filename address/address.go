@@ -0,0 +1,39 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package address
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidChainAddress is returned when parsing an address without chain alias
+var ErrInvalidChainAddress = errors.New("invalid chain address")
+
+// FormatChainAddress prefixes a bech32 address with the alias of its chain, e.g. "X-avax1..."
+func FormatChainAddress(chainAlias string, addr string) string {
+	return chainAlias + "-" + addr
+}
+
+// ParseChainAddress splits a chain address, e.g. "X-avax1...", into its chain alias and bech32 address
+func ParseChainAddress(chainAddress string) (chainAlias string, addr string, err error) {
+	separator := strings.IndexByte(chainAddress, '-')
+	if separator < 1 || separator == len(chainAddress)-1 {
+		return "", "", ErrInvalidChainAddress
+	}
+	return chainAddress[:separator], chainAddress[separator+1:], nil
+}
@@ -0,0 +1,41 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package address
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ChainAddress(t *testing.T) {
+	const addr = "avax1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw"
+
+	chainAddress := FormatChainAddress("X", addr)
+	assert.Equal(t, "X-"+addr, chainAddress)
+
+	alias, parsed, err := ParseChainAddress(chainAddress)
+	require.NoError(t, err)
+	assert.Equal(t, "X", alias)
+	assert.Equal(t, addr, parsed)
+
+	for _, invalid := range []string{addr, "-" + addr, "X-"} {
+		_, _, err = ParseChainAddress(invalid)
+		assert.ErrorIs(t, err, ErrInvalidChainAddress, invalid)
+	}
+}
@@ -0,0 +1,163 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package address encodes and decodes Avalanche addresses and IDs (bech32, CB58 and the
+// "X-avax1..." chain address format) without depending on avalanchego.
+package address
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidBech32 is returned when decoding a malformed bech32 string
+var ErrInvalidBech32 = errors.New("invalid bech32 string")
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HrpExpand(hrp string) []byte {
+	expanded := make([]byte, 0, 2*len(hrp)+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// convertBits regroups data from fromBits to toBits per element
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc := uint32(0)
+	bits := uint(0)
+	maxv := uint32(1)<<toBits - 1
+	result := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+
+	for _, value := range data {
+		if uint32(value)>>fromBits != 0 {
+			return nil, errors.New("invalid data range")
+		}
+		acc = acc<<fromBits | uint32(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte(acc>>bits&maxv))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			result = append(result, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil, errors.New("invalid padding")
+	}
+
+	return result, nil
+}
+
+// validHrp checks that all characters of hrp are in the [33, 126] range
+func validHrp(hrp string) bool {
+	if hrp == "" {
+		return false
+	}
+	for _, c := range hrp {
+		if c < 33 || c > 126 {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeBech32 encodes data (e.g an address hash) with the given human readable part
+func EncodeBech32(hrp string, data []byte) (string, error) {
+	if !validHrp(hrp) {
+		return "", errors.New("all characters in the HRP must be in the [33, 126] range")
+	}
+	hrp = strings.ToLower(hrp)
+
+	values, err := convertBits(data, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	polymod := bech32Polymod(append(append(bech32HrpExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ 1
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return sb.String(), nil
+}
+
+// DecodeBech32 returns the human readable part and the data of a bech32 string
+func DecodeBech32(encoded string) (hrp string, data []byte, err error) {
+	lower := strings.ToLower(encoded)
+	if lower != encoded && strings.ToUpper(encoded) != encoded {
+		return "", nil, ErrInvalidBech32
+	}
+
+	// [hrp | '1' | data | checksum]
+	separator := strings.LastIndexByte(lower, '1')
+	if separator < 1 || separator+7 > len(lower) {
+		return "", nil, ErrInvalidBech32
+	}
+	hrp = lower[:separator]
+	if !validHrp(hrp) {
+		return "", nil, ErrInvalidBech32
+	}
+
+	values := make([]byte, 0, len(lower)-separator-1)
+	for i := separator + 1; i < len(lower); i++ {
+		v := strings.IndexByte(bech32Charset, lower[i])
+		if v < 0 {
+			return "", nil, ErrInvalidBech32
+		}
+		values = append(values, byte(v))
+	}
+
+	if bech32Polymod(append(bech32HrpExpand(hrp), values...)) != 1 {
+		return "", nil, ErrInvalidBech32
+	}
+
+	data, err = convertBits(values[:len(values)-6], 5, 8, false)
+	if err != nil {
+		return "", nil, ErrInvalidBech32
+	}
+	return hrp, data, nil
+}
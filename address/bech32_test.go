@@ -0,0 +1,76 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package address
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EncodeBech32(t *testing.T) {
+	// BIP-173 test vector
+	data, _ := hex.DecodeString("00443214c74254b635cf84653a56d7c675be77df")
+
+	encoded, err := EncodeBech32("abcdef", data)
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", encoded)
+
+	_, err = EncodeBech32("", data)
+	assert.Error(t, err)
+}
+
+func Test_DecodeBech32(t *testing.T) {
+	hrp, data, err := DecodeBech32("abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw")
+	require.NoError(t, err)
+	assert.Equal(t, "abcdef", hrp)
+	assert.Equal(t, "00443214c74254b635cf84653a56d7c675be77df", hex.EncodeToString(data))
+
+	// BIP-173 valid string with empty data, in upper case
+	hrp, data, err = DecodeBech32("A12UEL5L")
+	require.NoError(t, err)
+	assert.Equal(t, "a", hrp)
+	assert.Empty(t, data)
+}
+
+func Test_DecodeBech32Invalid(t *testing.T) {
+	for _, encoded := range []string{
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxx", // checksum
+		"Abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", // mixed case
+		"1pzry9x0s0muk", // empty hrp
+		"pzry9x0s0muk",  // no separator
+		"a1b2uel5l",     // invalid character
+		"a1uel5",        // short checksum
+	} {
+		_, _, err := DecodeBech32(encoded)
+		assert.ErrorIs(t, err, ErrInvalidBech32, encoded)
+	}
+}
+
+func Test_Bech32RoundTrip(t *testing.T) {
+	hash, _ := hex.DecodeString("3cb7d3842e8cee6a0ebd09f1fe884f6861e1b29c")
+
+	encoded, err := EncodeBech32("fuji", hash)
+	require.NoError(t, err)
+
+	hrp, decoded, err := DecodeBech32(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "fuji", hrp)
+	assert.Equal(t, hash, decoded)
+}
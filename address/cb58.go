@@ -0,0 +1,55 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/mr-tron/base58"
+)
+
+// ErrInvalidCB58 is returned when decoding a malformed CB58 string or one with a wrong checksum
+var ErrInvalidCB58 = errors.New("invalid cb58 string")
+
+const cb58ChecksumLen = 4
+
+// cb58Checksum returns the last 4 bytes of the SHA-256 of data
+func cb58Checksum(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[len(hash)-cb58ChecksumLen:]
+}
+
+// EncodeCB58 encodes data, e.g. a chain or transaction ID, in base58 with a 4 byte checksum
+func EncodeCB58(data []byte) string {
+	return base58.Encode(append(append([]byte{}, data...), cb58Checksum(data)...))
+}
+
+// DecodeCB58 decodes a CB58 string, checking its checksum
+func DecodeCB58(encoded string) ([]byte, error) {
+	decoded, err := base58.Decode(encoded)
+	if err != nil || len(decoded) < cb58ChecksumLen {
+		return nil, ErrInvalidCB58
+	}
+
+	data := decoded[:len(decoded)-cb58ChecksumLen]
+	if !bytes.Equal(cb58Checksum(data), decoded[len(data):]) {
+		return nil, ErrInvalidCB58
+	}
+	return data, nil
+}
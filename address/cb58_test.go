@@ -0,0 +1,44 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package address
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EncodeCB58(t *testing.T) {
+	// the P-chain ID
+	assert.Equal(t, "11111111111111111111111111111111LpoYY", EncodeCB58(make([]byte, 32)))
+}
+
+func Test_DecodeCB58(t *testing.T) {
+	const xChainID = "2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByM"
+
+	data, err := DecodeCB58(xChainID)
+	require.NoError(t, err)
+	assert.Len(t, data, 32)
+	assert.Equal(t, xChainID, EncodeCB58(data))
+
+	_, err = DecodeCB58("11111111111111111111111111111111LpoYZ")
+	assert.ErrorIs(t, err, ErrInvalidCB58)
+
+	_, err = DecodeCB58("0OIl")
+	assert.ErrorIs(t, err, ErrInvalidCB58)
+}
@@ -0,0 +1,87 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetAddress(t *testing.T) {
+	publicKey, _ := hex.DecodeString("035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56")
+	response := append(append([]byte{33}, publicKey...), AddressHash(publicKey)...)
+	device := &mockDevice{handler: replies(response, response)}
+	ledger := newMockLedger(device)
+
+	address, err := ledger.GetAddress("m/44'/9000'/0'/0/0", "", "", true)
+	require.NoError(t, err)
+
+	expected, _ := encodeBech32("avax", AddressHash(publicKey))
+	assert.Equal(t, "P-"+expected, address)
+	assert.Equal(t, byte(P1_SHOW_ADDRESS_IN_DEVICE), device.sent[0][2])
+
+	address, err = ledger.GetAddress("m/44'/9000'/0'/0/0", "fuji", "2JVSBoinj9C2J33VntvzYtVJNZdN2NKiwwKjcumHUWEb5DbBrm", false)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(address, "X-fuji1"))
+}
+
+func Test_GetAddressMismatch(t *testing.T) {
+	publicKey, _ := hex.DecodeString("035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56")
+	response := append(append([]byte{33}, publicKey...), make([]byte, 20)...)
+	ledger := newMockLedger(&mockDevice{handler: replies(response)})
+
+	_, err := ledger.GetAddress("m/44'/9000'/0'/0/0", "", "", false)
+	assert.ErrorIs(t, err, ErrAddressMismatch)
+}
+
+func Test_ChainAlias(t *testing.T) {
+	for chainid, alias := range map[string]string{
+		"":                                      "P",
+		"11111111111111111111111111111111LpoYY": "P",
+		"2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByM": "X",
+		"yH8D7ThNJkxmtkuv2jgBa4P1Rn3Qpr4pPr7QYNfcdoS6k6HWp":  "C",
+	} {
+		assert.Equal(t, alias, ChainAlias(chainid))
+		_, err := SerializeChainID(chainid)
+		assert.NoError(t, err, chainid)
+	}
+	assert.Equal(t, "unknown", ChainAlias("unknown"))
+}
+
+func Test_GetPubKeyChainAlias(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, _, _ = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "fuji", "X")
+	fujiX, err := SerializeChainID("2JVSBoinj9C2J33VntvzYtVJNZdN2NKiwwKjcumHUWEb5DbBrm")
+	require.NoError(t, err)
+	require.Len(t, device.sent, 1)
+	assert.Contains(t, string(device.sent[0]), string(fujiX))
+
+	_, _, _ = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "P")
+	require.Len(t, device.sent, 2)
+	// [hrp | empty chain ID | path]
+	assert.Equal(t, byte(0), device.sent[1][5+1+len("avax")])
+
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "custom", "C")
+	assert.ErrorIs(t, err, ErrInvalidChainID)
+	assert.Len(t, device.sent, 2)
+}
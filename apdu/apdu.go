@@ -0,0 +1,115 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package apdu builds the commands understood by the Avalanche app, so instructions not wrapped
+// by ledger_avalanche_go yet can be sent with LedgerAvalanche.ExchangeRaw.
+package apdu
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxDataLen is the longest payload of a command, its length is sent in a single byte
+	MaxDataLen = 255
+	// MaxExtendedDataLen is the longest payload of a command with an extended length
+	MaxExtendedDataLen = 65535
+	// ChunkSize is the payload length used by the app to receive long messages
+	ChunkSize = 250
+
+	PayloadInit = 0x00
+	PayloadAdd  = 0x01
+	PayloadLast = 0x02
+
+	// FirstMessage is the P2 of the PayloadInit command of an upload
+	FirstMessage = 0x01
+)
+
+// ErrDataTooLong is returned when the payload of a command exceeds MaxDataLen
+var ErrDataTooLong = errors.New("APDU data exceeds 255 bytes")
+
+// Command is an APDU command sent to the device
+type Command struct {
+	CLA  byte
+	INS  byte
+	P1   byte
+	P2   byte
+	Data []byte
+}
+
+// New returns a command with the concatenation of data as payload
+func New(cla, ins, p1, p2 byte, data ...[]byte) Command {
+	var payload []byte
+	for _, d := range data {
+		payload = append(payload, d...)
+	}
+	return Command{CLA: cla, INS: ins, P1: p1, P2: p2, Data: payload}
+}
+
+// Bytes serializes the command as [CLA | INS | P1 | P2 | Lc | data]
+func (c Command) Bytes() ([]byte, error) {
+	if len(c.Data) > MaxDataLen {
+		return nil, fmt.Errorf("%w: %d bytes", ErrDataTooLong, len(c.Data))
+	}
+
+	message := make([]byte, 0, 5+len(c.Data))
+	message = append(message, c.CLA, c.INS, c.P1, c.P2, byte(len(c.Data)))
+	return append(message, c.Data...), nil
+}
+
+// ExtendedBytes serializes the command with an ISO 7816 extended length, as
+// [CLA | INS | P1 | P2 | 0 | Lc (2 bytes) | data], for transports relaying payloads longer than
+// MaxDataLen. The Ledger apps only accept commands serialized with Bytes.
+func (c Command) ExtendedBytes() ([]byte, error) {
+	if len(c.Data) > MaxExtendedDataLen {
+		return nil, fmt.Errorf("%w: %d bytes", ErrDataTooLong, len(c.Data))
+	}
+
+	message := make([]byte, 0, 7+len(c.Data))
+	message = append(message, c.CLA, c.INS, c.P1, c.P2, 0, byte(len(c.Data)>>8), byte(len(c.Data)))
+	return append(message, c.Data...), nil
+}
+
+// Chunks splits data in chunks of ChunkSize bytes, the last one possibly shorter
+func Chunks(data []byte) [][]byte {
+	chunks := make([][]byte, 0, (len(data)+ChunkSize-1)/ChunkSize)
+	for len(data) > ChunkSize {
+		chunks = append(chunks, data[:ChunkSize])
+		data = data[ChunkSize:]
+	}
+	if len(data) > 0 {
+		chunks = append(chunks, data)
+	}
+	return chunks
+}
+
+// Upload returns the commands sending a message the way the app receives it: a PayloadInit
+// command carrying init, e.g. the serialized signing path, followed by the message in chunks
+// sent with PayloadAdd, the last one with PayloadLast.
+func Upload(cla, ins byte, init []byte, message []byte) []Command {
+	commands := []Command{New(cla, ins, PayloadInit, FirstMessage, init)}
+
+	chunks := Chunks(message)
+	for idx, chunk := range chunks {
+		p1 := byte(PayloadAdd)
+		if idx == len(chunks)-1 {
+			p1 = PayloadLast
+		}
+		commands = append(commands, New(cla, ins, p1, 0, chunk))
+	}
+	return commands
+}
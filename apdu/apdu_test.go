@@ -0,0 +1,68 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package apdu
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CommandBytes(t *testing.T) {
+	message, err := New(0x80, 0x02, 0x01, 0x00, []byte{0xaa}, []byte{0xbb, 0xcc}).Bytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x80, 0x02, 0x01, 0x00, 0x03, 0xaa, 0xbb, 0xcc}, message)
+
+	_, err = Command{Data: make([]byte, MaxDataLen+1)}.Bytes()
+	assert.ErrorIs(t, err, ErrDataTooLong)
+}
+
+func Test_CommandExtendedBytes(t *testing.T) {
+	message, err := New(0x80, 0x02, 0, 0, make([]byte, 300)).ExtendedBytes()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x80, 0x02, 0, 0, 0, 0x01, 0x2c}, message[:7])
+	assert.Len(t, message, 7+300)
+
+	_, err = New(0x80, 0x02, 0, 0, make([]byte, MaxExtendedDataLen+1)).ExtendedBytes()
+	assert.ErrorIs(t, err, ErrDataTooLong)
+}
+
+func Test_Chunks(t *testing.T) {
+	data := bytes.Repeat([]byte{0x01}, 2*ChunkSize+10)
+
+	chunks := Chunks(data)
+	require.Len(t, chunks, 3)
+	assert.Len(t, chunks[0], ChunkSize)
+	assert.Len(t, chunks[2], 10)
+	assert.Equal(t, data, bytes.Join(chunks, nil))
+
+	assert.Len(t, Chunks(data[:ChunkSize]), 1)
+	assert.Empty(t, Chunks(nil))
+}
+
+func Test_Upload(t *testing.T) {
+	commands := Upload(0x80, 0x05, []byte{0x03}, make([]byte, ChunkSize+1))
+
+	require.Len(t, commands, 3)
+	assert.Equal(t, Command{CLA: 0x80, INS: 0x05, P1: PayloadInit, P2: FirstMessage, Data: []byte{0x03}}, commands[0])
+	assert.Equal(t, byte(PayloadAdd), commands[1].P1)
+	assert.Len(t, commands[1].Data, ChunkSize)
+	assert.Equal(t, byte(PayloadLast), commands[2].P1)
+	assert.Len(t, commands[2].Data, 1)
+}
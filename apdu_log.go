@@ -0,0 +1,102 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// APDUDirection tells whether a logged APDU was sent to or received from the device
+type APDUDirection int
+
+const (
+	APDUSent APDUDirection = iota
+	APDUReceived
+)
+
+func (d APDUDirection) String() string {
+	if d == APDUSent {
+		return "=>"
+	}
+	return "<="
+}
+
+// APDULogger receives every APDU exchanged with the device: the commands sent, and the
+// responses received followed by their status word. apdu must not be modified.
+type APDULogger func(direction APDUDirection, apdu []byte)
+
+// SetAPDULogger sets the function receiving every APDU exchanged with the device,
+// e.g. NewAPDUTraceLogger. A nil logger disables logging.
+func (ledger *LedgerAvalanche) SetAPDULogger(logger APDULogger) {
+	ledger.state.Lock()
+	defer ledger.state.Unlock()
+	ledger.apduLogger = logger
+}
+
+// currentAPDULogger returns the logger of SetAPDULogger, which may change during exchanges
+func (ledger *LedgerAvalanche) currentAPDULogger() APDULogger {
+	ledger.state.Lock()
+	defer ledger.state.Unlock()
+	return ledger.apduLogger
+}
+
+// NewAPDUTraceLogger returns an APDULogger writing a line per APDU to w, as a hex dump.
+// With redact set, the data of commands and responses is replaced by its length, so
+// transactions, addresses and signatures are not recorded, while the command headers and
+// status words are kept to trace the protocol.
+func NewAPDUTraceLogger(w io.Writer, redact bool) APDULogger {
+	return func(direction APDUDirection, apdu []byte) {
+		if !redact {
+			fmt.Fprintf(w, "%s %s\n", direction, hex.EncodeToString(apdu))
+			return
+		}
+
+		// keep the command header or the status word
+		if direction == APDUSent {
+			header := 5
+			if len(apdu) < header {
+				header = len(apdu)
+			}
+			fmt.Fprintf(w, "%s %s [%d bytes]\n", direction, hex.EncodeToString(apdu[:header]), len(apdu)-header)
+		} else {
+			data := len(apdu) - 2
+			if data < 0 {
+				data = 0
+			}
+			fmt.Fprintf(w, "%s [%d bytes] %s\n", direction, data, hex.EncodeToString(apdu[data:]))
+		}
+	}
+}
+
+// logAPDUs logs an exchange with the device. The status word is rebuilt, as the transport
+// strips it from the response. Transport failures have no response to log.
+func logAPDUs(logger APDULogger, command, response []byte, err error) {
+	logger(APDUSent, command)
+
+	sw := uint16(NoErrors)
+	if err != nil {
+		code, ok := parseStatusWord(err)
+		if !ok {
+			return
+		}
+		sw = uint16(code)
+	}
+	logger(APDUReceived, binary.BigEndian.AppendUint16(append([]byte{}, response...), sw))
+}
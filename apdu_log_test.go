@@ -0,0 +1,91 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_APDULogger(t *testing.T) {
+	calls := 0
+	ledger := newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+		calls++
+		switch calls {
+		case 1:
+			return []byte{0, 0, 6, 5}, nil
+		case 2:
+			return nil, statusError(TransactionRejected)
+		}
+		return nil, errors.New("hidapi: failed to write to device")
+	}})
+
+	var buf bytes.Buffer
+	ledger.SetAPDULogger(NewAPDUTraceLogger(&buf, false))
+
+	_, _ = ledger.GetVersion()
+	_, _ = ledger.GetVersion()
+	_, _ = ledger.GetVersion()
+
+	assert.Equal(t, "=> 8000000000\n"+
+		"<= 000006059000\n"+
+		"=> 8000000000\n"+
+		"<= 6986\n"+
+		"=> 8000000000\n", buf.String())
+}
+
+func Test_APDULoggerRedacted(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: replies(make([]byte, SIGNATURE_LEN))})
+
+	var buf bytes.Buffer
+	ledger.SetAPDULogger(NewAPDUTraceLogger(&buf, true))
+
+	_, _ = ledger.GetWalletID()
+	ledger.SetAPDULogger(nil)
+	_, _ = ledger.GetWalletID()
+
+	assert.Equal(t, "=> 8001000000 [0 bytes]\n<= [65 bytes] 9000\n", buf.String())
+}
+
+func Test_SetAPDULoggerWhileExchanging(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, statusError(TransactionRejected)
+	}})
+
+	// run with -race: the logger and the translator are replaced while exchanges read them
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			_, err := ledger.GetVersion()
+			assert.Error(t, err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			ledger.SetAPDULogger(func(APDUDirection, []byte) {})
+			ledger.SetErrorTranslator(nil)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,201 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnexpectedApp is returned when the running app does not match the expected fingerprint
+var ErrUnexpectedApp = errors.New("unexpected app running on the device")
+
+// GetAppInfo returns the name, version and flags of the running app as reported by the device OS
+func (ledger *LedgerAvalanche) GetAppInfo() (*AppInfo, error) {
+	message := []byte{CLA_BOLOS, INS_GET_APP_INFO, 0, 0, 0}
+	response, err := ledger.exchange(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAppInfoResponse(response)
+}
+
+// OS flags, in the first byte of the app info flags and in the dashboard device info flags
+const (
+	osFlagRecovery     = 0x01
+	osFlagOnboarded    = 0x04
+	osFlagPINValidated = 0x80
+)
+
+// Onboarded reports whether the device has been set up with a seed
+func (info *AppInfo) Onboarded() bool {
+	return len(info.Flags) > 0 && info.Flags[0]&osFlagOnboarded != 0
+}
+
+// PINValidated reports whether the device was unlocked with its PIN. Apps can only run on an
+// unlocked device, but the flag is cleared while it is locked again, e.g. after the auto-lock delay.
+func (info *AppInfo) PINValidated() bool {
+	return len(info.Flags) > 0 && info.Flags[0]&osFlagPINValidated != 0
+}
+
+// RecoveryMode reports whether the device was started in recovery mode
+func (info *AppInfo) RecoveryMode() bool {
+	return len(info.Flags) > 0 && info.Flags[0]&osFlagRecovery != 0
+}
+
+// GetDeviceInfo returns the target ID, firmware versions and flags of the device. It is answered
+// by the dashboard only: while an app runs, e.g. the Avalanche app, use GetAppInfo instead.
+func (ledger *LedgerAvalanche) GetDeviceInfo() (*FirmwareInfo, error) {
+	message := []byte{CLA_DASHBOARD, INS_GET_DEVICE_INFO, 0, 0, 0}
+	response, err := ledger.exchange(message)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDeviceInfoResponse(response)
+}
+
+// Onboarded reports whether the device has been set up with a seed
+func (info *FirmwareInfo) Onboarded() bool {
+	return len(info.Flags) > 0 && info.Flags[0]&osFlagOnboarded != 0
+}
+
+// PINValidated reports whether the device is unlocked
+func (info *FirmwareInfo) PINValidated() bool {
+	return len(info.Flags) > 0 && info.Flags[0]&osFlagPINValidated != 0
+}
+
+// RecoveryMode reports whether the device was started in recovery mode
+func (info *FirmwareInfo) RecoveryMode() bool {
+	return len(info.Flags) > 0 && info.Flags[0]&osFlagRecovery != 0
+}
+
+// osFlags returns the OS flags of the device info, or of the app info while an app runs and
+// does not answer the dashboard
+func (ledger *LedgerAvalanche) osFlags() ([]byte, error) {
+	deviceInfo, err := ledger.GetDeviceInfo()
+	if err == nil {
+		return deviceInfo.Flags, nil
+	}
+	if !isStatus(err, ClaNotSupported, InstructionNotSupported) {
+		return nil, err
+	}
+
+	appInfo, err := ledger.GetAppInfo()
+	if err != nil {
+		return nil, err
+	}
+	return appInfo.Flags, nil
+}
+
+// IsOnboarded reports whether the device has been set up with a seed, so that users of a new
+// device can be told to complete its setup in Ledger Live rather than shown an exchange error
+func (ledger *LedgerAvalanche) IsOnboarded() (bool, error) {
+	flags, err := ledger.osFlags()
+	if err != nil {
+		return false, err
+	}
+	return (&FirmwareInfo{Flags: flags}).Onboarded(), nil
+}
+
+// IsPinValidated reports whether the device was unlocked with its PIN
+func (ledger *LedgerAvalanche) IsPinValidated() (bool, error) {
+	flags, err := ledger.osFlags()
+	if err != nil {
+		return false, err
+	}
+	return (&FirmwareInfo{Flags: flags}).PINValidated(), nil
+}
+
+// ErrExpertModeNotReported is returned by IsExpertMode when the running app does not report expert mode
+var ErrExpertModeNotReported = errors.New("the app does not report expert mode")
+
+// appFlagExpertMode is set in the second byte of the app info flags when expert mode is enabled.
+// Older apps only send the first byte.
+const appFlagExpertMode = 0x01
+
+// GetCapabilities returns the app settings reported in the app info flags
+func (ledger *LedgerAvalanche) GetCapabilities() (*Capabilities, error) {
+	appInfo, err := ledger.GetAppInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	capabilities := &Capabilities{}
+	if len(appInfo.Flags) > 1 {
+		capabilities.ExpertModeReported = true
+		capabilities.ExpertMode = appInfo.Flags[1]&appFlagExpertMode != 0
+	}
+	return capabilities, nil
+}
+
+// IsExpertMode reports whether expert mode is enabled on the device, which changes the review
+// screens the user is shown. It cannot be toggled from the host. Returns ErrExpertModeNotReported
+// for apps that do not report it.
+func (ledger *LedgerAvalanche) IsExpertMode() (bool, error) {
+	capabilities, err := ledger.GetCapabilities()
+	if err != nil {
+		return false, err
+	}
+	if !capabilities.ExpertModeReported {
+		return false, ErrExpertModeNotReported
+	}
+	return capabilities.ExpertMode, nil
+}
+
+// GetAppFingerprint combines GetAppInfo and GetVersion into the fingerprint of the running app
+func (ledger *LedgerAvalanche) GetAppFingerprint() (*AppFingerprint, error) {
+	appInfo, err := ledger.GetAppInfo()
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := ledger.GetVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AppFingerprint{
+		Name:       appInfo.Name,
+		AppVersion: appInfo.Version,
+		Version:    *version,
+	}, nil
+}
+
+// VerifyAppIntegrity checks the running app against an expected fingerprint and returns
+// ErrUnexpectedApp on mismatch. Only the fields set in expected are compared, so callers
+// can pin e.g. the name alone; a zero Version is not compared.
+func (ledger *LedgerAvalanche) VerifyAppIntegrity(expected AppFingerprint) error {
+	found, err := ledger.GetAppFingerprint()
+	if err != nil {
+		return err
+	}
+
+	if expected.Name != "" && expected.Name != found.Name {
+		return fmt.Errorf("%w: name %q, expected %q", ErrUnexpectedApp, found.Name, expected.Name)
+	}
+	if expected.AppVersion != "" && expected.AppVersion != found.AppVersion {
+		return fmt.Errorf("%w: version %q, expected %q", ErrUnexpectedApp, found.AppVersion, expected.AppVersion)
+	}
+	if expected.Version != (VersionInfo{}) && expected.Version != found.Version {
+		return fmt.Errorf("%w: app version %v (mode %d), expected %v (mode %d)",
+			ErrUnexpectedApp, found.Version, found.Version.AppMode, expected.Version, expected.Version.AppMode)
+	}
+
+	return nil
+}
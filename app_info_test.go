@@ -0,0 +1,166 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appInfoResponse(name string, version string, flags ...byte) []byte {
+	response := []byte{1, byte(len(name))}
+	response = append(response, name...)
+	response = append(response, byte(len(version)))
+	response = append(response, version...)
+	response = append(response, byte(len(flags)))
+	return append(response, flags...)
+}
+
+func Test_GetAppInfo(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: replies(appInfoResponse("Avalanche", "0.6.5", 0x02))})
+
+	appInfo, err := ledger.GetAppInfo()
+	require.NoError(t, err)
+	assert.Equal(t, AppInfo{Name: "Avalanche", Version: "0.6.5", Flags: []byte{0x02}}, *appInfo)
+}
+
+func Test_GetAppInfoMalformed(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: replies([]byte{1, 20, 'A'})})
+
+	_, err := ledger.GetAppInfo()
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func Test_IsExpertMode(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: replies(appInfoResponse("Avalanche", "0.7.0", 0x02, 0x01))})
+	expert, err := ledger.IsExpertMode()
+	require.NoError(t, err)
+	assert.True(t, expert)
+
+	ledger = newMockLedger(&mockDevice{handler: replies(appInfoResponse("Avalanche", "0.7.0", 0x02, 0x00))})
+	expert, err = ledger.IsExpertMode()
+	require.NoError(t, err)
+	assert.False(t, expert)
+}
+
+func Test_IsExpertModeNotReported(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: replies(appInfoResponse("Avalanche", "0.6.5", 0x02))})
+
+	capabilities, err := ledger.GetCapabilities()
+	require.NoError(t, err)
+	assert.Equal(t, Capabilities{}, *capabilities)
+
+	ledger = newMockLedger(&mockDevice{handler: replies(appInfoResponse("Avalanche", "0.6.5", 0x02))})
+	_, err = ledger.IsExpertMode()
+	assert.ErrorIs(t, err, ErrExpertModeNotReported)
+}
+
+func Test_VerifyAppIntegrity(t *testing.T) {
+	newLedger := func() *LedgerAvalanche {
+		return newMockLedger(&mockDevice{handler: replies(
+			appInfoResponse("Avalanche", "0.6.5", 0x02),
+			[]byte{0, 0, 6, 5},
+		)})
+	}
+
+	expected := AppFingerprint{Name: "Avalanche", AppVersion: "0.6.5", Version: VersionInfo{0, 0, 6, 5}}
+	assert.NoError(t, newLedger().VerifyAppIntegrity(expected))
+	assert.NoError(t, newLedger().VerifyAppIntegrity(AppFingerprint{Name: "Avalanche"}))
+
+	err := newLedger().VerifyAppIntegrity(AppFingerprint{Name: "Ethereum"})
+	assert.ErrorIs(t, err, ErrUnexpectedApp)
+
+	err = newLedger().VerifyAppIntegrity(AppFingerprint{Version: VersionInfo{1, 0, 6, 5}})
+	assert.ErrorIs(t, err, ErrUnexpectedApp)
+}
+
+func Test_AppInfoFlags(t *testing.T) {
+	appInfo := AppInfo{Flags: []byte{osFlagOnboarded | osFlagPINValidated}}
+	assert.True(t, appInfo.Onboarded())
+	assert.True(t, appInfo.PINValidated())
+	assert.False(t, appInfo.RecoveryMode())
+
+	assert.False(t, (&AppInfo{}).Onboarded())
+}
+
+func Test_GetDeviceInfo(t *testing.T) {
+	response := []byte{0x33, 0x10, 0x00, 0x04}
+	response = append(response, 5)
+	response = append(response, "1.1.0"...)
+	response = append(response, 4, 0xa6, 0, 0, 0)
+	response = append(response, 5)
+	response = append(response, "4.04\x00"...)
+
+	device := &mockDevice{handler: replies(response)}
+	ledger := newMockLedger(device)
+
+	info, err := ledger.GetDeviceInfo()
+	require.NoError(t, err)
+	assert.Equal(t, FirmwareInfo{
+		TargetID:   0x33100004,
+		SEVersion:  "1.1.0",
+		MCUVersion: "4.04",
+		Flags:      []byte{0xa6, 0, 0, 0},
+	}, *info)
+	assert.Equal(t, []byte{CLA_DASHBOARD, INS_GET_DEVICE_INFO, 0, 0, 0}, device.sent[0])
+
+	ledger = newMockLedger(&mockDevice{handler: replies(response[:12])})
+	_, err = ledger.GetDeviceInfo()
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func Test_FirmwareInfoFlags(t *testing.T) {
+	info := FirmwareInfo{Flags: []byte{osFlagOnboarded, 0, 0, 0}}
+	assert.True(t, info.Onboarded())
+	assert.False(t, info.PINValidated())
+	assert.False(t, info.RecoveryMode())
+
+	assert.False(t, (&FirmwareInfo{}).Onboarded())
+}
+
+func Test_IsOnboarded(t *testing.T) {
+	// answered by the dashboard
+	response := []byte{0x33, 0x10, 0x00, 0x04, 0, 4, osFlagOnboarded | osFlagPINValidated, 0, 0, 0, 0}
+	ledger := newMockLedger(&mockDevice{handler: replies(response, response)})
+	onboarded, err := ledger.IsOnboarded()
+	require.NoError(t, err)
+	assert.True(t, onboarded)
+	validated, err := ledger.IsPinValidated()
+	require.NoError(t, err)
+	assert.True(t, validated)
+
+	// answered by the app
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[0] == CLA_DASHBOARD {
+			return nil, statusError(ClaNotSupported)
+		}
+		return appInfoResponse("Avalanche", "0.6.5", osFlagPINValidated), nil
+	}}
+	ledger = newMockLedger(device)
+	onboarded, err = ledger.IsOnboarded()
+	require.NoError(t, err)
+	assert.False(t, onboarded)
+	assert.Equal(t, byte(CLA_BOLOS), device.sent[1][0])
+
+	ledger = newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, statusError(DeviceLocked)
+	}})
+	_, err = ledger.IsPinValidated()
+	assert.ErrorIs(t, err, ErrLocked)
+}
@@ -0,0 +1,114 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AssetIDLength is the length of an X-chain asset ID
+const AssetIDLength = 32
+
+// Limits of the symbol and denomination of an asset created with a CreateAssetTx
+const (
+	maxAssetSymbolLength = 4
+	maxAssetDenomination = 32
+)
+
+// AssetInfo describes a custom X-chain asset, so its amounts can be shown with the right
+// symbol and decimals
+type AssetInfo struct {
+	AssetID [AssetIDLength]byte
+	Symbol  string
+	// Denomination is the number of decimals of the amounts of the asset, e.g. 9 for AVAX
+	Denomination uint8
+}
+
+// validate checks the symbol and denomination against the limits of the X-chain
+func (info AssetInfo) validate() error {
+	if info.Symbol == "" || len(info.Symbol) > maxAssetSymbolLength {
+		return fmt.Errorf("asset symbol %q should be 1 to %d characters long", info.Symbol, maxAssetSymbolLength)
+	}
+	if info.Denomination > maxAssetDenomination {
+		return fmt.Errorf("asset denomination %d is above %d", info.Denomination, maxAssetDenomination)
+	}
+	return nil
+}
+
+// FormatAmount formats amount, in the smallest unit of the asset, with its decimals and symbol
+// (e.g. "1.5 AVAX")
+func (info AssetInfo) FormatAmount(amount uint64) string {
+	digits := strconv.FormatUint(amount, 10)
+	decimals := int(info.Denomination)
+	if decimals == 0 {
+		return digits + " " + info.Symbol
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if fraction == "" {
+		return whole + " " + info.Symbol
+	}
+	return whole + "." + fraction + " " + info.Symbol
+}
+
+// AssetTable holds the metadata of the custom assets of a transaction, by asset ID
+type AssetTable map[[AssetIDLength]byte]AssetInfo
+
+// NewAssetTable returns the table of assets
+func NewAssetTable(assets ...AssetInfo) AssetTable {
+	table := make(AssetTable, len(assets))
+	for _, asset := range assets {
+		table[asset.AssetID] = asset
+	}
+	return table
+}
+
+// FormatAmount formats amount of the asset assetID, or returns false for an unknown asset
+func (table AssetTable) FormatAmount(assetID [AssetIDLength]byte, amount uint64) (string, bool) {
+	info, ok := table[assetID]
+	if !ok {
+		return "", false
+	}
+	return info.FormatAmount(amount), true
+}
+
+// assetInfoFeature is the instruction receiving asset metadata before a transaction. The app has
+// none yet and shows the amounts of custom assets without decimals; once it defines one, with its
+// descriptor format, SignWithAssets has to send the descriptors before signing.
+var assetInfoFeature = appFeature{name: "custom asset metadata"}
+
+// SignWithAssets works as Sign, first providing the metadata of the custom assets of the
+// transaction so the device shows their amounts with the right decimals. It fails with
+// ErrNotSupported when assets are given and the app cannot receive them, rather than
+// letting the user review amounts shown without decimals.
+func (ledger *LedgerAvalanche) SignWithAssets(pathPrefix string, signingPaths []string, message []byte, changePaths []string, assets AssetTable) (*ResponseSign, error) {
+	if len(assets) > 0 {
+		for _, asset := range assets {
+			if err := asset.validate(); err != nil {
+				return nil, err
+			}
+		}
+		if err := ledger.checkFeature(assetInfoFeature); err != nil {
+			return nil, err
+		}
+	}
+	return ledger.Sign(pathPrefix, signingPaths, message, changePaths)
+}
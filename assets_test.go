@@ -0,0 +1,75 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testAsset() AssetInfo {
+	var assetID [AssetIDLength]byte
+	assetID[0] = 0x21
+	return AssetInfo{AssetID: assetID, Symbol: "USDX", Denomination: 6}
+}
+
+func Test_AssetInfoValidate(t *testing.T) {
+	asset := testAsset()
+	assert.NoError(t, asset.validate())
+
+	asset.Symbol = ""
+	assert.Error(t, asset.validate())
+	asset.Symbol = "USDXY"
+	assert.Error(t, asset.validate())
+
+	asset = testAsset()
+	asset.Denomination = 33
+	assert.Error(t, asset.validate())
+}
+
+func Test_AssetFormatAmount(t *testing.T) {
+	asset := testAsset()
+	assert.Equal(t, "1.5 USDX", asset.FormatAmount(1500000))
+	assert.Equal(t, "0.000001 USDX", asset.FormatAmount(1))
+	assert.Equal(t, "0 USDX", asset.FormatAmount(0))
+	assert.Equal(t, "12 USDX", asset.FormatAmount(12000000))
+
+	asset.Denomination = 0
+	assert.Equal(t, "42 USDX", asset.FormatAmount(42))
+
+	table := NewAssetTable(testAsset())
+	formatted, ok := table.FormatAmount(testAsset().AssetID, 2500000)
+	assert.True(t, ok)
+	assert.Equal(t, "2.5 USDX", formatted)
+	_, ok = table.FormatAmount([AssetIDLength]byte{}, 1)
+	assert.False(t, ok)
+}
+
+func Test_SignWithAssets(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.SignWithAssets("m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil, NewAssetTable(testAsset()))
+	assert.ErrorIs(t, err, ErrNotSupported)
+	assert.Empty(t, device.sent, "nothing is signed when the assets cannot be provided")
+
+	_, err = ledger.SignWithAssets("m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil, nil)
+	require.NoError(t, err)
+	assert.NotEmpty(t, device.sent)
+}
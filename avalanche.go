@@ -17,220 +17,835 @@
 package ledger_avalanche_go
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io"
+	"time"
+
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/zondax/ledger-avalanche-go/apdu"
+	"github.com/zondax/ledger-avalanche-go/serialize"
 	"github.com/zondax/ledger-go"
 )
 
 // FindLedgerAvalancheApp FindLedgerAvalancheUserApp finds a Avax user app running in a ledger device
-func FindLedgerAvalancheApp() (_ *LedgerAvalanche, rerr error) {
-	ledgerAdmin := ledger_go.NewLedgerAdmin()
-	ledgerAPI, err := ledgerAdmin.Connect(0)
-	if err != nil {
+func FindLedgerAvalancheApp(opts ...Option) (*LedgerAvalanche, error) {
+	return FindLedgerAvalancheAppOnDevice(0, opts...)
+}
+
+// NewLedgerAvalanche uses an already connected device, e.g. a custom transport or an emulator.
+// Unlike FindLedgerAvalancheApp, it does not check the running app.
+func NewLedgerAvalanche(device ledger_go.LedgerDevice, opts ...Option) *LedgerAvalanche {
+	return newLedgerAvalanche(device, opts...)
+}
+
+// FindLedgerAvalancheAppOnDevice finds the Avax user app running in the ledger device at index,
+// in the order returned by ListLedgerDevices. No HID device is looked up when a transport is
+// given with WithDevice, or a Bluetooth scanner with WithBLEScanner.
+func FindLedgerAvalancheAppOnDevice(index int, opts ...Option) (*LedgerAvalanche, error) {
+	app := newLedgerAvalanche(nil, opts...)
+	if app.api == nil && app.bleScanner != nil {
+		device, err := app.connectBLE(index)
+		if err != nil {
+			return nil, err
+		}
+		app.api = device
+	}
+	if app.api == nil {
+		device, err := app.connect(index)
+		if err != nil {
+			return nil, err
+		}
+		app.api = device
+		app.deviceIndex = index
+		app.reconnectable = true
+	}
+
+	if _, err := app.open(); err != nil {
 		return nil, err
 	}
+	if app.keepAliveInterval > 0 {
+		app.startKeepAlive()
+	}
+	return app, nil
+}
+
+// connectDevice connects to the Ledger HID device at index
+var connectDevice = func(index int) (ledger_go.LedgerDevice, error) {
+	return ledger_go.NewLedgerAdmin().Connect(index)
+}
+
+// connect connects to the HID device at index, following the connection timeout and
+// retry policy of the ledger
+func (ledger *LedgerAvalanche) connect(index int) (device ledger_go.LedgerDevice, err error) {
+	for attempt := 0; attempt < ledger.connectAttempts || attempt == 0; attempt++ {
+		if attempt > 0 && ledger.connectBackoff > 0 {
+			time.Sleep(ledger.connectBackoff << uint(attempt-1))
+		}
+		if device, err = ledger.connectWithTimeout(index); err == nil {
+			return device, nil
+		}
+	}
+	return nil, err
+}
+
+func (ledger *LedgerAvalanche) connectWithTimeout(index int) (ledger_go.LedgerDevice, error) {
+	if ledger.connectTimeout <= 0 {
+		return ledger.connectUSB(index)
+	}
+
+	type result struct {
+		device ledger_go.LedgerDevice
+		err    error
+	}
+	done := make(chan result)
+	abandoned := make(chan struct{})
+	go func() {
+		device, err := ledger.connectUSB(index)
+		select {
+		case done <- result{device, err}:
+		case <-abandoned:
+			// nobody waits for this connection anymore
+			if err == nil {
+				device.Close()
+			}
+		}
+	}()
+
+	timer := time.NewTimer(ledger.connectTimeout)
+	defer timer.Stop()
 
+	select {
+	case r := <-done:
+		return r.device, r.err
+	case <-timer.C:
+		close(abandoned)
+		return nil, fmt.Errorf("ledger device (idx %d): %w", index, ErrConnectTimeout)
+	}
+}
+
+// openApp checks that a supported version of the Avax user app runs on device, closing it otherwise
+func openApp(device ledger_go.LedgerDevice, opts ...Option) (*LedgerAvalanche, error) {
+	return newLedgerAvalanche(device, opts...).open()
+}
+
+// open checks that a supported version of the Avax user app runs on the device, closing it otherwise
+func (ledger *LedgerAvalanche) open() (_ *LedgerAvalanche, rerr error) {
 	defer func() {
 		if rerr != nil {
-			ledgerAPI.Close()
+			ledger.api.Close()
 		}
 	}()
 
-	app := &LedgerAvalanche{ledgerAPI, VersionInfo{}}
-	appVersion, err := app.GetVersion()
-	if err != nil {
-		if err.Error() == "[APDU_CODE_CLA_NOT_SUPPORTED] CLA not supported" {
-			err = errors.New("are you sure the Avalanche app is open?")
+	if !ledger.skipVersionCheck {
+		appVersion, err := ledger.GetVersion()
+		if err != nil {
+			if isStatus(err, ClaNotSupported) {
+				err = fmt.Errorf("are you sure the Avalanche app is open? (%w)", err)
+			}
+			return nil, err
 		}
+
+		if err := ledger.applyVersionPolicy(ledger.CheckVersion(*appVersion)); err != nil {
+			return nil, err
+		}
+	}
+	if err := ledger.checkReleaseApp(); err != nil {
 		return nil, err
 	}
 
-	if err := app.CheckVersion(*appVersion); err != nil {
-		return nil, err
+	if ledger.expectedWalletID != nil {
+		if err := ledger.VerifyWalletID(ledger.expectedWalletID); err != nil {
+			return nil, err
+		}
 	}
 
-	return app, err
+	return ledger, nil
+}
+
+// checkReleaseApp refuses apps that are not release builds when RequireReleaseApp is set
+func (ledger *LedgerAvalanche) checkReleaseApp() error {
+	if !ledger.requireReleaseApp {
+		return nil
+	}
+	version, err := ledger.GetVersion()
+	if err != nil {
+		return err
+	}
+	if mode := version.Mode(); mode != AppModeRelease {
+		return fmt.Errorf("%w: %s build", ErrNonReleaseApp, mode)
+	}
+	return nil
 }
 
 // Close closes a connection with the Avalanche user app
 func (ledger *LedgerAvalanche) Close() error {
+	if ledger.stopKeepAlive != nil {
+		ledger.stopKeepAlive()
+	}
 	return ledger.api.Close()
 }
 
-// CheckVersion returns true if the App version is supported by this library
+// SetErrorTranslator sets the function used to produce the message of device errors,
+// e.g. to present them in the user's language. A nil translator restores the English messages.
+func (ledger *LedgerAvalanche) SetErrorTranslator(translator ErrorTranslator) {
+	ledger.state.Lock()
+	defer ledger.state.Unlock()
+	ledger.errorTranslator = translator
+}
+
+// currentErrorTranslator returns the translator of SetErrorTranslator, which may change during exchanges
+func (ledger *LedgerAvalanche) currentErrorTranslator() ErrorTranslator {
+	ledger.state.Lock()
+	defer ledger.state.Unlock()
+	return ledger.errorTranslator
+}
+
+// exchange sends an APDU to the device, reporting error status words as *APDUError
+// and transport failures as ErrDeviceDisconnected
+func (ledger *LedgerAvalanche) exchange(message []byte) ([]byte, error) {
+	return ledger.exchangeContext(context.Background(), message, 0, nil)
+}
+
+// ExchangeRaw sends a command built with the apdu package, e.g. an instruction not wrapped by
+// this package yet, and returns the response without the status word. Error status words are
+// reported as *APDUError and transport failures as ErrDeviceDisconnected.
+func (ledger *LedgerAvalanche) ExchangeRaw(cmd apdu.Command) ([]byte, error) {
+	message, err := cmd.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return ledger.exchange(message)
+}
+
+// transmit sends an APDU to the device within the signing flow of ctx, if any, see exchange
+func (ledger *LedgerAvalanche) transmit(ctx context.Context, message []byte) ([]byte, error) {
+	unlock, err := ledger.lockExchange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	response, err := ledger.transport(message)
+	if err != nil {
+		if code, ok := parseStatusWord(err); ok {
+			err = &APDUError{Code: code, translate: ledger.currentErrorTranslator()}
+		} else {
+			err = wrapTransportError(err)
+		}
+	}
+	return response, err
+}
+
+// exchangeContext works as exchange but gives up with timeoutErr once timeout elapses, or with
+// the context error once ctx is done. A zero timeout disables the timeout.
+// The abandoned exchange keeps waiting for the device in the background, so the session
+// should be considered out of sync after giving up.
+func (ledger *LedgerAvalanche) exchangeContext(ctx context.Context, message []byte, timeout time.Duration, timeoutErr error) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 && ctx.Done() == nil {
+		return ledger.transmit(ctx, message)
+	}
+
+	type result struct {
+		response []byte
+		err      error
+	}
+	done := make(chan result, 1)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		response, err := ledger.transmit(ctx, message)
+		done <- result{response, err}
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-expired:
+		ledger.abandon(finished)
+		return nil, timeoutErr
+	case <-ctx.Done():
+		ledger.abandon(finished)
+		return nil, ctx.Err()
+	}
+}
+
+// abandon records an exchange given up before the device answered, which completes once finished is closed
+func (ledger *LedgerAvalanche) abandon(finished <-chan struct{}) {
+	ledger.state.Lock()
+	defer ledger.state.Unlock()
+	ledger.pending = finished
+	ledger.desynced = true
+}
+
+// isDesynced reports whether an exchange was abandoned since the last session reset
+func (ledger *LedgerAvalanche) isDesynced() bool {
+	ledger.state.Lock()
+	defer ledger.state.Unlock()
+	return ledger.desynced
+}
+
+// ResetSession brings the session back to a known state: it waits for an exchange abandoned
+// after a timeout to complete and checks that the app answers a status query.
+// It runs automatically before signing after a timeout. Call it manually after an unexpected
+// response or a transport error, before retrying the operation.
+func (ledger *LedgerAvalanche) ResetSession() error {
+	return ledger.resetSession(context.Background())
+}
+
+func (ledger *LedgerAvalanche) resetSession(ctx context.Context) error {
+	ledger.state.Lock()
+	pending := ledger.pending
+	ledger.state.Unlock()
+
+	if err := ledger.waitPending(ctx); err != nil {
+		return err
+	}
+
+	if _, err := ledger.GetVersionContext(ctx); err != nil {
+		return err
+	}
+
+	ledger.state.Lock()
+	defer ledger.state.Unlock()
+	if ledger.pending == pending {
+		ledger.pending = nil
+		ledger.desynced = false
+	}
+	return nil
+}
+
+// CheckVersion returns an *ErrVersionTooLow if the version of the app running on the device
+// is older than RequiredVersion. The version is read from the device, ver is ignored.
 func (ledger *LedgerAvalanche) CheckVersion(ver VersionInfo) error {
+	return ledger.CheckMinVersion(ledger.minVersion)
+}
+
+// CheckMinVersion returns an *ErrVersionTooLow if the version of the app running on the device
+// is older than min, e.g. before using an instruction added in a later version
+func (ledger *LedgerAvalanche) CheckMinVersion(min VersionInfo) error {
 	version, err := ledger.GetVersion()
 	if err != nil {
 		return err
 	}
 
-	return CheckVersion(*version, VersionInfo{0, 0, 6, 5})
+	return CheckVersion(*version, min)
+}
+
+// RequiredVersion returns the oldest version of the app accepted by this ledger,
+// MinAppVersion unless set with WithMinAppVersion
+func (ledger *LedgerAvalanche) RequiredVersion() VersionInfo {
+	return ledger.minVersion
 }
 
 // GetVersion returns the current version of the Avalanche user app
 func (ledger *LedgerAvalanche) GetVersion() (*VersionInfo, error) {
+	return ledger.GetVersionContext(context.Background())
+}
+
+// GetVersionContext works as GetVersion but gives up once ctx is done
+func (ledger *LedgerAvalanche) GetVersionContext(ctx context.Context) (_ *VersionInfo, err error) {
+	defer ledger.observe(OperationGetVersion, time.Now(), &err)
+
 	message := []byte{CLA, INS_GET_VERSION, 0, 0, 0}
-	response, err := ledger.api.Exchange(message)
+	response, err := ledger.exchangeContext(ctx, message, 0, nil)
 
+	// A busy or locked device answers with an error status word and no version
 	if err != nil {
 		return nil, err
 	}
 
-	if len(response) < 4 {
-		return nil, errors.New("invalid response")
+	version, locked, err := parseVersionResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		return nil, &APDUError{Code: DeviceLocked, translate: ledger.currentErrorTranslator()}
 	}
 
-	ledger.version = VersionInfo{
-		AppMode: response[0],
-		Major:   response[1],
-		Minor:   response[2],
-		Patch:   response[3],
+	ledger.state.Lock()
+	ledger.version = version
+	ledger.state.Unlock()
+
+	return &version, nil
+}
+
+// GetWalletID returns the wallet ID of the device, which identifies the seed it holds
+func (ledger *LedgerAvalanche) GetWalletID() (_ []byte, err error) {
+	defer ledger.observe(OperationGetWalletID, time.Now(), &err)
+
+	message := []byte{CLA, INS_WALLET_ID, 0, 0, 0}
+	response, err := ledger.exchange(message)
+	if err != nil {
+		return nil, err
 	}
 
-	return &ledger.version, nil
+	if len(response) == 0 {
+		return nil, errors.New("invalid response")
+	}
+
+	return append([]byte{}, response...), nil
 }
 
-// GetPubKey returns the pubkey and hash
+// VerifyWalletID checks that the device holds the seed identified by walletID, e.g. a wallet ID
+// stored along with an account, before signing for that account. It returns ErrWalletIDMismatch
+// when another device or seed is in use.
+func (ledger *LedgerAvalanche) VerifyWalletID(walletID []byte) error {
+	found, err := ledger.GetWalletID()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(found, walletID) {
+		return fmt.Errorf("%w: found %x, expected %x", ErrWalletIDMismatch, found, walletID)
+	}
+	return nil
+}
+
+// GetPubKey returns the pubkey and hash. With show set, or when the ledger was created with
+// RequireOnDeviceConfirmation, the address is returned only once the user confirmed it on the device.
 func (ledger *LedgerAvalanche) GetPubKey(path string, show bool, hrp string, chainid string) (publicKey []byte, hash []byte, err error) {
-	if len(hrp) > 83 {
-		return nil, nil, errors.New("hrp len should be < 83 chars")
+	return ledger.GetPubKeyContext(context.Background(), path, show, hrp, chainid)
+}
+
+// GetPubKeyContext works as GetPubKey but gives up once ctx is done, e.g. when the user
+// does not confirm the address on the device
+func (ledger *LedgerAvalanche) GetPubKeyContext(ctx context.Context, path string, show bool, hrp string, chainid string) (publicKey []byte, hash []byte, err error) {
+	defer ledger.observe(OperationGetPubKey, time.Now(), &err)
+
+	p1 := byte(P1_ONLY_RETRIEVE)
+	if show || ledger.requireConfirmation {
+		p1 = byte(P1_SHOW_ADDRESS_IN_DEVICE)
 	}
 
-	serializedHRP, err := SerializeHrp(hrp)
-	if err != nil {
+	cacheKey := pubKeyCacheKey{path: path, hrp: hrp, chainID: chainid}
+	if ledger.pubKeyCache != nil && p1 == P1_ONLY_RETRIEVE {
+		if publicKey, hash, ok := ledger.pubKeyCache.get(cacheKey); ok {
+			return publicKey, hash, nil
+		}
+	}
+
+	if err := ledger.checkCommandVersion(ctx, CLA, INS_GET_ADDR); err != nil {
 		return nil, nil, err
 	}
 
-	serializedPath, err := SerializePath(path)
+	message, err := ledger.pubKeyAPDU(INS_GET_ADDR, p1, path, hrp, chainid)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	serializedChainID, err := SerializeChainID(chainid)
+	// The address is returned once the user confirmed it on the device
+	var timeout time.Duration
+	if p1 == P1_SHOW_ADDRESS_IN_DEVICE {
+		timeout = ledger.confirmationTimeout
+	}
+	response, err := ledger.exchangeContext(ctx, message, timeout, ErrConfirmationTimeout)
+
 	if err != nil {
 		return nil, nil, err
 	}
 
-	p1 := byte(P1_ONLY_RETRIEVE)
-	if show {
-		p1 = byte(P1_SHOW_ADDRESS_IN_DEVICE)
+	if len(response) < 35+len(hrp) {
+		return nil, nil, errors.New("Invalid response")
 	}
 
-	// Prepare message
-	header := []byte{CLA, INS_GET_ADDR, p1, 0, 0}
-	message := append(header, serializedHRP...)
-	message = append(message, serializedChainID...)
-	message = append(message, serializedPath...)
-	message[4] = byte(len(message) - len(header)) // update length
+	publicKey, hash, err = parsePubKeyResponse(response)
+	if err == nil && ledger.pubKeyCache != nil {
+		ledger.pubKeyCache.put(cacheKey, publicKey, hash)
+	}
+	return publicKey, hash, err
+}
 
-	response, err := ledger.api.Exchange(message)
+// pubKeyAPDU builds a public key request: [hrp | chainID | path]. With DefaultPathSerializer the
+// header is at most 1+83 + 1+32 + 1+4*10 = 162 bytes, so it always fits in an APDU. The chain
+// aliases "P", "X" and "C" are resolved on the network of hrp.
+func (ledger *LedgerAvalanche) pubKeyAPDU(ins, p1 byte, path string, hrp string, chainid string) ([]byte, error) {
+	chainid, err := serialize.ResolveChainAlias(chainid, hrp)
+	if err != nil {
+		return nil, err
+	}
+	if !ledger.permissiveInputs {
+		if err := serialize.ValidateHrp(hrp); err != nil {
+			return nil, err
+		}
+		if err := serialize.ValidateChainID(chainid); err != nil {
+			return nil, err
+		}
+	}
 
+	serializedHRP, err := ledger.serializer.SerializeHrp(hrp)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	if len(response) < 35+len(hrp) {
-		return nil, nil, errors.New("Invalid response")
+	serializedPath, err := ledger.serializer.SerializePath(path)
+	if err != nil {
+		return nil, err
 	}
 
-	// [publicKeyLen | publicKey | hash | errorCode]
-	publicKeyLen := response[0]
-	publicKey = response[1 : publicKeyLen+1]
-	hash = response[publicKeyLen+1 : len(response)]
+	serializedChainID, err := ledger.serializer.SerializeChainID(chainid)
+	if err != nil {
+		return nil, err
+	}
 
-	return publicKey, hash, err
+	return buildAPDU(CLA, ins, p1, 0, serializedHRP, serializedChainID, serializedPath)
+}
+
+// GetPubKeyWithFormat works as GetPubKey but returns the public key in the requested format,
+// converting the key returned by the device when needed
+func (ledger *LedgerAvalanche) GetPubKeyWithFormat(path string, show bool, hrp string, chainid string, format PublicKeyFormat) (publicKey []byte, hash []byte, err error) {
+	publicKey, hash, err = ledger.GetPubKey(path, show, hrp, chainid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	publicKey, err = FormatPublicKey(publicKey, format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return publicKey, hash, nil
 }
 
 func (ledger *LedgerAvalanche) Sign(pathPrefix string, signingPaths []string, message []byte, changePaths []string) (*ResponseSign, error) {
+	return ledger.SignContext(context.Background(), pathPrefix, signingPaths, message, changePaths)
+}
+
+// SignContext works as Sign but gives up once ctx is done, e.g. when the user never
+// reviews the transaction on the device
+func (ledger *LedgerAvalanche) SignContext(ctx context.Context, pathPrefix string, signingPaths []string, message []byte, changePaths []string) (*ResponseSign, error) {
+	return ledger.SignStreamContext(ctx, pathPrefix, signingPaths, changePaths, bytes.NewReader(message), len(message))
+}
+
+// SignStream works as Sign but reads the total bytes of the transaction from r while they are
+// uploaded, so the whole transaction never needs to be held in memory
+func (ledger *LedgerAvalanche) SignStream(pathPrefix string, signingPaths, changePaths []string, r io.Reader, total int) (*ResponseSign, error) {
+	return ledger.SignStreamContext(context.Background(), pathPrefix, signingPaths, changePaths, r, total)
+}
+
+// SignStreamContext works as SignStream but gives up once ctx is done
+func (ledger *LedgerAvalanche) SignStreamContext(ctx context.Context, pathPrefix string, signingPaths, changePaths []string, r io.Reader, total int) (*ResponseSign, error) {
+	return ledger.signStream(ctx, pathPrefix, signingPaths, changePaths, r, total, 0)
+}
+
+// signStream works as SignStreamContext, sending p2 with the payload chunks, see SignOptions
+func (ledger *LedgerAvalanche) signStream(ctx context.Context, pathPrefix string, signingPaths, changePaths []string, r io.Reader, total int, p2 byte) (_ *ResponseSign, err error) {
+	defer ledger.observe(OperationSign, time.Now(), &err)
+
+	// checked before the upload, so the user is not asked to approve a transaction to no avail
+	if ledger.bindTxHash {
+		if err := ledger.checkFeature(txHashFeature); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, release, err := ledger.beginSigning(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	signingPaths = RemoveDuplicates(signingPaths)
+	if err := ledger.checkSigningPaths(signingPaths); err != nil {
+		return nil, err
+	}
+
 	paths := signingPaths
 	if changePaths != nil {
-		paths = append(paths, changePaths...)
+		paths = append(append([]string{}, signingPaths...), changePaths...)
 		paths = RemoveDuplicates(paths)
 	}
 
-	serializedPath, err := SerializePath(pathPrefix)
+	serializedPath, err := ledger.serializer.SerializePath(pathPrefix)
 	if err != nil {
 		return nil, err
 	}
 
-	payloadType := PAYLOAD_INIT
-	p2 := FIRST_MESSAGE
-	header := []byte{CLA, INS_SIGN, byte(payloadType), byte(p2), byte(len(serializedPath))}
-	bytesToSend := append(header, serializedPath...)
-	_, err = ledger.api.Exchange(bytesToSend)
+	// The change path header goes first, followed by the transaction
+	header, err := serializeChangePaths(ledger.serializer, paths)
 	if err != nil {
-		return nil, errors.New("command rejected")
+		return nil, err
 	}
+	// The device signs the sha256 of the transaction, which is computed while it is uploaded
+	txHash := sha256.New()
+	msg := io.MultiReader(bytes.NewReader(header), io.TeeReader(io.LimitReader(r, int64(total)), txHash))
 
-	msg := ConcatMessageAndChangePath(message, paths)
+	if err := ledger.uploadPayload(ctx, INS_SIGN, p2, serializedPath, msg, len(header)+total); err != nil {
+		return nil, err
+	}
 
-	for i := 0; i < len(msg); i += CHUNK_SIZE {
-		end := i + CHUNK_SIZE
-		payloadType := PAYLOAD_ADD
-		p2 := 0
+	// Transaction was approved so start iterating over signing_paths to sign
+	// and collect each signature
+	return ledger.signAndCollect(ctx, pathPrefix, signingPaths, txHash.Sum(nil))
+}
 
-		if end > len(msg) {
-			end = len(msg)
-			payloadType = PAYLOAD_LAST
-		}
-		chunk := msg[i:end]
-		chunkSize := end - i
+// SignReader signs a transaction of size bytes streamed from r, e.g. a large UTXO consolidation
+// read from a file, with the keys of paths. Unlike SignStream it takes no change paths.
+func (ledger *LedgerAvalanche) SignReader(pathPrefix string, paths []string, r io.Reader, size int64) (*ResponseSign, error) {
+	return ledger.SignReaderContext(context.Background(), pathPrefix, paths, r, size)
+}
 
-		header := []byte{CLA, INS_SIGN, byte(payloadType), byte(p2), byte(chunkSize)}
-		bytesToSend := append(header, chunk...)
-		response, err := ledger.api.Exchange(bytesToSend)
-		if err != nil {
-			if err.Error() == "[APDU_CODE_BAD_KEY_HANDLE] The parameters in the data field are incorrect" {
-				// In this special case, we can extract additional info
-				errorMsg := string(response)
-				return nil, errors.New(errorMsg)
-			}
-			if err.Error() == "[APDU_CODE_DATA_INVALID] Referenced data reversibly blocked (invalidated)" {
-				errorMsg := string(response)
-				return nil, errors.New(errorMsg)
-			}
-			return nil, err
-		}
+// SignReaderContext works as SignReader but gives up once ctx is done
+func (ledger *LedgerAvalanche) SignReaderContext(ctx context.Context, pathPrefix string, paths []string, r io.Reader, size int64) (*ResponseSign, error) {
+	if size < 0 || int64(int(size)) != size {
+		return nil, ErrInvalidTransactionSize
 	}
+	return ledger.SignStreamContext(ctx, pathPrefix, paths, nil, r, int(size))
+}
 
-	// Transaction was approved so start iterating over signing_paths to sign
-	// and collect each signature
-	return SignAndCollect(signingPaths, ledger)
+// SignMessage signs an arbitrary message with the key at path (e.g "m/44'/9000'/0'/0/0").
+// The message is sent as is: the app frames it with the Avalanche message prefix and its
+// length before hashing, see AvalancheMessageHash. The signature is returned under the
+// path suffix and its last byte is the recovery id.
+func (ledger *LedgerAvalanche) SignMessage(path string, message []byte) (*ResponseSign, error) {
+	pathPrefix, suffix, err := SplitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return ledger.signMessage(pathPrefix, []string{suffix}, message)
+}
+
+// SignAvalancheMessage signs msg, e.g. an off-chain authentication challenge, with the keys at the
+// signing path suffixes of pathPrefix. The device displays the message text for the user to
+// approve, and signs the "\x1AAvalanche Signed Message:\n" prefixed digest, see AvalancheMessageHash.
+func (ledger *LedgerAvalanche) SignAvalancheMessage(pathPrefix string, signingPaths []string, msg string) (*ResponseSign, error) {
+	signingPaths = RemoveDuplicates(signingPaths)
+	if err := ledger.checkSigningPaths(signingPaths); err != nil {
+		return nil, err
+	}
+	return ledger.signMessage(pathPrefix, signingPaths, []byte(msg))
 }
 
+func (ledger *LedgerAvalanche) signMessage(pathPrefix string, signingPaths []string, message []byte) (_ *ResponseSign, err error) {
+	defer ledger.observe(OperationSignMessage, time.Now(), &err)
+
+	ctx, release, err := ledger.beginSigning(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	serializedPath, err := ledger.serializer.SerializePath(pathPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ledger.uploadPayload(ctx, INS_SIGN_MSG, 0, serializedPath, bytes.NewReader(message), len(message)); err != nil {
+		return nil, err
+	}
+
+	return ledger.signAndCollect(ctx, pathPrefix, signingPaths, AvalancheMessageHash(message))
+}
+
+// SignHash signs a precomputed 32-byte hash (e.g. from ComputeSignHash) with the keys at the signing
+// path suffixes of pathPrefix, without uploading the transaction. The device shows the hash for
+// the user to approve instead of the transaction details, so it must be enabled with AllowBlindSigning.
 func (ledger *LedgerAvalanche) SignHash(pathPrefix string, signingPaths []string, hash []byte) (*ResponseSign, error) {
+	return ledger.SignHashContext(context.Background(), pathPrefix, signingPaths, hash)
+}
+
+// SignHashContext works as SignHash but gives up once ctx is done
+func (ledger *LedgerAvalanche) SignHashContext(ctx context.Context, pathPrefix string, signingPaths []string, hash []byte) (_ *ResponseSign, err error) {
+	defer ledger.observe(OperationSignHash, time.Now(), &err)
+
 	if len(hash) != HASH_LEN {
 		return nil, errors.New("wrong hash size")
 	}
 
-	serializedPath, err := SerializePath(pathPrefix)
+	signingPaths = RemoveDuplicates(signingPaths)
+	if err := ledger.checkSigningPaths(signingPaths); err != nil {
+		return nil, err
+	}
+
+	if err := ledger.checkBlindSigning(ctx, pathPrefix, signingPaths, hash); err != nil {
+		return nil, err
+	}
+
+	ctx, release, err := ledger.beginSigning(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
-	header := []byte{CLA, INS_SIGN_HASH, FIRST_MESSAGE, byte(0x00), byte(len(serializedPath) + len(hash))}
-	bytesToSend := append(header, serializedPath...)
-	bytesToSend = append(bytesToSend, hash...)
-	firstResponse, err := ledger.api.Exchange(bytesToSend)
+	if err := ledger.checkCommandVersion(ctx, CLA, INS_SIGN_HASH); err != nil {
+		return nil, err
+	}
 
+	serializedPath, err := ledger.serializer.SerializePath(pathPrefix)
 	if err != nil {
-		return nil, errors.New("command rejected")
+		return nil, err
+	}
+
+	bytesToSend, err := buildAPDU(CLA, INS_SIGN_HASH, FIRST_MESSAGE, 0x00, serializedPath, hash)
+	if err != nil {
+		return nil, err
+	}
+	ledger.reportProgress(SignAwaitingConfirmation, 0, 0)
+	firstResponse, err := ledger.exchangeContext(ctx, bytesToSend, ledger.confirmationTimeout, ErrConfirmationTimeout)
+
+	if err == ErrConfirmationTimeout || ctx.Err() != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("command rejected: %w", err)
 	}
 	if len(firstResponse) != 0 {
 		return nil, errors.New("wrong response")
 	}
 
-	return SignAndCollect(signingPaths, ledger)
+	return ledger.signAndCollect(ctx, pathPrefix, signingPaths, hash)
+}
+
+// checkSigningPaths validates the number of signing paths of a request
+func (ledger *LedgerAvalanche) checkSigningPaths(signingPaths []string) error {
+	if len(signingPaths) == 0 {
+		return ErrNoSigningPaths
+	}
+	if ledger.maxSigningPaths > 0 && len(signingPaths) > ledger.maxSigningPaths {
+		return fmt.Errorf("%w: %d paths, at most %d allowed", ErrTooManySigningPaths, len(signingPaths), ledger.maxSigningPaths)
+	}
+	return nil
+}
+
+// uploadPayload initializes a signing session with the path prefix and streams the total bytes of msg
+// to the device in chunks sent with p2. An upload interrupted after the session was initialized is aborted,
+// see abortUpload.
+func (ledger *LedgerAvalanche) uploadPayload(ctx context.Context, ins, p2 byte, serializedPath []byte, msg io.Reader, total int) (err error) {
+	if err := ledger.checkCommandVersion(ctx, CLA, ins); err != nil {
+		return err
+	}
+	if ledger.isDesynced() {
+		if err := ledger.resetSession(ctx); err != nil {
+			return err
+		}
+	}
+
+	header := []byte{CLA, ins, PAYLOAD_INIT, FIRST_MESSAGE, byte(len(serializedPath))}
+	bytesToSend := append(header, serializedPath...)
+	_, err = ledger.exchangeContext(ctx, bytesToSend, ledger.exchangeTimeout, ErrExchangeTimeout)
+	if err == ErrExchangeTimeout || ctx.Err() != nil {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("command rejected: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			ledger.abortUpload(ctx, bytesToSend, err)
+		}
+	}()
+
+	size := ledger.uploadChunkSize()
+	chunks := (total + size - 1) / size
+	chunk := make([]byte, size)
+	for sent := 0; sent < total; {
+		chunkSize := size
+		if total-sent < chunkSize {
+			chunkSize = total - sent
+		}
+		if _, err := io.ReadFull(msg, chunk[:chunkSize]); err != nil {
+			return fmt.Errorf("message is shorter than %d bytes: %w", total, io.ErrUnexpectedEOF)
+		}
+		sent += chunkSize
+
+		payloadType := PAYLOAD_ADD
+		if sent == total {
+			payloadType = PAYLOAD_LAST
+		}
+
+		ledger.reportProgress(SignUploading, (sent-1)/size+1, chunks)
+
+		// Once the last chunk is received the device waits for the user to review the transaction
+		timeout, timeoutErr := ledger.exchangeTimeout, ErrExchangeTimeout
+		if payloadType == PAYLOAD_LAST {
+			timeout, timeoutErr = ledger.confirmationTimeout, ErrConfirmationTimeout
+			ledger.reportProgress(SignAwaitingConfirmation, 0, 0)
+		}
+
+		bytesToSend, err := ledger.buildCommand(CLA, ins, byte(payloadType), p2, chunk[:chunkSize])
+		if err != nil {
+			return err
+		}
+		response, err := ledger.exchangeContext(ctx, bytesToSend, timeout, timeoutErr)
+		if err != nil {
+			var apduErr *APDUError
+			if errors.As(err, &apduErr) && isStatus(err, DataIsInvalid, DataInvalidated) {
+				// In this special case, we can extract additional info
+				err = apduErr.withPayload(response)
+			}
+			return &ChunkError{
+				Index: (sent - 1) / size,
+				Count: chunks,
+				Start: sent - chunkSize,
+				End:   sent,
+				Err:   err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// abortUpload discards the partial payload held by the app after an upload failed with err, so
+// the next operation does not fail on it: the session is initialized again with init, the
+// command that started the upload. The user rejecting the transaction already reset the app.
+// When the device may not be reached, e.g. after a timeout or a transport error, the session is
+// marked out of sync instead, to be reset before the next upload.
+func (ledger *LedgerAvalanche) abortUpload(ctx context.Context, init []byte, err error) {
+	if errors.Is(err, ErrUserRejected) || ledger.isDesynced() {
+		return
+	}
+	var apduErr *APDUError
+	if errors.As(err, &apduErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		if _, initErr := ledger.exchangeContext(ctx, init, ledger.exchangeTimeout, ErrExchangeTimeout); initErr == nil {
+			return
+		}
+	}
+	ledger.markDesynced()
 }
 
+// markDesynced records that the session must be reset before the next upload
+func (ledger *LedgerAvalanche) markDesynced() {
+	ledger.state.Lock()
+	defer ledger.state.Unlock()
+	ledger.desynced = true
+}
+
+// SignAndCollect collects the signature of each signing path over the hash held by the device
 func SignAndCollect(signingPaths []string, ledger *LedgerAvalanche) (*ResponseSign, error) {
+	ctx, release, err := ledger.beginSigning(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return ledger.signAndCollect(ctx, "", signingPaths, nil)
+}
+
+// signAndCollect works as SignAndCollect and reports hash as the signed digest,
+// unless the app returns the digest along with the signatures, which must then be hash with
+// BindTransactionHash. With WithSignatureVerification,
+// the signatures are checked against the keys under pathPrefix, when known.
+func (ledger *LedgerAvalanche) signAndCollect(ctx context.Context, pathPrefix string, signingPaths []string, hash []byte) (*ResponseSign, error) {
 	// Where each pair path_suffix, signature are stored
 	signatures := make(map[string][]byte)
+	ordered := make([]PathSignature, 0, len(signingPaths))
 
 	for idx, suffix := range signingPaths {
-		pathBuf, err := SerializePathSuffix(suffix)
+		pathBuf, err := ledger.serializer.SerializePathSuffix(suffix)
 		if err != nil {
 			return nil, err
 		}
@@ -240,21 +855,62 @@ func SignAndCollect(signingPaths []string, ledger *LedgerAvalanche) (*ResponseSi
 			p1 = NEXT_MESSAGE
 		}
 
+		ledger.reportProgress(SignCollectingSignatures, idx+1, len(signingPaths))
+
 		// Send path to sign hash that should be in device's ram memory
 		header := []byte{CLA, INS_SIGN_HASH, byte(p1), byte(0x00), byte(len(pathBuf))}
 		bytesToSend := append(header, pathBuf...)
-		response, err := ledger.api.Exchange(bytesToSend)
+		response, err := ledger.exchangeContext(ctx, bytesToSend, 0, nil)
 
 		if err != nil {
+			var apduErr *APDUError
+			if errors.As(err, &apduErr) {
+				return &ResponseSign{
+					Hash:              hash,
+					Signature:         signatures,
+					SignaturesOrdered: ordered,
+					ReturnCode:        apduErr.Code,
+					ErrorMessage:      apduErr.Error(),
+				}, err
+			}
 			return nil, err
 		}
+
+		// [hash | signature] when the app reports the digest it signed
+		reportsHash := len(response) == HASH_LEN+SIGNATURE_LEN
+		if ledger.bindTxHash && hash != nil && !reportsHash {
+			return nil, fmt.Errorf("%w: the app does not report the hash it signed to bind it", ErrNotSupported)
+		}
+		if reportsHash {
+			if ledger.bindTxHash && hash != nil && !bytes.Equal(hash, response[:HASH_LEN]) {
+				return nil, fmt.Errorf("%w: computed %x, device signed %x", ErrHashMismatch, hash, response[:HASH_LEN])
+			}
+			hash = response[:HASH_LEN]
+			response = response[HASH_LEN:]
+		}
+		if ledger.enforceLowS {
+			response = NormalizeLowS(response)
+		}
 		signatures[suffix] = response
+		ordered = append(ordered, newPathSignature(suffix, response, hash))
+	}
+
+	response := &ResponseSign{Hash: hash, Signature: signatures, SignaturesOrdered: ordered, ReturnCode: NoErrors}
+	if ledger.verifySignatures && pathPrefix != "" {
+		if err := ledger.verifyResponse(ctx, pathPrefix, response); err != nil {
+			return nil, err
+		}
 	}
+	return response, nil
+}
 
-	return &ResponseSign{nil, signatures}, nil
+func newPathSignature(path string, signature, hash []byte) PathSignature {
+	return PathSignature{Path: path, Signature: signature, Hash: hash}
 }
 
-func (ledger *LedgerAvalanche) VerifyMultipleSignatures(response ResponseSign, messageHash []byte, rootPath string, signingPaths []string, hrp string, chainID string) error {
+func (ledger *LedgerAvalanche) VerifyMultipleSignatures(response ResponseSign, messageHash []byte, rootPath string, signingPaths []string, hrp string, chainID string) (rerr error) {
+	defer recoverMalformedResponse(&rerr)
+
 	if len(response.Signature) != len(signingPaths) {
 		return errors.New("sizes of signatures and paths don't match")
 	}
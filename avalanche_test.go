@@ -17,13 +17,22 @@
 package ledger_avalanche_go
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/zondax/ledger-avalanche-go/apdu"
+	"github.com/zondax/ledger-avalanche-go/serialize"
+	"github.com/zondax/ledger-go"
 )
 
 // Ledger Test Mnemonic: equip will roof matter pink blind book anxiety banner elbow sun young
@@ -300,7 +309,7 @@ func Test_UserSignHash(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping test in short mode.")
 	}
-	userApp, err := FindLedgerAvalancheApp()
+	userApp, err := FindLedgerAvalancheApp(AllowBlindSigning(true))
 	if err != nil {
 		t.Fatalf(err.Error())
 	}
@@ -326,3 +335,699 @@ func Test_UserSignHash(t *testing.T) {
 		t.Fatalf("Detected error, err: %s\n", err.Error())
 	}
 }
+
+func Test_SignAndCollectOrdered(t *testing.T) {
+	signingPaths := []string{"5/8", "0/0", "4/8", "0/1"}
+
+	var responses [][]byte
+	for i := range signingPaths {
+		responses = append(responses, []byte{byte(i)})
+	}
+	ledger := newMockLedger(&mockDevice{handler: replies(responses...)})
+
+	response, err := SignAndCollect(signingPaths, ledger)
+	require.NoError(t, err)
+	require.Len(t, response.SignaturesOrdered, len(signingPaths))
+
+	for i, suffix := range signingPaths {
+		assert.Equal(t, suffix, response.SignaturesOrdered[i].Path)
+		assert.Equal(t, []byte{byte(i)}, response.SignaturesOrdered[i].Signature)
+		assert.Equal(t, response.Signature[suffix], response.SignaturesOrdered[i].Signature)
+	}
+}
+
+func Test_SignAndCollectLowS(t *testing.T) {
+	signature, _ := hex.DecodeString("d6844ac6fc813791fcc9030566ae54b1b8f7a70d67fb3252d7a6096c8fe282153625aff848e6e787334f4bc7ee347a7be78857cec74d81ff61f594b261c731ff01")
+	malleated := highS(signature)
+
+	ledger := newMockLedger(&mockDevice{handler: replies(malleated)})
+	response, err := SignAndCollect([]string{"0/0"}, ledger)
+	require.NoError(t, err)
+	assert.Equal(t, signature, response.Signature["0/0"])
+
+	ledger = newMockLedger(&mockDevice{handler: replies(malleated)}, WithEnforceLowS(false))
+	response, err = SignAndCollect([]string{"0/0"}, ledger)
+	require.NoError(t, err)
+	assert.Equal(t, malleated, response.Signature["0/0"])
+}
+
+func Test_SignMessage(t *testing.T) {
+	message := []byte("Hello Avalanche!")
+	publicKey, _ := hex.DecodeString("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2")
+	signature, _ := hex.DecodeString("d6844ac6fc813791fcc9030566ae54b1b8f7a70d67fb3252d7a6096c8fe282153625aff848e6e787334f4bc7ee347a7be78857cec74d81ff61f594b261c731ff01")
+
+	device := &mockDevice{handler: replies([]byte{}, []byte{}, signature)}
+	ledger := newMockLedger(device)
+
+	response, err := ledger.SignMessage("m/44'/9000'/0'/0/0", message)
+	require.NoError(t, err)
+
+	require.Len(t, device.sent, 3)
+	serializedPrefix, _ := SerializePath("m/44'/9000'/0'")
+	assert.Equal(t, append([]byte{CLA, INS_SIGN_MSG, PAYLOAD_INIT, FIRST_MESSAGE, byte(len(serializedPrefix))}, serializedPrefix...), device.sent[0])
+	assert.Equal(t, append([]byte{CLA, INS_SIGN_MSG, PAYLOAD_LAST, 0, byte(len(message))}, message...), device.sent[1])
+	assert.Equal(t, byte(INS_SIGN_HASH), device.sent[2][1])
+
+	assert.Equal(t,
+		"84b9e445b89c349ee63379dbb4fc13cca69b30d286f9bd2ca5629a5e1e78fdfc",
+		hex.EncodeToString(AvalancheMessageHash(message)))
+
+	sig := response.Signature["0/0"]
+	require.Len(t, sig, 65)
+	assert.True(t, VerifySignature(publicKey, AvalancheMessageHash(message), sig[:64]))
+
+	assert.Equal(t, AvalancheMessageHash(message), response.SignaturesOrdered[0].Hash)
+	assert.True(t, VerifySignature(publicKey, response.SignaturesOrdered[0].Hash, sig[:64]))
+}
+
+func Test_SignHash(t *testing.T) {
+	signature, _ := hex.DecodeString("d6844ac6fc813791fcc9030566ae54b1b8f7a70d67fb3252d7a6096c8fe282153625aff848e6e787334f4bc7ee347a7be78857cec74d81ff61f594b261c731ff01")
+	publicKey, _ := hex.DecodeString("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2")
+	hash := AvalancheMessageHash([]byte("Hello Avalanche!"))
+
+	device := &mockDevice{handler: replies([]byte{}, signature, signature)}
+	ledger := newMockLedger(device, AllowBlindSigning(true))
+
+	response, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0", "0/1"}, hash)
+	require.NoError(t, err)
+
+	require.Len(t, device.sent, 3)
+	prefix, _ := SerializePath("m/44'/9000'/0'")
+	data := append(append([]byte{}, prefix...), hash...)
+	assert.Equal(t, append([]byte{CLA, INS_SIGN_HASH, FIRST_MESSAGE, 0, byte(len(data))}, data...), device.sent[0])
+	assert.Equal(t, byte(NEXT_MESSAGE), device.sent[1][2])
+	assert.Equal(t, byte(LAST_MESSAGE), device.sent[2][2])
+
+	assert.Equal(t, hash, response.Hash)
+	assert.True(t, VerifySignature(publicKey, response.Hash, response.Signature["0/0"][:64]))
+
+	assert.Equal(t, NoErrors, response.ReturnCode)
+	first, err := response.SignaturesOrdered[0].Parsed()
+	require.NoError(t, err)
+	assert.Equal(t, signature[:32], first.R())
+	assert.Equal(t, signature[32:64], first.S())
+	assert.Equal(t, byte(1), first.V())
+
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, hash[:HASH_LEN-1])
+	assert.Error(t, err)
+}
+
+func Test_SignPartialResponse(t *testing.T) {
+	signature := bytes.Repeat([]byte{0x01}, SIGNATURE_LEN)
+	device := &mockDevice{}
+	device.handler = func(apdu []byte) ([]byte, error) {
+		switch len(device.sent) {
+		case 1:
+			return []byte{}, nil
+		case 2:
+			return signature, nil
+		}
+		return nil, statusError(DataIsInvalid)
+	}
+	ledger := newMockLedger(device, AllowBlindSigning(true))
+
+	response, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0", "0/1"}, make([]byte, HASH_LEN))
+	var apduErr *APDUError
+	require.ErrorAs(t, err, &apduErr)
+	require.NotNil(t, response)
+	assert.Equal(t, DataIsInvalid, response.ReturnCode)
+	assert.Equal(t, apduErr.Error(), response.ErrorMessage)
+	require.Len(t, response.SignaturesOrdered, 1)
+	assert.Equal(t, signature, response.Signature["0/0"])
+}
+
+func Test_SignHashFromResponse(t *testing.T) {
+	message := []byte("Hello Avalanche!")
+	signature, _ := hex.DecodeString("d6844ac6fc813791fcc9030566ae54b1b8f7a70d67fb3252d7a6096c8fe282153625aff848e6e787334f4bc7ee347a7be78857cec74d81ff61f594b261c731ff01")
+	hash := AvalancheMessageHash(message)
+
+	ledger := newMockLedger(&mockDevice{handler: replies(append(append([]byte{}, hash...), signature...))})
+	response, err := SignAndCollect([]string{"0/0"}, ledger)
+	require.NoError(t, err)
+
+	assert.Equal(t, signature, response.Signature["0/0"])
+	assert.Equal(t, hash, response.SignaturesOrdered[0].Hash)
+	assert.Equal(t, hash, response.Hash)
+}
+
+func Test_SignMessageInvalidPath(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{})
+
+	_, err := ledger.SignMessage("m/44'/9000'/0'", []byte("message"))
+	assert.Error(t, err)
+}
+
+func Test_SignStream(t *testing.T) {
+	// the change path header holds one path suffix: 1 + 9 bytes
+	message := bytes.Repeat([]byte{0xAB}, 2*CHUNK_SIZE-10)
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.SignStream("m/44'/9000'/0'", []string{"0/0"}, nil, bytes.NewReader(message), len(message))
+	require.NoError(t, err)
+
+	// init, two chunks (change path header + message) and the signature collection
+	require.Len(t, device.sent, 4)
+	assert.Equal(t, byte(PAYLOAD_ADD), device.sent[1][2])
+	assert.Equal(t, byte(CHUNK_SIZE), device.sent[1][4])
+	assert.Equal(t, byte(1), device.sent[1][5], "change path header goes first")
+	assert.Equal(t, byte(PAYLOAD_LAST), device.sent[2][2])
+	assert.Equal(t, byte(CHUNK_SIZE), device.sent[2][4])
+	assert.Equal(t, byte(INS_SIGN_HASH), device.sent[3][1])
+}
+
+func Test_SignReportsTransactionHash(t *testing.T) {
+	message := bytes.Repeat([]byte{0xAB}, 300)
+	ledger := newMockLedger(&mockDevice{})
+
+	response, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, message, []string{"1/0"})
+	require.NoError(t, err)
+
+	expected := sha256.Sum256(message)
+	assert.Equal(t, expected[:], response.Hash)
+	for _, signature := range response.SignaturesOrdered {
+		assert.Equal(t, expected[:], signature.Hash)
+	}
+}
+
+func Test_SignBindTransactionHash(t *testing.T) {
+	message := bytes.Repeat([]byte{0xAB}, 300)
+	hash := sha256.Sum256(message)
+	signature := bytes.Repeat([]byte{0x01}, SIGNATURE_LEN)
+	signed := func(hash []byte) func([]byte) ([]byte, error) {
+		return func(command []byte) ([]byte, error) {
+			switch command[1] {
+			case INS_SIGN_HASH:
+				return append(append([]byte{}, hash...), signature...), nil
+			case INS_GET_VERSION:
+				return []byte{0, 0, 6, 5}, nil
+			}
+			return nil, nil
+		}
+	}
+
+	// no released app reports the hash: the transaction is not uploaded
+	device := &mockDevice{handler: replies([]byte{0, 0, 6, 5})}
+	ledger := newMockLedger(device, BindTransactionHash(true))
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, message, nil)
+	assert.ErrorIs(t, err, ErrNotSupported)
+	assert.Empty(t, device.sent)
+
+	original := txHashFeature
+	txHashFeature = appFeature{name: original.name, since: &VersionInfo{0, 0, 6, 5}}
+	t.Cleanup(func() { txHashFeature = original })
+
+	ledger = newMockLedger(&mockDevice{handler: signed(hash[:])}, BindTransactionHash(true))
+	response, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, message, nil)
+	require.NoError(t, err)
+	assert.Equal(t, hash[:], response.Hash)
+
+	device = &mockDevice{handler: signed(make([]byte, HASH_LEN))}
+	ledger = newMockLedger(device, BindTransactionHash(true))
+	_, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, message, nil)
+	assert.ErrorIs(t, err, ErrHashMismatch)
+	assert.Equal(t, byte(INS_SIGN_HASH), device.sent[len(device.sent)-1][1])
+	assert.Equal(t, byte(NEXT_MESSAGE), device.sent[len(device.sent)-1][2], "no other signature is collected")
+
+	ledger = newMockLedger(&mockDevice{handler: signed(make([]byte, HASH_LEN))})
+	response, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+	require.NoError(t, err)
+	assert.Equal(t, make([]byte, HASH_LEN), response.Hash)
+
+	// the app reports no hash to compare despite its version
+	device = &mockDevice{handler: signed(nil)}
+	ledger = newMockLedger(device, BindTransactionHash(true))
+	_, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, message, nil)
+	assert.ErrorIs(t, err, ErrNotSupported)
+	assert.Equal(t, byte(NEXT_MESSAGE), device.sent[len(device.sent)-1][2], "no other signature is collected")
+}
+
+func Test_SignStreamShortReader(t *testing.T) {
+	message := bytes.Repeat([]byte{0xAB}, 100)
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.SignStream("m/44'/9000'/0'", []string{"0/0"}, nil, bytes.NewReader(message), 600)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	for _, apdu := range device.sent {
+		assert.NotEqual(t, byte(PAYLOAD_LAST), apdu[2], "an incomplete transaction must not be finalized")
+	}
+}
+
+func Test_SignReader(t *testing.T) {
+	message := bytes.Repeat([]byte{0xCD}, 3*CHUNK_SIZE)
+	ledger := newMockLedger(&mockDevice{})
+
+	response, err := ledger.SignReader("m/44'/9000'/0'", []string{"0/0", "0/1"}, bytes.NewReader(message), int64(len(message)))
+	require.NoError(t, err)
+
+	expected := sha256.Sum256(message)
+	assert.Equal(t, expected[:], response.Hash)
+	assert.Len(t, response.SignaturesOrdered, 2)
+}
+
+func Test_SignReaderInvalidSize(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.SignReader("m/44'/9000'/0'", []string{"0/0"}, bytes.NewReader(nil), -1)
+	assert.ErrorIs(t, err, ErrInvalidTransactionSize)
+	assert.Empty(t, device.sent)
+}
+
+func Test_GetVersionErrorStatus(t *testing.T) {
+	for _, code := range []LedgerError{DeviceLocked, DeviceIsBusy} {
+		ledger := newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+			return []byte{0x55}, statusError(code)
+		}})
+		ledger.version = VersionInfo{0, 0, 6, 5}
+
+		_, err := ledger.GetVersion()
+		var apduErr *APDUError
+		require.ErrorAs(t, err, &apduErr)
+		assert.Equal(t, code, apduErr.Code)
+		assert.Equal(t, VersionInfo{0, 0, 6, 5}, ledger.version, "version must not be overwritten")
+	}
+}
+
+func Test_GetVersionDeviceLocked(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: replies([]byte{0, 0, 6, 5, 1, 0x31, 0x10, 0x00, 0x04})})
+
+	_, err := ledger.GetVersion()
+	var apduErr *APDUError
+	require.ErrorAs(t, err, &apduErr)
+	assert.Equal(t, DeviceLocked, apduErr.Code)
+	assert.Equal(t, VersionInfo{}, ledger.version)
+}
+
+func Test_GetPubKeyStrictInputs(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "AVAX", "")
+	assert.ErrorIs(t, err, ErrInvalidHrp)
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "3qbR1eZRqXUWroWKKYhbDmR3FfqTHfqSU8zZSxtANzYh")
+	assert.ErrorIs(t, err, ErrInvalidChainID)
+	assert.Empty(t, device.sent)
+
+	// the mock device gives no public key, only the request matters
+	ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "X")
+	require.Len(t, device.sent, 1)
+	xChainID, _ := SerializeChainID("2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByM")
+	assert.Contains(t, string(device.sent[0]), string(xChainID))
+}
+
+func Test_GetPubKeyPermissiveInputs(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device, PermissiveInputs(true))
+
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "AVAX", "3qbR1eZRqXUWroWKKYhbDmR3FfqTHfqSU8zZSxtANzYh")
+	assert.NotErrorIs(t, err, ErrInvalidHrp)
+	assert.NotErrorIs(t, err, ErrInvalidChainID)
+	assert.Len(t, device.sent, 1)
+}
+
+func Test_GetPubKeyWithFormat(t *testing.T) {
+	compressed, _ := hex.DecodeString("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2")
+	hash := bytes.Repeat([]byte{0x11}, 20)
+	response := append(append([]byte{33}, compressed...), hash...)
+	ledger := newMockLedger(&mockDevice{handler: replies(response)})
+
+	publicKey, pkHash, err := ledger.GetPubKeyWithFormat("m/44'/60'/0'/0/0", false, "", "", Uncompressed)
+	require.NoError(t, err)
+	assert.Len(t, publicKey, 65)
+	assert.Equal(t, byte(0x04), publicKey[0])
+	assert.Equal(t, compressed[1:], publicKey[1:33])
+	assert.Equal(t, hash, pkHash)
+}
+
+func Test_RequireOnDeviceConfirmation(t *testing.T) {
+	compressed, _ := hex.DecodeString("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2")
+	response := append(append([]byte{33}, compressed...), bytes.Repeat([]byte{0x11}, 20)...)
+
+	device := &mockDevice{handler: replies(response)}
+	ledger := newMockLedger(device)
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, byte(P1_ONLY_RETRIEVE), device.sent[0][2])
+
+	device = &mockDevice{handler: replies(response)}
+	ledger = newMockLedger(device, RequireOnDeviceConfirmation())
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, byte(P1_SHOW_ADDRESS_IN_DEVICE), device.sent[0][2])
+}
+
+func Test_SignNoSigningPaths(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{}, []byte{0x01}, []string{"1/0"})
+	assert.ErrorIs(t, err, ErrNoSigningPaths)
+
+	_, err = ledger.SignHash("m/44'/9000'/0'", nil, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, ErrNoSigningPaths)
+
+	assert.Empty(t, device.sent, "no APDU should reach the device")
+}
+
+func Test_SignTooManySigningPaths(t *testing.T) {
+	signingPaths := make([]string, DefaultMaxSigningPaths+1)
+	for i := range signingPaths {
+		signingPaths[i] = fmt.Sprintf("0/%d", i)
+	}
+
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+	_, err := ledger.Sign("m/44'/9000'/0'", signingPaths, []byte{0x01}, nil)
+	assert.ErrorIs(t, err, ErrTooManySigningPaths)
+	assert.Empty(t, device.sent)
+
+	ledger = newMockLedger(&mockDevice{}, WithMaxSigningPaths(0), AllowBlindSigning(true))
+	_, err = ledger.SignHash("m/44'/9000'/0'", signingPaths, make([]byte, HASH_LEN))
+	assert.NoError(t, err)
+}
+
+func Test_SignConfirmationTimeout(t *testing.T) {
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[2] == PAYLOAD_LAST {
+			time.Sleep(time.Second)
+		}
+		return []byte{}, nil
+	}}
+	ledger := newMockLedger(device, WithConfirmationTimeout(10*time.Millisecond))
+
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil)
+	assert.ErrorIs(t, err, ErrConfirmationTimeout)
+}
+
+func Test_ShowAddressUserTimeout(t *testing.T) {
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[2] == P1_SHOW_ADDRESS_IN_DEVICE {
+			time.Sleep(time.Second)
+		}
+		return append(append([]byte{33}, make([]byte, 33)...), make([]byte, 20)...), nil
+	}}
+	ledger := newMockLedger(device, WithUserActionTimeout(10*time.Millisecond))
+
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", true, "", "")
+	assert.ErrorIs(t, err, ErrUserTimeout)
+
+	// retrieving without showing is not bounded by the user action timeout
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/1", false, "", "")
+	assert.NoError(t, err)
+}
+
+func Test_SignExchangeTimeout(t *testing.T) {
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[2] == PAYLOAD_ADD {
+			time.Sleep(time.Second)
+		}
+		return []byte{}, nil
+	}}
+	ledger := newMockLedger(device, WithExchangeTimeout(10*time.Millisecond))
+
+	message := bytes.Repeat([]byte{0x01}, 2*CHUNK_SIZE)
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+	assert.ErrorIs(t, err, ErrExchangeTimeout)
+}
+
+func Test_SignResetsSessionAfterTimeout(t *testing.T) {
+	release := make(chan struct{})
+	device := &mockDevice{}
+	device.handler = func(apdu []byte) ([]byte, error) {
+		if apdu[2] == PAYLOAD_ADD && len(device.sent) == 2 {
+			<-release
+		}
+		if apdu[1] == INS_GET_VERSION {
+			return []byte{0, 0, 6, 5}, nil
+		}
+		return []byte{}, nil
+	}
+	ledger := newMockLedger(device, WithExchangeTimeout(10*time.Millisecond))
+
+	message := bytes.Repeat([]byte{0x01}, 2*CHUNK_SIZE)
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+	require.ErrorIs(t, err, ErrExchangeTimeout)
+
+	close(release)
+	_, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, byte(INS_GET_VERSION), device.sent[2][1])
+	assert.Equal(t, byte(PAYLOAD_INIT), device.sent[3][2])
+}
+
+func Test_SignAbortsInterruptedUpload(t *testing.T) {
+	message := bytes.Repeat([]byte{0x01}, 3*CHUNK_SIZE)
+	prefix, _ := SerializePath("m/44'/9000'/0'")
+	init := append([]byte{CLA, INS_SIGN, PAYLOAD_INIT, FIRST_MESSAGE, byte(len(prefix))}, prefix...)
+
+	failing := func(err error) *mockDevice {
+		device := &mockDevice{}
+		device.handler = func(apdu []byte) ([]byte, error) {
+			if apdu[2] == PAYLOAD_ADD && len(device.sent) == 3 {
+				return nil, err
+			}
+			if apdu[1] == INS_GET_VERSION {
+				return []byte{0, 0, 6, 5}, nil
+			}
+			return []byte{}, nil
+		}
+		return device
+	}
+
+	// the app refused a chunk: the session is initialized again
+	for _, code := range []LedgerError{DataIsInvalid, ConditionsNotSatisfied} {
+		device := failing(statusError(code))
+		ledger := newMockLedger(device)
+		_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+		var chunkErr *ChunkError
+		require.ErrorAs(t, err, &chunkErr)
+		require.Len(t, device.sent, 4)
+		assert.Equal(t, init, device.sent[3])
+	}
+
+	// the device went away: the session is reset before the next upload
+	device := failing(errors.New("hidapi: write error"))
+	ledger := newMockLedger(device)
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+	require.ErrorIs(t, err, ErrDeviceDisconnected)
+	require.Len(t, device.sent, 3)
+	_, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+	require.NoError(t, err)
+	assert.Equal(t, byte(INS_GET_VERSION), device.sent[3][1])
+	assert.Equal(t, init, device.sent[4])
+
+	// the transaction is shorter than announced
+	device = &mockDevice{}
+	ledger = newMockLedger(device)
+	_, err = ledger.SignStream("m/44'/9000'/0'", []string{"0/0"}, nil, bytes.NewReader(message[:CHUNK_SIZE]), len(message))
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, init, device.sent[len(device.sent)-1])
+
+	// a rejected transaction needs no abort
+	device = &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[2] == PAYLOAD_LAST {
+			return nil, statusError(TransactionRejected)
+		}
+		return []byte{}, nil
+	}}
+	ledger = newMockLedger(device)
+	_, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+	require.ErrorIs(t, err, ErrUserRejected)
+	assert.Equal(t, byte(PAYLOAD_LAST), device.sent[len(device.sent)-1][2])
+}
+
+func Test_ResetSession(t *testing.T) {
+	device := &mockDevice{handler: replies([]byte{0, 0, 6, 5})}
+	ledger := newMockLedger(device)
+
+	require.NoError(t, ledger.ResetSession())
+	assert.Equal(t, [][]byte{{CLA, INS_GET_VERSION, 0, 0, 0}}, device.sent)
+
+	ledger = newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, statusError(AppDoesNotSeemToBeOpen)
+	}})
+	assert.True(t, isStatus(ledger.ResetSession(), AppDoesNotSeemToBeOpen))
+}
+
+func Test_GetPubKeyHrpTooLong(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device, PermissiveInputs(true))
+
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, strings.Repeat("a", serialize.MaxHrpLength+1), "")
+	assert.ErrorIs(t, err, ErrInvalidHrp)
+	assert.Empty(t, device.sent)
+}
+
+func Test_SignContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[2] == PAYLOAD_LAST {
+			// the user never reviews the transaction
+			cancel()
+			time.Sleep(time.Second)
+		}
+		return []byte{}, nil
+	}}
+	ledger := newMockLedger(device, WithConfirmationTimeout(0))
+
+	_, err := ledger.SignContext(ctx, "m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func Test_ContextDoneBeforeExchange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	device := &mockDevice{}
+	ledger := newMockLedger(device, AllowBlindSigning(true))
+
+	_, err := ledger.GetVersionContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	_, _, err = ledger.GetPubKeyContext(ctx, "m/44'/9000'/0'/0/0", true, "", "")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = ledger.SignHashContext(ctx, "m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, device.sent)
+}
+
+func Test_FindWithDevice(t *testing.T) {
+	device := &mockDevice{handler: replies([]byte{0, 0, 6, 5}, []byte{0, 0, 6, 5})}
+
+	ledger, err := FindLedgerAvalancheApp(WithDevice(device))
+	require.NoError(t, err)
+	assert.Equal(t, device, ledger.api)
+
+	device = &mockDevice{handler: replies([]byte{0, 0, 6, 5}, []byte{0, 0, 6, 5})}
+	_, err = FindLedgerAvalancheApp(WithDevice(device), WithMinAppVersion(VersionInfo{0, 0, 7, 0}))
+	var versionErr *ErrVersionTooLow
+	assert.ErrorAs(t, err, &versionErr)
+	assert.True(t, device.closed)
+
+	device = &mockDevice{}
+	_, err = FindLedgerAvalancheApp(WithDevice(device), SkipVersionCheck())
+	require.NoError(t, err)
+	assert.Empty(t, device.sent)
+}
+
+func withConnectDevice(t *testing.T, connect func(index int) (ledger_go.LedgerDevice, error)) {
+	original := connectDevice
+	connectDevice = connect
+	t.Cleanup(func() { connectDevice = original })
+}
+
+func Test_FindWithConnectRetry(t *testing.T) {
+	attempts := 0
+	withConnectDevice(t, func(int) (ledger_go.LedgerDevice, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("LedgerHID device (idx 0) not found")
+		}
+		return &mockDevice{handler: replies([]byte{0, 0, 6, 5}, []byte{0, 0, 6, 5})}, nil
+	})
+
+	_, err := FindLedgerAvalancheApp(WithConnectRetry(2, time.Millisecond))
+	assert.Error(t, err)
+
+	attempts = 0
+	_, err = FindLedgerAvalancheApp(WithConnectRetry(3, time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func Test_FindWithConnectTimeout(t *testing.T) {
+	late := &mockDevice{}
+	connected := make(chan struct{})
+	withConnectDevice(t, func(int) (ledger_go.LedgerDevice, error) {
+		time.Sleep(50 * time.Millisecond)
+		defer close(connected)
+		return late, nil
+	})
+
+	_, err := FindLedgerAvalancheApp(WithConnectTimeout(time.Millisecond))
+	assert.ErrorIs(t, err, ErrConnectTimeout)
+
+	<-connected
+	assert.Eventually(t, func() bool {
+		late.mu.Lock()
+		defer late.mu.Unlock()
+		return late.closed
+	}, time.Second, time.Millisecond)
+}
+
+func Test_RequiredVersion(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: replies([]byte{0, 0, 7, 0}, []byte{0, 0, 7, 0})})
+	assert.Equal(t, MinAppVersion, ledger.RequiredVersion())
+
+	assert.NoError(t, ledger.CheckMinVersion(VersionInfo{0, 0, 7, 0}))
+
+	var tooLow *ErrVersionTooLow
+	assert.ErrorAs(t, ledger.CheckMinVersion(VersionInfo{0, 0, 8, 0}), &tooLow)
+
+	ledger = newMockLedger(&mockDevice{}, WithMinAppVersion(VersionInfo{0, 0, 8, 0}))
+	assert.Equal(t, VersionInfo{0, 0, 8, 0}, ledger.RequiredVersion())
+}
+
+func Test_ExchangeRaw(t *testing.T) {
+	device := &mockDevice{handler: func(command []byte) ([]byte, error) {
+		if command[1] == 0x7f {
+			return nil, statusError(InstructionNotSupported)
+		}
+		return []byte{0x01, 0x02}, nil
+	}}
+	ledger := newMockLedger(device)
+
+	response, err := ledger.ExchangeRaw(apdu.New(CLA, INS_GET_VERSION, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x02}, response)
+	assert.Equal(t, []byte{CLA, INS_GET_VERSION, 0, 0, 0}, device.sent[0])
+
+	_, err = ledger.ExchangeRaw(apdu.New(CLA, 0x7f, 0, 0, []byte{0xaa}))
+	assert.True(t, isStatus(err, InstructionNotSupported))
+
+	_, err = ledger.ExchangeRaw(apdu.Command{CLA: CLA, Data: make([]byte, apdu.MaxDataLen+1)})
+	assert.ErrorIs(t, err, ErrAPDUTooLong)
+}
+
+func Test_FindWithExpectedWalletID(t *testing.T) {
+	walletID := []byte{1, 2, 3, 4, 5, 6}
+
+	device := &mockDevice{handler: replies(walletID)}
+	_, err := FindLedgerAvalancheApp(WithDevice(device), SkipVersionCheck(), WithExpectedWalletID(walletID))
+	require.NoError(t, err)
+
+	device = &mockDevice{handler: replies([]byte{6, 5, 4, 3, 2, 1})}
+	_, err = FindLedgerAvalancheApp(WithDevice(device), SkipVersionCheck(), WithExpectedWalletID(walletID))
+	assert.ErrorIs(t, err, ErrWalletIDMismatch)
+	assert.True(t, device.closed)
+}
+
+func Test_FindRequireReleaseApp(t *testing.T) {
+	release := []byte{byte(AppModeRelease), 0, 6, 5}
+	test := []byte{byte(AppModeTest), 0, 6, 5}
+
+	device := &mockDevice{handler: replies(release, release, release)}
+	ledger, err := FindLedgerAvalancheApp(WithDevice(device), RequireReleaseApp(true))
+	require.NoError(t, err)
+	ledger.Close()
+
+	device = &mockDevice{handler: replies(test, test, test)}
+	_, err = FindLedgerAvalancheApp(WithDevice(device), RequireReleaseApp(true))
+	assert.ErrorIs(t, err, ErrNonReleaseApp)
+	assert.True(t, device.closed)
+
+	device = &mockDevice{handler: replies(test, test)}
+	_, err = FindLedgerAvalancheApp(WithDevice(device))
+	assert.NoError(t, err, "test builds are accepted by default")
+}
+
+func Test_AppMode(t *testing.T) {
+	version := VersionInfo{AppMode: 1, Major: 0, Minor: 6, Patch: 5}
+	assert.Equal(t, AppModeTest, version.Mode())
+	assert.Equal(t, "test", version.Mode().String())
+	assert.Equal(t, "release", AppModeRelease.String())
+	assert.Equal(t, "unknown mode 0x07", AppMode(7).String())
+}
@@ -0,0 +1,127 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package avax
+
+import (
+	ledger "github.com/zondax/ledger-avalanche-go"
+)
+
+// Signer has the methods of avalanchego's keychain.Signer, with ShortID in place of ids.ShortID
+type Signer interface {
+	SignHash(hash []byte) ([]byte, error)
+	Sign(unsignedTx []byte) ([]byte, error)
+	Address() ShortID
+}
+
+// AddressSet is a set of addresses, laid out as avalanchego's set.Set[ids.ShortID]
+type AddressSet map[ShortID]struct{}
+
+// Contains reports whether address is in the set
+func (s AddressSet) Contains(address ShortID) bool {
+	_, ok := s[address]
+	return ok
+}
+
+// SignerKeychain has the methods of avalanchego's keychain.Keychain, with ShortID in place of
+// ids.ShortID. This module does not depend on avalanchego, whose ID types differ from ShortID
+// by name only: callers using avalanchego convert the IDs when wrapping a SignerKeychain.
+type SignerKeychain interface {
+	Get(address ShortID) (Signer, bool)
+	Addresses() AddressSet
+}
+
+var (
+	_ SignerKeychain = (*LedgerKeychain)(nil)
+	_ Signer         = (*LedgerSigner)(nil)
+)
+
+// LedgerKeychain offers the addresses derived on the device as a SignerKeychain
+type LedgerKeychain struct {
+	device   *ledger.LedgerAvalanche
+	keychain Keychain
+}
+
+// NewLedgerKeychain derives the addresses at accountPath/change/index for each of indices
+func NewLedgerKeychain(device *ledger.LedgerAvalanche, accountPath string, change uint32, indices []uint32) (*LedgerKeychain, error) {
+	keychain, err := NewKeychain(device, accountPath, change, indices)
+	if err != nil {
+		return nil, err
+	}
+	return &LedgerKeychain{device: device, keychain: keychain}, nil
+}
+
+// Get returns the signer of address, if it was derived
+func (k *LedgerKeychain) Get(address ShortID) (Signer, bool) {
+	path, ok := k.keychain[address]
+	if !ok {
+		return nil, false
+	}
+	return &LedgerSigner{device: k.device, path: path, address: address}, true
+}
+
+// Addresses returns the derived addresses
+func (k *LedgerKeychain) Addresses() AddressSet {
+	addresses := make(AddressSet, len(k.keychain))
+	for address := range k.keychain {
+		addresses[address] = struct{}{}
+	}
+	return addresses
+}
+
+// Keychain returns the address to path mapping, e.g. for SignUnsignedTx
+func (k *LedgerKeychain) Keychain() Keychain {
+	return k.keychain
+}
+
+// LedgerSigner signs with the key of one address. Every signature must be approved on the device,
+// prefer SignUnsignedTx to sign all the inputs of a transaction with a single review.
+type LedgerSigner struct {
+	device  *ledger.LedgerAvalanche
+	path    string
+	address ShortID
+}
+
+func (s *LedgerSigner) Address() ShortID {
+	return s.address
+}
+
+// SignHash signs a 32-byte hash and returns the [r | s | v] signature. The device must be
+// created with ledger.AllowBlindSigning.
+func (s *LedgerSigner) SignHash(hash []byte) ([]byte, error) {
+	prefix, suffix, err := ledger.SplitPath(s.path)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.device.SignHash(prefix, []string{suffix}, hash)
+	if err != nil {
+		return nil, err
+	}
+	return response.Signature[suffix], nil
+}
+
+// Sign signs the serialized unsigned transaction and returns the [r | s | v] signature
+func (s *LedgerSigner) Sign(unsignedTx []byte) ([]byte, error) {
+	prefix, suffix, err := ledger.SplitPath(s.path)
+	if err != nil {
+		return nil, err
+	}
+	response, err := s.device.Sign(prefix, []string{suffix}, unsignedTx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Signature[suffix], nil
+}
@@ -0,0 +1,63 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package avax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/mock"
+)
+
+func Test_LedgerKeychain(t *testing.T) {
+	device, err := mock.NewMockLedgerFromMnemonic(testMnemonic, ledger.AllowBlindSigning(true))
+	require.NoError(t, err)
+
+	keychain, err := NewLedgerKeychain(device.LedgerAvalanche, "m/44'/9000'/0'", 1, []uint32{0, 5})
+	require.NoError(t, err)
+
+	addresses := keychain.Addresses()
+	require.Len(t, addresses, 2)
+	assert.False(t, addresses.Contains(ShortID{0x01}))
+
+	_, ok := keychain.Get(ShortID{0x01})
+	assert.False(t, ok)
+
+	for address := range addresses {
+		assert.Contains(t, []string{"m/44'/9000'/0'/1/0", "m/44'/9000'/0'/1/5"}, keychain.Keychain()[address])
+
+		signer, ok := keychain.Get(address)
+		require.True(t, ok)
+		assert.Equal(t, address, signer.Address())
+
+		publicKey, _, err := device.GetPubKey(keychain.Keychain()[address], false, "", "")
+		require.NoError(t, err)
+
+		hash := make([]byte, ledger.HASH_LEN)
+		signature, err := signer.SignHash(hash)
+		require.NoError(t, err)
+		assert.True(t, ledger.VerifySignature(publicKey, hash, signature[:64]))
+
+		unsignedTx := []byte{0x00, 0x00, 0x01}
+		signature, err = signer.Sign(unsignedTx)
+		require.NoError(t, err)
+		txHash, _ := ledger.ComputeSignHash(unsignedTx)
+		assert.True(t, ledger.VerifySignature(publicKey, txHash, signature[:64]))
+	}
+}
@@ -0,0 +1,150 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package avax signs avalanchego transactions with a Ledger device. It works on the
+// codec-serialized unsigned transaction so it does not depend on avalanchego: e.g. for a
+// P-chain txs.Tx, pass txs.Codec.Marshal(txs.CodecVersion, &tx.Unsigned) as Tx.Unsigned and
+// set tx.Creds from Tx.Creds as secp256k1fx.Credential values.
+package avax
+
+import (
+	"errors"
+	"fmt"
+
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/decode"
+)
+
+// ShortID is a 20-byte address, laid out as avalanchego's ids.ShortID
+type ShortID [20]byte
+
+// Keychain maps the addresses controlled by the device to their full derivation paths
+type Keychain map[ShortID]string
+
+// ErrUnknownSigner is returned when an input must be signed by an address missing from the keychain
+var ErrUnknownSigner = errors.New("signer is not in the keychain")
+
+// NewKeychain derives the addresses at accountPath/change/index (e.g "m/44'/9000'/0'") for the given indices
+func NewKeychain(device *ledger.LedgerAvalanche, accountPath string, change uint32, indices []uint32) (Keychain, error) {
+	keychain := make(Keychain, len(indices))
+	for _, index := range indices {
+		path := fmt.Sprintf("%s/%d/%d", accountPath, change, index)
+		_, hash, err := device.GetPubKey(path, false, "", "")
+		if err != nil {
+			return nil, err
+		}
+		if len(hash) != len(ShortID{}) {
+			return nil, ledger.ErrMalformedResponse
+		}
+
+		var address ShortID
+		copy(address[:], hash)
+		keychain[address] = path
+	}
+	return keychain, nil
+}
+
+// Input lists the addresses that must sign a transaction input, in the order of its
+// signature indices
+type Input struct {
+	Signers []ShortID
+}
+
+// Credential holds the signatures of an input, laid out as secp256k1fx.Credential
+type Credential struct {
+	Sigs [][ledger.SIGNATURE_LEN]byte
+}
+
+// Tx is a transaction and its credentials, laid out as avalanchego's txs.Tx
+type Tx struct {
+	// Unsigned is the codec-serialized unsigned transaction
+	Unsigned []byte
+	Creds    []Credential
+}
+
+// SignTx signs tx, a transaction of chain, with the addresses of keychain and attaches one
+// credential per input to it, see SignUnsignedTx
+func SignTx(device *ledger.LedgerAvalanche, tx *Tx, chain decode.Chain, inputs []Input, keychain Keychain) error {
+	credentials, err := SignUnsignedTx(device, tx.Unsigned, chain, inputs, keychain)
+	if err != nil {
+		return err
+	}
+	tx.Creds = credentials
+	return nil
+}
+
+// ChangePaths returns the paths of the keychain addresses receiving outputs of unsignedTx, a
+// transaction of chain. Only outputs spendable right away by a single address are change.
+func ChangePaths(unsignedTx []byte, chain decode.Chain, keychain Keychain) ([]string, error) {
+	summary, err := decode.Decode(unsignedTx, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	seen := make(map[string]bool)
+	for _, output := range summary.Outputs {
+		owner := output.Owner
+		if output.Kind != decode.OutputTransfer || output.StakeableLocktime != 0 ||
+			owner.Locktime != 0 || owner.Threshold != 1 || len(owner.Addresses) != 1 {
+			continue
+		}
+		path, ok := keychain[ShortID(owner.Addresses[0])]
+		if ok && !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// SignUnsignedTx signs the serialized unsigned transaction of chain and returns one credential
+// per input. The outputs going back to keychain addresses are reported to the device as change,
+// see ChangePaths, so they are not shown for review.
+func SignUnsignedTx(device *ledger.LedgerAvalanche, unsignedTx []byte, chain decode.Chain, inputs []Input, keychain Keychain) ([]Credential, error) {
+	var signingPaths []string
+	for i, input := range inputs {
+		for _, signer := range input.Signers {
+			path, ok := keychain[signer]
+			if !ok {
+				return nil, fmt.Errorf("%w: input %d", ErrUnknownSigner, i)
+			}
+			signingPaths = append(signingPaths, path)
+		}
+	}
+
+	changePaths, err := ChangePaths(unsignedTx, chain, keychain)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := device.SignMultiAccount(signingPaths, unsignedTx, changePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := make([]Credential, len(inputs))
+	for i, input := range inputs {
+		for _, signer := range input.Signers {
+			sig, err := ledger.ParseSignature(response.Signature[keychain[signer]])
+			if err != nil {
+				return nil, ledger.ErrMalformedResponse
+			}
+			credentials[i].Sigs = append(credentials[i].Sigs, sig.Credential())
+		}
+	}
+	return credentials, nil
+}
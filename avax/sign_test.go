@@ -0,0 +1,119 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package avax
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/decode"
+	"github.com/zondax/ledger-avalanche-go/mock"
+)
+
+const testMnemonic = "equip will roof matter pink blind book anxiety banner elbow sun young"
+
+func Test_SignUnsignedTx(t *testing.T) {
+	device, err := mock.NewMockLedgerFromMnemonic(testMnemonic)
+	require.NoError(t, err)
+
+	keychain, err := NewKeychain(device.LedgerAvalanche, "m/44'/9000'/0'", 0, []uint32{0, 1, 2})
+	require.NoError(t, err)
+	require.Len(t, keychain, 3)
+
+	var addresses []ShortID
+	for _, index := range []string{"0", "1", "2"} {
+		for address, path := range keychain {
+			if path == "m/44'/9000'/0'/0/"+index {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+
+	unsignedTx := baseTx(addresses[2], ShortID{0xee})
+	inputs := []Input{{Signers: []ShortID{addresses[1], addresses[0]}}, {Signers: []ShortID{addresses[1]}}}
+
+	changePaths, err := ChangePaths(unsignedTx, decode.PChain, keychain)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m/44'/9000'/0'/0/2"}, changePaths)
+
+	credentials, err := SignUnsignedTx(device.LedgerAvalanche, unsignedTx, decode.PChain, inputs, keychain)
+	require.NoError(t, err)
+	require.Len(t, credentials, 2)
+	require.Len(t, credentials[0].Sigs, 2)
+	require.Len(t, credentials[1].Sigs, 1)
+	assert.Equal(t, credentials[0].Sigs[0], credentials[1].Sigs[0])
+
+	hash, _ := ledger.ComputeSignHash(unsignedTx)
+	for i, path := range []string{"m/44'/9000'/0'/0/1", "m/44'/9000'/0'/0/0"} {
+		publicKey, _, err := device.GetPubKey(path, false, "", "")
+		require.NoError(t, err)
+		assert.True(t, ledger.VerifySignature(publicKey, hash, credentials[0].Sigs[i][:64]), path)
+	}
+
+	tx := &Tx{Unsigned: unsignedTx}
+	require.NoError(t, SignTx(device.LedgerAvalanche, tx, decode.PChain, inputs, keychain))
+	assert.Equal(t, credentials, tx.Creds)
+
+	_, err = SignUnsignedTx(device.LedgerAvalanche, []byte{0x00}, decode.PChain, inputs, keychain)
+	assert.ErrorIs(t, err, decode.ErrMalformedTransaction)
+}
+
+// baseTx serializes a P-chain BaseTx spending two UTXOs, with an output to each of owners
+func baseTx(owners ...ShortID) []byte {
+	var buf []byte
+	u32 := func(v uint32) { buf = binary.BigEndian.AppendUint32(buf, v) }
+	u64 := func(v uint64) { buf = binary.BigEndian.AppendUint64(buf, v) }
+	id := func(b byte) { buf = append(buf, make([]byte, 31)...); buf = append(buf, b) }
+
+	buf = append(buf, 0x00, 0x00)
+	u32(34)
+	u32(1)
+	id(0)
+	u32(uint32(len(owners)))
+	for _, owner := range owners {
+		id(0xaa)
+		u32(7)
+		u64(1000)
+		u64(0)
+		u32(1)
+		u32(1)
+		buf = append(buf, owner[:]...)
+	}
+	u32(2)
+	for i := byte(1); i <= 2; i++ {
+		id(i)
+		u32(0)
+		id(0xaa)
+		u32(5)
+		u64(2000)
+		u32(1)
+		u32(0)
+	}
+	u32(0)
+	return buf
+}
+
+func Test_SignUnsignedTxUnknownSigner(t *testing.T) {
+	device, err := mock.NewMockLedgerFromMnemonic(testMnemonic)
+	require.NoError(t, err)
+
+	_, err = SignUnsignedTx(device.LedgerAvalanche, baseTx(), decode.PChain, []Input{{Signers: []ShortID{{0x01}}}}, Keychain{})
+	assert.ErrorIs(t, err, ErrUnknownSigner)
+}
@@ -0,0 +1,67 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import "fmt"
+
+// BatchError reports which transaction of a batch failed
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("transaction %d: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// SignRequest is a transaction to sign with SignRequests, see Sign for the paths
+type SignRequest struct {
+	Message      []byte
+	SigningPaths []string
+	ChangePaths  []string
+}
+
+// SignBatch signs several independent transactions with the same signing paths over one connection,
+// see SignRequests.
+func (ledger *LedgerAvalanche) SignBatch(pathPrefix string, txs [][]byte, signingPaths []string) ([]ResponseSign, error) {
+	requests := make([]SignRequest, len(txs))
+	for idx, tx := range txs {
+		requests[idx] = SignRequest{Message: tx, SigningPaths: signingPaths}
+	}
+	return ledger.SignRequests(pathPrefix, requests)
+}
+
+// SignRequests signs several transactions in a row over one connection, each with its own signing
+// and change paths, e.g. the staking transactions of a validator. The Avalanche app has no batch
+// instruction, so every transaction is still reviewed and approved on the device separately.
+// Signing stops at the first failure, e.g. when the user rejects a transaction: the responses of
+// the transactions signed so far are returned with a *BatchError holding the index of the failed one.
+func (ledger *LedgerAvalanche) SignRequests(pathPrefix string, requests []SignRequest) ([]ResponseSign, error) {
+	responses := make([]ResponseSign, 0, len(requests))
+	for idx, request := range requests {
+		response, err := ledger.Sign(pathPrefix, request.SigningPaths, request.Message, request.ChangePaths)
+		if err != nil {
+			return responses, &BatchError{Index: idx, Err: err}
+		}
+		responses = append(responses, *response)
+	}
+	return responses, nil
+}
@@ -0,0 +1,63 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignBatch(t *testing.T) {
+	txs := [][]byte{{0x01}, {0x02}, {0x03}}
+	// The user rejects the second transaction
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[1] == INS_SIGN && apdu[2] == PAYLOAD_LAST && apdu[len(apdu)-1] == 0x02 {
+			return nil, statusError(TransactionRejected)
+		}
+		return []byte{0xAA}, nil
+	}}
+	ledger := newMockLedger(device)
+
+	responses, err := ledger.SignBatch("m/44'/9000'/0'", txs, []string{"0/0"})
+
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 1, batchErr.Index)
+	assert.True(t, isStatus(err, TransactionRejected))
+
+	require.Len(t, responses, 1)
+	assert.Equal(t, []byte{0xAA}, responses[0].Signature["0/0"])
+}
+
+func Test_SignRequests(t *testing.T) {
+	device := &mockDevice{handler: func([]byte) ([]byte, error) {
+		return []byte{0xAA}, nil
+	}}
+	ledger := newMockLedger(device)
+
+	responses, err := ledger.SignRequests("m/44'/9000'/0'", []SignRequest{
+		{Message: []byte{0x01}, SigningPaths: []string{"0/0"}, ChangePaths: []string{"1/0"}},
+		{Message: []byte{0x02}, SigningPaths: []string{"0/1", "0/2"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, responses, 2)
+	assert.Len(t, responses[0].Signature, 1)
+	assert.Len(t, responses[1].Signature, 2)
+	assert.Equal(t, []byte{0xAA}, responses[1].Signature["0/2"])
+}
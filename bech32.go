@@ -0,0 +1,29 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"github.com/zondax/ledger-avalanche-go/address"
+)
+
+// encodeBech32 encodes data (e.g an address hash) with the given human readable part
+func encodeBech32(hrp string, data []byte) (string, error) {
+	if _, err := SerializeHrp(hrp); err != nil {
+		return "", err
+	}
+	return address.EncodeBech32(hrp, data)
+}
@@ -0,0 +1,222 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/zondax/ledger-go"
+)
+
+// GATT service and characteristics of the Ledger devices with Bluetooth. APDUs are written
+// to the write characteristic and answered through notifications of the notify characteristic.
+const (
+	BLEServiceNanoX = "13d63400-2c97-0004-0000-4c6564676572"
+	BLENotifyNanoX  = "13d63400-2c97-0004-0001-4c6564676572"
+	BLEWriteNanoX   = "13d63400-2c97-0004-0002-4c6564676572"
+
+	BLEServiceStax = "13d63400-2c97-6004-0000-4c6564676572"
+	BLENotifyStax  = "13d63400-2c97-6004-0001-4c6564676572"
+	BLEWriteStax   = "13d63400-2c97-6004-0002-4c6564676572"
+)
+
+const (
+	bleTagAPDU = 0x05
+	bleTagMTU  = 0x08
+
+	// DefaultBLEMTU is the frame size used until NegotiateMTU is called
+	DefaultBLEMTU = 20
+)
+
+// BLEConn is a connection to the GATT service of a Ledger device, provided by the Bluetooth
+// stack of the platform: Write writes a frame to the write characteristic and Read returns the
+// next notification of the notify characteristic, blocking until it is received.
+type BLEConn interface {
+	Write(frame []byte) error
+	Read() ([]byte, error)
+	Close() error
+}
+
+// BLEPeripheral is a Ledger device found by a BLEScanner. Service is the GATT service it
+// advertises, one of BLEServiceNanoX and BLEServiceStax.
+type BLEPeripheral struct {
+	ID      string
+	Name    string
+	Service string
+}
+
+// BLEScanner discovers the Ledger devices in Bluetooth range and connects to them, through the
+// Bluetooth stack of the platform. Scan returns the devices advertising BLEServiceNanoX or
+// BLEServiceStax, and Connect subscribes to the notify characteristic of the service of
+// peripheral (see BLECharacteristics).
+type BLEScanner interface {
+	Scan() ([]BLEPeripheral, error)
+	Connect(peripheral BLEPeripheral) (BLEConn, error)
+}
+
+// BLECharacteristics returns the notify and write characteristics of a Ledger GATT service
+func BLECharacteristics(service string) (notify, write string, ok bool) {
+	switch service {
+	case BLEServiceNanoX:
+		return BLENotifyNanoX, BLEWriteNanoX, true
+	case BLEServiceStax:
+		return BLENotifyStax, BLEWriteStax, true
+	}
+	return "", "", false
+}
+
+// connectBLE connects to the Ledger device at index among the ones found by the BLE scanner
+func (ledger *LedgerAvalanche) connectBLE(index int) (ledger_go.LedgerDevice, error) {
+	peripherals, err := ledger.bleScanner.Scan()
+	if err != nil {
+		return nil, err
+	}
+	if index < 0 || index >= len(peripherals) {
+		return nil, fmt.Errorf("Ledger BLE device (idx %d) not found", index)
+	}
+
+	conn, err := ledger.bleScanner.Connect(peripherals[index])
+	if err != nil {
+		return nil, err
+	}
+	device := NewBLEDevice(conn)
+	if err := device.NegotiateMTU(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return device, nil
+}
+
+// BLEDevice exchanges APDUs with a Ledger device over Bluetooth, e.g. a Nano X or a Stax,
+// splitting them in frames as the device expects
+type BLEDevice struct {
+	conn BLEConn
+	mtu  int
+}
+
+// NewBLEDevice uses conn to exchange APDUs with the device
+func NewBLEDevice(conn BLEConn) *BLEDevice {
+	return &BLEDevice{conn: conn, mtu: DefaultBLEMTU}
+}
+
+// FindLedgerAvalancheAppBLE finds the Avax user app running in a device connected over Bluetooth
+func FindLedgerAvalancheAppBLE(conn BLEConn, opts ...Option) (*LedgerAvalanche, error) {
+	device := NewBLEDevice(conn)
+	if err := device.NegotiateMTU(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return openApp(device, opts...)
+}
+
+// NegotiateMTU asks the device for the largest frame it accepts, to send fewer frames per APDU
+func (d *BLEDevice) NegotiateMTU() error {
+	if err := d.conn.Write([]byte{bleTagMTU, 0, 0, 0, 0}); err != nil {
+		return err
+	}
+	frame, err := d.conn.Read()
+	if err != nil {
+		return err
+	}
+	if len(frame) < 6 || frame[0] != bleTagMTU {
+		return fmt.Errorf("%w: unexpected MTU frame %x", ErrMalformedResponse, frame)
+	}
+	if mtu := int(frame[5]); mtu > 5 {
+		d.mtu = mtu
+	}
+	return nil
+}
+
+// Exchange sends an APDU and blocks until the device answers, reporting status words
+// other than 0x9000 as ledger-go does
+func (d *BLEDevice) Exchange(command []byte) ([]byte, error) {
+	if len(command) > 0xFFFF {
+		return nil, errors.New("APDU too long")
+	}
+
+	for _, frame := range bleFrames(command, d.mtu) {
+		if err := d.conn.Write(frame); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := d.readAPDU()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 {
+		return nil, errors.New("len(response) < 2")
+	}
+
+	swOffset := len(data) - 2
+	sw := binary.BigEndian.Uint16(data[swOffset:])
+	if sw != 0x9000 {
+		return data[:swOffset], errors.New(ledger_go.ErrorMessage(sw))
+	}
+	return data[:swOffset], nil
+}
+
+func (d *BLEDevice) Close() error {
+	return d.conn.Close()
+}
+
+// bleFrames splits an APDU in frames of at most mtu bytes:
+// [tag | sequence | length | data] for the first one and [tag | sequence | data] for the rest
+func bleFrames(apdu []byte, mtu int) [][]byte {
+	var frames [][]byte
+	for seq, offset := 0, 0; offset < len(apdu) || seq == 0; seq++ {
+		frame := []byte{bleTagAPDU, byte(seq >> 8), byte(seq)}
+		if seq == 0 {
+			frame = binary.BigEndian.AppendUint16(frame, uint16(len(apdu)))
+		}
+
+		end := offset + mtu - len(frame)
+		if end > len(apdu) {
+			end = len(apdu)
+		}
+		frames = append(frames, append(frame, apdu[offset:end]...))
+		offset = end
+	}
+	return frames
+}
+
+// readAPDU reassembles the frames of a response
+func (d *BLEDevice) readAPDU() ([]byte, error) {
+	var data []byte
+	total := -1
+	for seq := 0; total < 0 || len(data) < total; seq++ {
+		frame, err := d.conn.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		header := 3
+		if seq == 0 {
+			header = 5
+		}
+		if len(frame) < header || frame[0] != bleTagAPDU || int(binary.BigEndian.Uint16(frame[1:3])) != seq {
+			return nil, fmt.Errorf("%w: unexpected frame %x", ErrMalformedResponse, frame)
+		}
+		if seq == 0 {
+			total = int(binary.BigEndian.Uint16(frame[3:5]))
+		}
+		data = append(data, frame[header:]...)
+	}
+	return data[:total], nil
+}
@@ -0,0 +1,163 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBLEConn reassembles the frames written by the host and answers each APDU through
+// the notifications, as a device with the given MTU
+type fakeBLEConn struct {
+	mtu      int
+	handler  func(apdu []byte) []byte
+	written  [][]byte
+	pending  []byte
+	expected int
+	notify   [][]byte
+	closed   bool
+}
+
+func (c *fakeBLEConn) Write(frame []byte) error {
+	c.written = append(c.written, frame)
+	if frame[0] == bleTagMTU {
+		c.notify = append(c.notify, []byte{bleTagMTU, 0, 0, 0, 1, byte(c.mtu)})
+		return nil
+	}
+
+	if binary.BigEndian.Uint16(frame[1:3]) == 0 {
+		c.expected = int(binary.BigEndian.Uint16(frame[3:5]))
+		c.pending = append([]byte{}, frame[5:]...)
+	} else {
+		c.pending = append(c.pending, frame[3:]...)
+	}
+	if len(c.pending) == c.expected {
+		c.notify = append(c.notify, bleFrames(c.handler(c.pending), c.mtu)...)
+	}
+	return nil
+}
+
+func (c *fakeBLEConn) Read() ([]byte, error) {
+	frame := c.notify[0]
+	c.notify = c.notify[1:]
+	return frame, nil
+}
+
+func (c *fakeBLEConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func Test_BLEFrames(t *testing.T) {
+	apdu := bytes.Repeat([]byte{0xAA}, 40)
+	frames := bleFrames(apdu, 20)
+
+	require.Len(t, frames, 3)
+	assert.Equal(t, []byte{bleTagAPDU, 0, 0, 0, 40}, frames[0][:5])
+	assert.Len(t, frames[0], 20)
+	assert.Equal(t, []byte{bleTagAPDU, 0, 1}, frames[1][:3])
+	assert.Len(t, frames[1], 20)
+	assert.Len(t, frames[2], 3+40-15-17)
+
+	assert.Equal(t, [][]byte{{bleTagAPDU, 0, 0, 0, 0}}, bleFrames(nil, 20))
+}
+
+func Test_BLEDevice(t *testing.T) {
+	conn := &fakeBLEConn{mtu: 50, handler: func(apdu []byte) []byte {
+		if apdu[1] == INS_GET_VERSION {
+			return []byte{0, 0, 6, 5, 0x90, 0x00}
+		}
+		// echo the payload
+		return append(append([]byte{}, apdu[5:]...), 0x90, 0x00)
+	}}
+
+	ledger, err := FindLedgerAvalancheAppBLE(conn)
+	require.NoError(t, err)
+
+	payload := bytes.Repeat([]byte{0x42}, 200)
+	message, _ := buildAPDU(CLA, INS_SIGN_MSG, 0, 0, payload)
+	response, err := ledger.exchange(message)
+	require.NoError(t, err)
+	assert.Equal(t, payload, response)
+
+	for _, frame := range conn.written {
+		assert.LessOrEqual(t, len(frame), 50)
+	}
+}
+
+func Test_BLEDeviceStatusWord(t *testing.T) {
+	conn := &fakeBLEConn{mtu: 20, handler: func([]byte) []byte {
+		return []byte{0x69, 0x86}
+	}}
+
+	_, err := NewLedgerAvalanche(NewBLEDevice(conn)).GetVersion()
+	assert.ErrorIs(t, err, ErrUserRejected)
+}
+
+// fakeBLEScanner finds the peripherals with a connection in conns
+type fakeBLEScanner struct {
+	peripherals []BLEPeripheral
+	conns       map[string]*fakeBLEConn
+}
+
+func (s *fakeBLEScanner) Scan() ([]BLEPeripheral, error) {
+	return s.peripherals, nil
+}
+
+func (s *fakeBLEScanner) Connect(peripheral BLEPeripheral) (BLEConn, error) {
+	return s.conns[peripheral.ID], nil
+}
+
+func Test_FindWithBLEScanner(t *testing.T) {
+	version := func([]byte) []byte { return []byte{0, 0, 6, 5, 0x90, 0x00} }
+	scanner := &fakeBLEScanner{
+		peripherals: []BLEPeripheral{
+			{ID: "nanox", Name: "Nano X 1A2B", Service: BLEServiceNanoX},
+			{ID: "stax", Name: "Stax 3C4D", Service: BLEServiceStax},
+		},
+		conns: map[string]*fakeBLEConn{
+			"nanox": {mtu: 50, handler: version},
+			"stax":  {mtu: 50, handler: version},
+		},
+	}
+
+	ledger, err := FindLedgerAvalancheAppOnDevice(1, WithBLEScanner(scanner))
+	require.NoError(t, err)
+	assert.NotEmpty(t, scanner.conns["stax"].written)
+	assert.Empty(t, scanner.conns["nanox"].written)
+
+	require.NoError(t, ledger.Close())
+	assert.True(t, scanner.conns["stax"].closed)
+
+	_, err = FindLedgerAvalancheAppOnDevice(2, WithBLEScanner(scanner))
+	assert.Error(t, err)
+}
+
+func Test_BLECharacteristics(t *testing.T) {
+	notify, write, ok := BLECharacteristics(BLEServiceStax)
+	assert.True(t, ok)
+	assert.Equal(t, BLENotifyStax, notify)
+	assert.Equal(t, BLEWriteStax, write)
+
+	_, _, ok = BLECharacteristics("180a")
+	assert.False(t, ok)
+}
@@ -0,0 +1,70 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import "fmt"
+
+const (
+	// BLSPublicKeyLength is the length of a compressed BLS12-381 public key
+	BLSPublicKeyLength = 48
+	// BLSSignatureLength is the length of a compressed BLS12-381 signature
+	BLSSignatureLength = 96
+)
+
+// BLSProofOfPossession is what a validator registers along with its node ID: the BLS public key
+// and its signature, proving the key holder made it
+type BLSProofOfPossession struct {
+	PublicKey [BLSPublicKeyLength]byte
+	Signature [BLSSignatureLength]byte
+}
+
+// errBLSNotSupported is returned by the BLS methods. The Avalanche app has no BLS instructions:
+// validators keep their BLS key on the node, which signs its proof of possession.
+var errBLSNotSupported = fmt.Errorf("%w: BLS keys", ErrNotSupported)
+
+// SupportsBLS reports whether the app running on the device can return BLS keys and signatures,
+// which it cannot
+func (ledger *LedgerAvalanche) SupportsBLS() bool {
+	return false
+}
+
+// GetBLSPublicKey returns the BLS public key at path. It fails with ErrNotSupported.
+func (ledger *LedgerAvalanche) GetBLSPublicKey(path string) ([BLSPublicKeyLength]byte, error) {
+	var publicKey [BLSPublicKeyLength]byte
+	if _, err := ledger.serializer.SerializePath(path); err != nil {
+		return publicKey, err
+	}
+	return publicKey, errBLSNotSupported
+}
+
+// SignBLS signs message with the BLS key at path. It fails with ErrNotSupported.
+func (ledger *LedgerAvalanche) SignBLS(path string, message []byte) ([BLSSignatureLength]byte, error) {
+	var signature [BLSSignatureLength]byte
+	if _, err := ledger.serializer.SerializePath(path); err != nil {
+		return signature, err
+	}
+	return signature, errBLSNotSupported
+}
+
+// GetBLSProofOfPossession returns the proof of possession of the BLS key at path, as registered
+// by a validator. It fails with ErrNotSupported.
+func (ledger *LedgerAvalanche) GetBLSProofOfPossession(path string) (*BLSProofOfPossession, error) {
+	if _, err := ledger.serializer.SerializePath(path); err != nil {
+		return nil, err
+	}
+	return nil, errBLSNotSupported
+}
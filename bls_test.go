@@ -0,0 +1,46 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BLSNotSupported(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	assert.False(t, ledger.SupportsBLS())
+
+	_, err := ledger.GetBLSPublicKey("m/12381'/9000'/0'/0'")
+	assert.ErrorIs(t, err, ErrNotSupported)
+	_, err = ledger.SignBLS("m/12381'/9000'/0'/0'", []byte("message"))
+	assert.ErrorIs(t, err, ErrNotSupported)
+	_, err = ledger.GetBLSProofOfPossession("m/12381'/9000'/0'/0'")
+	assert.ErrorIs(t, err, ErrNotSupported)
+	assert.Empty(t, device.sent)
+}
+
+func Test_BLSInvalidPath(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{})
+
+	_, err := ledger.GetBLSPublicKey("12381'/9000'")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotSupported)
+}
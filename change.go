@@ -0,0 +1,88 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"fmt"
+
+	"github.com/zondax/ledger-avalanche-go/decode"
+)
+
+// OutputAddresses returns the addresses (20-byte hashes) owning the outputs of an unsigned
+// transaction issued on chain. Only the outputs returning funds on the same chain are read:
+// exported and staked outputs are not.
+func OutputAddresses(unsignedTx []byte, chain decode.Chain) ([][]byte, error) {
+	summary, err := decode.Decode(unsignedTx, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses [][]byte
+	for _, output := range summary.Outputs {
+		if output.Kind != decode.OutputTransfer {
+			continue
+		}
+		for i := range output.Owner.Addresses {
+			addresses = append(addresses, output.Owner.Addresses[i][:])
+		}
+	}
+	return addresses, nil
+}
+
+// DetectChangePaths returns the path suffixes (e.g "1/3") of the outputs of unsignedTx, issued on
+// chain, owned by the account, given its extended public key (e.g at "m/44'/9000'/0'"), to be
+// passed as change paths to Sign. The first count addresses of the external and change chains
+// are looked at.
+func DetectChangePaths(unsignedTx []byte, chain decode.Chain, account *ExtendedPublicKey, count uint32) ([]string, error) {
+	outputs, err := OutputAddresses(unsignedTx, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	owned := make(map[string]string)
+	for _, branch := range []uint32{externalChain, changeChain} {
+		branchKey, err := account.Child(branch)
+		if err != nil {
+			return nil, err
+		}
+		addresses, err := DeriveAddresses(branchKey, "", 0, count)
+		if err != nil {
+			return nil, err
+		}
+		for i, address := range addresses {
+			owned[string(address.Hash)] = fmt.Sprintf("%d/%d", branch, i)
+		}
+	}
+
+	var changePaths []string
+	for _, output := range outputs {
+		if suffix, ok := owned[string(output)]; ok {
+			changePaths = append(changePaths, suffix)
+		}
+	}
+	return RemoveDuplicates(changePaths), nil
+}
+
+// DetectChangePaths works as the DetectChangePaths function, reading the extended public key
+// of the account at pathPrefix (e.g "m/44'/9000'/0'") from the device
+func (ledger *LedgerAvalanche) DetectChangePaths(pathPrefix string, unsignedTx []byte, chain decode.Chain, count uint32) ([]string, error) {
+	account, err := ledger.GetExtendedPubKey(pathPrefix, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return DetectChangePaths(unsignedTx, chain, account, count)
+}
@@ -0,0 +1,117 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zondax/ledger-avalanche-go/decode"
+)
+
+// Type IDs of the P- and X-chain codecs
+const (
+	secp256k1TransferInputID  = 5
+	secp256k1TransferOutputID = 7
+	stakeableLockInputID      = 21
+	stakeableLockOutputID     = 22
+)
+
+// baseTx serializes an unsigned P-chain BaseTx paying each address in turn, the first one with a
+// stakeable locked output
+func baseTx(addresses ...[]byte) []byte {
+	tx := binary.BigEndian.AppendUint16(nil, 0)
+	tx = binary.BigEndian.AppendUint32(tx, 34)
+	tx = binary.BigEndian.AppendUint32(tx, 1)
+	tx = append(tx, make([]byte, 32)...)
+	tx = binary.BigEndian.AppendUint32(tx, uint32(len(addresses)))
+	for i, address := range addresses {
+		tx = append(tx, make([]byte, 32)...)
+		if i == 0 {
+			tx = binary.BigEndian.AppendUint32(tx, stakeableLockOutputID)
+			tx = binary.BigEndian.AppendUint64(tx, 0)
+		}
+		tx = binary.BigEndian.AppendUint32(tx, secp256k1TransferOutputID)
+		tx = binary.BigEndian.AppendUint64(tx, 1000)
+		tx = binary.BigEndian.AppendUint64(tx, 0)
+		tx = binary.BigEndian.AppendUint32(tx, 1)
+		tx = binary.BigEndian.AppendUint32(tx, 1)
+		tx = append(tx, address...)
+	}
+	// no inputs nor memo
+	tx = binary.BigEndian.AppendUint32(tx, 0)
+	return binary.BigEndian.AppendUint32(tx, 0)
+}
+
+func Test_OutputAddresses(t *testing.T) {
+	first, second := make([]byte, 20), make([]byte, 20)
+	first[0], second[0] = 1, 2
+
+	addresses, err := OutputAddresses(baseTx(first, second), decode.PChain)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{first, second}, addresses)
+
+	tx := baseTx(first)
+	_, err = OutputAddresses(tx[:60], decode.PChain)
+	assert.ErrorIs(t, err, ErrMalformedTransaction)
+
+	// type 34 is not an X-chain transaction
+	_, err = OutputAddresses(tx, decode.XChain)
+	assert.ErrorIs(t, err, ErrUnsupportedTransaction)
+
+	// exported outputs do not return funds on the chain
+	addresses, err = OutputAddresses(pChainExportTx(xChainID), decode.PChain)
+	require.NoError(t, err)
+	assert.Empty(t, addresses)
+}
+
+func Test_DetectChangePaths(t *testing.T) {
+	account := testVectorXPub()
+	changeKey, err := account.Child(changeChain)
+	require.NoError(t, err)
+	change, err := DeriveAddresses(changeKey, "", 2, 1)
+	require.NoError(t, err)
+
+	recipient := make([]byte, 20)
+	changePaths, err := DetectChangePaths(baseTx(recipient, change[0].Hash), decode.PChain, account, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1/2"}, changePaths)
+
+	changePaths, err = DetectChangePaths(baseTx(recipient), decode.PChain, account, 5)
+	require.NoError(t, err)
+	assert.Empty(t, changePaths)
+}
+
+func Test_LedgerDetectChangePaths(t *testing.T) {
+	account := testVectorXPub()
+	external, err := account.Child(externalChain)
+	require.NoError(t, err)
+	owned, err := DeriveAddresses(external, "", 0, 1)
+	require.NoError(t, err)
+
+	response := append(append([]byte{33}, account.PublicKey...), account.ChainCode...)
+	device := &mockDevice{handler: replies(response)}
+	ledger := newMockLedger(device)
+
+	changePaths, err := ledger.DetectChangePaths("m/44'/9000'/0'", baseTx(owned[0].Hash), decode.PChain, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0/0"}, changePaths)
+	assert.Equal(t, byte(INS_GET_EXTENDED_PUBLIC_KEY), device.sent[0][1])
+}
@@ -0,0 +1,48 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"github.com/zondax/ledger-avalanche-go/apdu"
+)
+
+// MaxAPDUReporter is implemented by transports advertising the longest APDU payload they
+// carry, e.g. an emulator or a bridge accepting frames longer than a HID report. Without
+// WithChunkSize, transactions are uploaded in chunks of that size.
+type MaxAPDUReporter interface {
+	MaxAPDUDataLength() int
+}
+
+// uploadChunkSize returns the size of the chunks a payload is uploaded in: the size set with
+// WithChunkSize, otherwise the one advertised by the transport, otherwise CHUNK_SIZE.
+// Chunks longer than apdu.MaxDataLen are only sent with WithExtendedLength.
+func (ledger *LedgerAvalanche) uploadChunkSize() int {
+	size := ledger.chunkSize
+	if size <= 0 {
+		size = CHUNK_SIZE
+		if reporter, ok := ledger.api.(MaxAPDUReporter); ok && reporter.MaxAPDUDataLength() > 0 {
+			size = reporter.MaxAPDUDataLength()
+		}
+	}
+	switch {
+	case size > apdu.MaxDataLen && !ledger.extendedLength:
+		return apdu.MaxDataLen
+	case size > apdu.MaxExtendedDataLen:
+		return apdu.MaxExtendedDataLen
+	}
+	return size
+}
@@ -0,0 +1,76 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// framedDevice is a mockDevice advertising the longest payload it carries
+type framedDevice struct {
+	*mockDevice
+	maxData int
+}
+
+func (d framedDevice) MaxAPDUDataLength() int {
+	return d.maxData
+}
+
+// uploadedChunks returns the payload sizes of the PAYLOAD_ADD and PAYLOAD_LAST commands of ins sent
+func uploadedChunks(sent [][]byte, ins byte) []int {
+	var sizes []int
+	for _, command := range sent {
+		if command[1] == ins && (command[2] == PAYLOAD_ADD || command[2] == PAYLOAD_LAST) {
+			sizes = append(sizes, len(command)-5)
+		}
+	}
+	return sizes
+}
+
+func Test_UploadChunkSize(t *testing.T) {
+	assert.Equal(t, CHUNK_SIZE, newMockLedger(&mockDevice{}).uploadChunkSize())
+	assert.Equal(t, 100, newMockLedger(&mockDevice{}, WithChunkSize(100)).uploadChunkSize())
+	assert.Equal(t, 200, newLedgerAvalanche(framedDevice{&mockDevice{}, 200}).uploadChunkSize())
+	assert.Equal(t, 100, newLedgerAvalanche(framedDevice{&mockDevice{}, 200}, WithChunkSize(100)).uploadChunkSize())
+	assert.Equal(t, 255, newLedgerAvalanche(framedDevice{&mockDevice{}, 4096}).uploadChunkSize())
+	assert.Equal(t, 4096, newLedgerAvalanche(framedDevice{&mockDevice{}, 4096}, WithExtendedLength(true)).uploadChunkSize())
+	assert.Equal(t, 65535, newMockLedger(&mockDevice{}, WithChunkSize(1<<20), WithExtendedLength(true)).uploadChunkSize())
+}
+
+func Test_SignWithChunkSize(t *testing.T) {
+	message := bytes.Repeat([]byte{0x01}, 500)
+
+	device := &mockDevice{}
+	ledger := newMockLedger(device, WithChunkSize(200))
+	_, err := ledger.SignMessage("m/44'/9000'/0'/0/0", message)
+	require.NoError(t, err)
+	assert.Equal(t, []int{200, 200, 100}, uploadedChunks(device.sent, INS_SIGN_MSG))
+
+	device = &mockDevice{}
+	ledger = newLedgerAvalanche(framedDevice{device, 1024}, WithExtendedLength(true))
+	_, err = ledger.SignMessage("m/44'/9000'/0'/0/0", message)
+	require.NoError(t, err)
+	last := device.sent[1]
+	assert.Equal(t, byte(PAYLOAD_LAST), last[2])
+	// extended length: a zero byte followed by the length on two bytes
+	assert.Equal(t, []byte{0x00, 0x01, 0xf4}, last[4:7])
+	assert.Equal(t, message, last[7:])
+}
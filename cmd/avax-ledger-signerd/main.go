@@ -0,0 +1,120 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// avax-ledger-signerd keeps a Ledger on a secured host and serves GetAddress, Sign and SignHash
+// over gRPC, authenticating its clients with mutual TLS.
+//
+//	avax-ledger-signerd -listen :9090 -cert server.pem -key server-key.pem -client-ca clients.pem
+//
+// Messages are encoded as JSON: clients call the methods of zondax.avalanche.ledger.Signer with
+// the "json" content subtype.
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	avax "github.com/zondax/ledger-avalanche-go"
+	"google.golang.org/grpc/credentials"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:9090", "address to listen on")
+	certFile := flag.String("cert", "", "server certificate (PEM)")
+	keyFile := flag.String("key", "", "server private key (PEM)")
+	clientCAFile := flag.String("client-ca", "", "CA certificates of the allowed clients (PEM)")
+	device := flag.Int("device", 0, "index of the Ledger device")
+	blindSigning := flag.Bool("allow-blind-signing", false, "serve SignHash requests")
+	walletID := flag.String("wallet-id", "", "refuse devices with another wallet ID (hex)")
+	flag.Parse()
+
+	tlsConfig, err := loadTLSConfig(*certFile, *keyFile, *clientCAFile)
+	if err != nil {
+		log.Fatalf("loading TLS configuration: %v", err)
+	}
+
+	opts := []avax.Option{
+		avax.AllowBlindSigning(*blindSigning),
+		avax.WithAuditLogger(func(entry avax.AuditEntry) {
+			log.Printf("sign hash: %+v", entry)
+		}),
+	}
+	if *walletID != "" {
+		id, err := hex.DecodeString(*walletID)
+		if err != nil {
+			log.Fatalf("invalid wallet ID: %v", err)
+		}
+		opts = append(opts, avax.WithExpectedWalletID(id))
+	}
+
+	ledger, err := avax.FindLedgerAvalancheAppOnDevice(*device, opts...)
+	if err != nil {
+		log.Fatalf("connecting to the Ledger: %v", err)
+	}
+	defer ledger.Close()
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("listening on %s: %v", *listen, err)
+	}
+
+	server := newServer(ledger, credentials.NewTLS(tlsConfig))
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		server.GracefulStop()
+	}()
+
+	log.Printf("serving on %s", listener.Addr())
+	if err := server.Serve(listener); err != nil {
+		log.Fatalf("serving: %v", err)
+	}
+}
+
+// loadTLSConfig returns a TLS configuration requiring clients to present a certificate signed by
+// one of the CAs in clientCAFile
+func loadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || clientCAFile == "" {
+		return nil, errors.New("-cert, -key and -client-ca are required")
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	pem, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + clientCAFile)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
@@ -0,0 +1,231 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	avax "github.com/zondax/ledger-avalanche-go"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// serviceName is the full name of the gRPC service served by the daemon
+const serviceName = "zondax.avalanche.ledger.Signer"
+
+// GetAddressRequest asks for the address of Path, shown on the device if Show is set
+type GetAddressRequest struct {
+	Path    string `json:"path"`
+	Hrp     string `json:"hrp,omitempty"`
+	ChainID string `json:"chain_id,omitempty"`
+	Show    bool   `json:"show,omitempty"`
+}
+
+// GetAddressResponse holds the address of a GetAddressRequest
+type GetAddressResponse struct {
+	Address string `json:"address"`
+}
+
+// SignRequest asks to sign Message with the keys of SigningPaths, relative to PathPrefix
+type SignRequest struct {
+	PathPrefix   string   `json:"path_prefix"`
+	SigningPaths []string `json:"signing_paths"`
+	ChangePaths  []string `json:"change_paths,omitempty"`
+	Message      []byte   `json:"message"`
+}
+
+// SignHashRequest asks to sign Hash blindly with the keys of SigningPaths, relative to PathPrefix
+type SignHashRequest struct {
+	PathPrefix   string   `json:"path_prefix"`
+	SigningPaths []string `json:"signing_paths"`
+	Hash         []byte   `json:"hash"`
+}
+
+// Signature is the signature of a single signing path
+type Signature struct {
+	Path      string `json:"path"`
+	Signature []byte `json:"signature"`
+}
+
+// SignResponse holds the hash signed by the device and the signatures in request order
+type SignResponse struct {
+	Hash       []byte      `json:"hash"`
+	Signatures []Signature `json:"signatures"`
+}
+
+// jsonCodec encodes the messages of the service as JSON, so neither the daemon nor its clients
+// need generated protobuf code. It is registered next to the default codecs and clients select
+// it with the "json" content subtype, i.e. grpc.CallContentSubtype("json").
+type jsonCodec struct{}
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+// signerService serves the requests of the daemon with a single ledger
+type signerService struct {
+	ledger *avax.LedgerAvalanche
+}
+
+// newServer returns a gRPC server of the signer service using ledger. creds enables mTLS;
+// without it the server is plaintext, which is only meant for tests.
+func newServer(ledger *avax.LedgerAvalanche, creds credentials.TransportCredentials) *grpc.Server {
+	var opts []grpc.ServerOption
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	server := grpc.NewServer(opts...)
+	server.RegisterService(&serviceDesc, &signerService{ledger: ledger})
+	return server
+}
+
+func (s *signerService) GetAddress(ctx context.Context, req *GetAddressRequest) (*GetAddressResponse, error) {
+	address, err := s.ledger.GetAddress(req.Path, req.Hrp, req.ChainID, req.Show)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &GetAddressResponse{Address: address}, nil
+}
+
+func (s *signerService) Sign(ctx context.Context, req *SignRequest) (*SignResponse, error) {
+	response, err := s.ledger.SignContext(ctx, req.PathPrefix, req.SigningPaths, req.Message, req.ChangePaths)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSignResponse(response), nil
+}
+
+func (s *signerService) SignHash(ctx context.Context, req *SignHashRequest) (*SignResponse, error) {
+	ctx = avax.WithReason(ctx, "gRPC request from "+clientName(ctx))
+	response, err := s.ledger.SignHashContext(ctx, req.PathPrefix, req.SigningPaths, req.Hash)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toSignResponse(response), nil
+}
+
+func toSignResponse(response *avax.ResponseSign) *SignResponse {
+	signatures := make([]Signature, 0, len(response.SignaturesOrdered))
+	for _, signature := range response.SignaturesOrdered {
+		signatures = append(signatures, Signature{Path: signature.Path, Signature: signature.Signature})
+	}
+	return &SignResponse{Hash: response.Hash, Signatures: signatures}
+}
+
+// clientName returns the common name of the client certificate of the request, or its address
+// when the connection is not authenticated
+func clientName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown client"
+	}
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+		return info.State.PeerCertificates[0].Subject.CommonName
+	}
+	return p.Addr.String()
+}
+
+// toStatus maps the errors of the ledger to gRPC status codes
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, avax.ErrUserRejected), errors.Is(err, avax.ErrBlindSigningDisabled):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, avax.ErrBusy), errors.Is(err, avax.ErrDeviceDisconnected),
+		errors.Is(err, avax.ErrLocked), errors.Is(err, avax.ErrAppNotOpen):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, avax.ErrConfirmationTimeout), errors.Is(err, avax.ErrExchangeTimeout),
+		errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, avax.ErrInvalidPathComponent), errors.Is(err, avax.ErrNoSigningPaths),
+		errors.Is(err, avax.ErrTooManySigningPaths), errors.Is(err, avax.ErrAPDUTooLong):
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+func getAddressHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(GetAddressRequest)
+	if err := dec(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	call := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*signerService).GetAddress(ctx, req.(*GetAddressRequest))
+	}
+	return intercept(srv, ctx, req, "GetAddress", interceptor, call)
+}
+
+func signHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(SignRequest)
+	if err := dec(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	call := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*signerService).Sign(ctx, req.(*SignRequest))
+	}
+	return intercept(srv, ctx, req, "Sign", interceptor, call)
+}
+
+func signHashHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	req := new(SignHashRequest)
+	if err := dec(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	call := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(*signerService).SignHash(ctx, req.(*SignHashRequest))
+	}
+	return intercept(srv, ctx, req, "SignHash", interceptor, call)
+}
+
+// intercept runs call through the interceptor of the server, if any
+func intercept(srv interface{}, ctx context.Context, req interface{}, method string, interceptor grpc.UnaryServerInterceptor, call grpc.UnaryHandler) (interface{}, error) {
+	if interceptor == nil {
+		return call(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + method}
+	return interceptor(ctx, req, info, call)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "GetAddress", Handler: getAddressHandler},
+		{MethodName: "Sign", Handler: signHandler},
+		{MethodName: "SignHash", Handler: signHashHandler},
+	},
+	Metadata: "avax-ledger-signerd",
+}
@@ -0,0 +1,177 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	avax "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testMnemonic = "equip will roof matter pink blind book anxiety banner elbow sun young"
+
+// startServer serves a mock ledger over mTLS and returns a connection of a client named "client"
+func startServer(t *testing.T, opts ...avax.Option) (*grpc.ClientConn, *mock.MockLedger) {
+	ledger, err := mock.NewMockLedgerFromMnemonic(testMnemonic, opts...)
+	require.NoError(t, err)
+
+	ca := newTestCA(t)
+	config, err := loadTLSConfig(writeServerFiles(t, ca))
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 16)
+	server := newServer(ledger.LedgerAvalanche, credentials.NewTLS(config))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	certPEM, keyPEM := ca.issue(t, "client")
+	clientCert, err := tls.X509KeyPair(certPEM, keyPEM)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCreds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      roots,
+		ServerName:   "localhost",
+	})
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(clientCreds),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, ledger
+}
+
+func invoke(conn *grpc.ClientConn, method string, req interface{}, resp interface{}) error {
+	return conn.Invoke(context.Background(), "/"+serviceName+"/"+method, req, resp)
+}
+
+func Test_GetAddress(t *testing.T) {
+	conn, ledger := startServer(t)
+
+	var resp GetAddressResponse
+	err := invoke(conn, "GetAddress", &GetAddressRequest{Path: "m/44'/9000'/0'/0/0", Hrp: "avax"}, &resp)
+	require.NoError(t, err)
+
+	expected, err := ledger.GetAddress("m/44'/9000'/0'/0/0", "avax", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, expected, resp.Address)
+}
+
+func Test_Sign(t *testing.T) {
+	conn, ledger := startServer(t)
+	message := []byte("unsigned transaction")
+
+	var resp SignResponse
+	err := invoke(conn, "Sign", &SignRequest{
+		PathPrefix:   "m/44'/9000'/0'",
+		SigningPaths: []string{"0/0", "0/1"},
+		Message:      message,
+	}, &resp)
+	require.NoError(t, err)
+
+	expected, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, message, nil)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Hash, resp.Hash)
+	require.Len(t, resp.Signatures, 2)
+	assert.Equal(t, "0/0", resp.Signatures[0].Path)
+	assert.Equal(t, expected.Signature["0/1"], resp.Signatures[1].Signature)
+}
+
+func Test_SignHashRefusedByDefault(t *testing.T) {
+	conn, _ := startServer(t)
+
+	var resp SignResponse
+	err := invoke(conn, "SignHash", &SignHashRequest{
+		PathPrefix:   "m/44'/9000'/0'",
+		SigningPaths: []string{"0/0"},
+		Hash:         make([]byte, 32),
+	}, &resp)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func Test_SignHashAudited(t *testing.T) {
+	var entries []avax.AuditEntry
+	conn, _ := startServer(t, avax.AllowBlindSigning(true), avax.WithAuditLogger(func(entry avax.AuditEntry) {
+		entries = append(entries, entry)
+	}))
+
+	var resp SignResponse
+	err := invoke(conn, "SignHash", &SignHashRequest{
+		PathPrefix:   "m/44'/9000'/0'",
+		SigningPaths: []string{"0/0"},
+		Hash:         make([]byte, 32),
+	}, &resp)
+	require.NoError(t, err)
+	assert.Len(t, resp.Signatures, 1)
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].Reason, "client")
+}
+
+func Test_InvalidPath(t *testing.T) {
+	conn, _ := startServer(t)
+
+	var resp GetAddressResponse
+	err := invoke(conn, "GetAddress", &GetAddressRequest{Path: "m/44'/x", Hrp: "avax"}, &resp)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_ClientWithoutCertificate(t *testing.T) {
+	ledger, err := mock.NewMockLedgerFromMnemonic(testMnemonic)
+	require.NoError(t, err)
+	ca := newTestCA(t)
+	config, err := loadTLSConfig(writeServerFiles(t, ca))
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1 << 16)
+	server := newServer(ledger.LedgerAvalanche, credentials.NewTLS(config))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: roots, ServerName: "localhost"})),
+		grpc.WithDefaultCallOptions(grpc.CallContentSubtype("json")),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var resp GetAddressResponse
+	err = invoke(conn, "GetAddress", &GetAddressRequest{Path: "m/44'/9000'/0'/0/0", Hrp: "avax"}, &resp)
+	assert.Error(t, err)
+}
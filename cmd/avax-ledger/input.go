@@ -0,0 +1,81 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// errNoTransaction is returned when neither an argument nor a file gives the transaction
+var errNoTransaction = errors.New("no transaction given")
+
+// txJSON is the JSON form of an unsigned transaction, as printed by avalanchego tools
+type txJSON struct {
+	Tx         string `json:"tx"`
+	UnsignedTx string `json:"unsignedTx"`
+}
+
+// readInput returns the bytes given as arg, or read from file ("-" for stdin) when arg is empty
+func readInput(arg string, file string, stdin io.Reader) ([]byte, error) {
+	switch {
+	case arg != "":
+		return []byte(arg), nil
+	case file == "-":
+		return io.ReadAll(stdin)
+	case file != "":
+		return os.ReadFile(file)
+	}
+	return nil, errNoTransaction
+}
+
+// decodeTx decodes an unsigned transaction given as hex (with or without 0x), as a JSON object
+// with a "tx" or "unsignedTx" hex field or, when allowRaw is set as for files, as raw bytes
+func decodeTx(input []byte, allowRaw bool) ([]byte, error) {
+	text := strings.TrimSpace(string(input))
+	if strings.HasPrefix(text, "{") {
+		var tx txJSON
+		if err := json.Unmarshal([]byte(text), &tx); err != nil {
+			return nil, err
+		}
+		if tx.Tx == "" {
+			tx.Tx = tx.UnsignedTx
+		}
+		if tx.Tx == "" {
+			return nil, errNoTransaction
+		}
+		return decodeHex(tx.Tx)
+	}
+	decoded, err := decodeHex(text)
+	if err == nil || !allowRaw || errors.Is(err, errNoTransaction) {
+		return decoded, err
+	}
+	return input, nil
+}
+
+// decodeHex decodes a hex string with an optional 0x prefix
+func decodeHex(text string) ([]byte, error) {
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "0x"), "0X")
+	if text == "" {
+		return nil, errNoTransaction
+	}
+	return hex.DecodeString(text)
+}
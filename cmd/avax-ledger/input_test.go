@@ -0,0 +1,82 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecodeTx(t *testing.T) {
+	expected := []byte{0x00, 0x00, 0x00, 0x01, 0xab}
+
+	for _, input := range []string{
+		"00000001ab",
+		"0x00000001ab\n",
+		`{"tx": "0x00000001ab"}`,
+		`{"unsignedTx": "00000001ab"}`,
+	} {
+		tx, err := decodeTx([]byte(input), false)
+		require.NoError(t, err, input)
+		assert.Equal(t, expected, tx, input)
+	}
+}
+
+func Test_DecodeTxRaw(t *testing.T) {
+	raw := []byte{0x00, 0x00, 0xff, 0x10}
+
+	tx, err := decodeTx(raw, true)
+	require.NoError(t, err)
+	assert.Equal(t, raw, tx)
+
+	// arguments are never raw bytes
+	_, err = decodeTx([]byte("00000001ag"), false)
+	assert.Error(t, err)
+}
+
+func Test_DecodeTxEmpty(t *testing.T) {
+	_, err := decodeTx([]byte(" \n"), true)
+	assert.ErrorIs(t, err, errNoTransaction)
+
+	_, err = decodeTx([]byte(`{"memo": "x"}`), true)
+	assert.ErrorIs(t, err, errNoTransaction)
+}
+
+func Test_ReadInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tx.hex")
+	require.NoError(t, os.WriteFile(path, []byte("00ab"), 0o600))
+
+	input, err := readInput("", path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("00ab"), input)
+
+	input, err = readInput("", "-", strings.NewReader("01cd"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("01cd"), input)
+
+	input, err = readInput("02ef", path, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("02ef"), input)
+
+	_, err = readInput("", "", nil)
+	assert.ErrorIs(t, err, errNoTransaction)
+}
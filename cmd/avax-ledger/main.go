@@ -0,0 +1,212 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// avax-ledger drives the Avalanche app of a Ledger from the command line:
+//
+//	avax-ledger version
+//	avax-ledger addr [-hrp avax] [-chain-id id] [-show] m/44'/9000'/0'/0/0
+//	avax-ledger sign [-prefix m/44'/9000'/0'] -paths 0/0,0/1 [-change 1/0] [-file tx.json] [tx]
+//	avax-ledger -allow-blind-signing sign-hash [-prefix m/44'/9000'/0'] -paths 0/0 hash
+//
+// Transactions are given as hex, as JSON with a "tx" or "unsignedTx" hex field, or as raw bytes
+// in a file ("-" reads stdin). Signatures are printed as hex, one "path signature" per line.
+// sign-hash signs a hash the device cannot decode, and is refused without -allow-blind-signing.
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	avax "github.com/zondax/ledger-avalanche-go"
+)
+
+// cli holds what the commands need, so tests can use a mock ledger
+type cli struct {
+	connect func() (*avax.LedgerAvalanche, error)
+	stdin   io.Reader
+	stdout  io.Writer
+}
+
+// command is a subcommand of the tool
+type command struct {
+	usage string
+	run   func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"version":   {usage: "version", run: runVersion},
+	"addr":      {usage: "addr [-hrp hrp] [-chain-id id] [-show] path", run: runAddr},
+	"sign":      {usage: "sign [-prefix path] -paths p1,p2 [-change c1,c2] [-file file] [tx]", run: runSign},
+	"sign-hash": {usage: "sign-hash [-prefix path] -paths p1,p2 hash", run: runSignHash},
+}
+
+func main() {
+	device := flag.Int("device", 0, "index of the Ledger device")
+	blindSigning := flag.Bool("allow-blind-signing", false, "allow sign-hash, which signs hashes the device cannot decode")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	c := &cli{
+		connect: func() (*avax.LedgerAvalanche, error) {
+			return avax.FindLedgerAvalancheAppOnDevice(*device, avax.AllowBlindSigning(*blindSigning))
+		},
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+	}
+	if err := c.run(flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "avax-ledger:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(flag.CommandLine.Output(), "usage: avax-ledger [-device index] [-allow-blind-signing] command [flags] [args]")
+	for _, name := range []string{"version", "addr", "sign", "sign-hash"} {
+		fmt.Fprintln(flag.CommandLine.Output(), "  avax-ledger", commands[name].usage)
+	}
+}
+
+// run runs the command named by args[0]
+func (c *cli) run(args []string) error {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return cmd.run(c, args[1:])
+}
+
+// withLedger connects to the ledger, runs fn and closes it
+func (c *cli) withLedger(fn func(ledger *avax.LedgerAvalanche) error) error {
+	ledger, err := c.connect()
+	if err != nil {
+		return err
+	}
+	defer ledger.Close()
+	return fn(ledger)
+}
+
+func runVersion(c *cli, args []string) error {
+	return c.withLedger(func(ledger *avax.LedgerAvalanche) error {
+		version, err := ledger.GetVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, version)
+		return nil
+	})
+}
+
+func runAddr(c *cli, args []string) error {
+	flags := flag.NewFlagSet("addr", flag.ContinueOnError)
+	hrp := flags.String("hrp", "avax", "human readable part of the address")
+	chainID := flags.String("chain-id", "", "CB58 chain ID of the address, empty for the P-chain")
+	show := flags.Bool("show", false, "show the address on the device")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("addr needs a single path")
+	}
+
+	return c.withLedger(func(ledger *avax.LedgerAvalanche) error {
+		address, err := ledger.GetAddress(flags.Arg(0), *hrp, *chainID, *show)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, address)
+		return nil
+	})
+}
+
+func runSign(c *cli, args []string) error {
+	flags := flag.NewFlagSet("sign", flag.ContinueOnError)
+	prefix := flags.String("prefix", "m/44'/9000'/0'", "path prefix of the signing and change paths")
+	paths := flags.String("paths", "", "comma separated signing paths, relative to the prefix")
+	change := flags.String("change", "", "comma separated change paths, relative to the prefix")
+	file := flags.String("file", "", "file holding the transaction, - for stdin")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input, err := readInput(flags.Arg(0), *file, c.stdin)
+	if err != nil {
+		return err
+	}
+	// raw bytes are only read from files, a mistyped argument is reported
+	tx, err := decodeTx(input, flags.Arg(0) == "")
+	if err != nil {
+		return err
+	}
+
+	return c.withLedger(func(ledger *avax.LedgerAvalanche) error {
+		response, err := ledger.Sign(*prefix, splitPaths(*paths), tx, splitPaths(*change))
+		if err != nil {
+			return err
+		}
+		c.printSignatures(response)
+		return nil
+	})
+}
+
+func runSignHash(c *cli, args []string) error {
+	flags := flag.NewFlagSet("sign-hash", flag.ContinueOnError)
+	prefix := flags.String("prefix", "m/44'/9000'/0'", "path prefix of the signing paths")
+	paths := flags.String("paths", "", "comma separated signing paths, relative to the prefix")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("sign-hash needs a single hash")
+	}
+	hash, err := decodeHex(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	return c.withLedger(func(ledger *avax.LedgerAvalanche) error {
+		response, err := ledger.SignHash(*prefix, splitPaths(*paths), hash)
+		if err != nil {
+			return err
+		}
+		c.printSignatures(response)
+		return nil
+	})
+}
+
+// printSignatures prints the signed hash, then each signature in request order
+func (c *cli) printSignatures(response *avax.ResponseSign) {
+	fmt.Fprintln(c.stdout, "hash", hex.EncodeToString(response.Hash))
+	for _, signature := range response.SignaturesOrdered {
+		fmt.Fprintln(c.stdout, signature.Path, hex.EncodeToString(signature.Signature))
+	}
+}
+
+// splitPaths splits a comma separated list of paths
+func splitPaths(paths string) []string {
+	if paths == "" {
+		return nil
+	}
+	return strings.Split(paths, ",")
+}
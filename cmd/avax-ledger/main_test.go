@@ -0,0 +1,101 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	avax "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/mock"
+)
+
+const testMnemonic = "equip will roof matter pink blind book anxiety banner elbow sun young"
+
+// runCLI runs args against a mock ledger and returns the output
+func runCLI(t *testing.T, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	c := &cli{
+		connect: func() (*avax.LedgerAvalanche, error) {
+			ledger, err := mock.NewMockLedgerFromMnemonic(testMnemonic, avax.AllowBlindSigning(true))
+			if err != nil {
+				return nil, err
+			}
+			return ledger.LedgerAvalanche, nil
+		},
+		stdin:  strings.NewReader(""),
+		stdout: &stdout,
+	}
+	err := c.run(args)
+	return stdout.String(), err
+}
+
+func Test_Version(t *testing.T) {
+	out, err := runCLI(t, "version")
+	require.NoError(t, err)
+	assert.Equal(t, "0.6.5\n", out)
+}
+
+func Test_Addr(t *testing.T) {
+	ledger, err := mock.NewMockLedgerFromMnemonic(testMnemonic)
+	require.NoError(t, err)
+	expected, err := ledger.GetAddress("m/44'/9000'/0'/0/0", "avax", "", false)
+	require.NoError(t, err)
+
+	out, err := runCLI(t, "addr", "m/44'/9000'/0'/0/0")
+	require.NoError(t, err)
+	assert.Equal(t, expected+"\n", out)
+}
+
+func Test_Sign(t *testing.T) {
+	message := []byte("unsigned transaction")
+	ledger, err := mock.NewMockLedgerFromMnemonic(testMnemonic)
+	require.NoError(t, err)
+	expected, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, message, nil)
+	require.NoError(t, err)
+
+	out, err := runCLI(t, "sign", "-paths", "0/0,0/1", hex.EncodeToString(message))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, "hash "+hex.EncodeToString(expected.Hash), lines[0])
+	assert.Equal(t, "0/0 "+hex.EncodeToString(expected.Signature["0/0"]), lines[1])
+	assert.Equal(t, "0/1 "+hex.EncodeToString(expected.Signature["0/1"]), lines[2])
+}
+
+func Test_SignHash(t *testing.T) {
+	out, err := runCLI(t, "sign-hash", "-paths", "0/0", strings.Repeat("ab", 32))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "hash "+strings.Repeat("ab", 32), lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], "0/0 "))
+}
+
+func Test_UnknownCommand(t *testing.T) {
+	_, err := runCLI(t, "export")
+	assert.Error(t, err)
+}
+
+func Test_SignWithoutTransaction(t *testing.T) {
+	_, err := runCLI(t, "sign", "-paths", "0/0")
+	assert.ErrorIs(t, err, errNoTransaction)
+}
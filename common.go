@@ -17,18 +17,32 @@
 package ledger_avalanche_go
 
 import (
+	"crypto/sha256"
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 
-	"github.com/mr-tron/base58"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/zondax/ledger-avalanche-go/apdu"
+	"github.com/zondax/ledger-avalanche-go/serialize"
 )
 
 func (e VersionRequiredError) Error() string {
-	//return fmt.Sprintf("App Version required %s - Version found: %s", e.Required, e.Found)
-	return fmt.Sprintf("App Version required %s - Version found: %s", "asd", "123")
+	return fmt.Sprintf("App Version required %s - Version found: %s", e.Required, e.Found)
+}
+
+func (e *ErrVersionTooLow) Error() string {
+	return fmt.Sprintf("App Version required %s - Version found: %s", e.Want, e.Have)
+}
+
+// As lets errors.As match an ErrVersionTooLow with the former *VersionRequiredError
+func (e *ErrVersionTooLow) As(target interface{}) bool {
+	if t, ok := target.(**VersionRequiredError); ok {
+		*t = &VersionRequiredError{Found: e.Have, Required: e.Want}
+		return true
+	}
+	return false
 }
 
 // CheckVersion compares the current version with the required version
@@ -54,123 +68,112 @@ func CheckVersion(ver VersionInfo, req VersionInfo) error {
 }
 
 func NewVersionRequiredError(req VersionInfo, ver VersionInfo) error {
-	return &VersionRequiredError{
-		Found:    ver,
-		Required: req,
+	return &ErrVersionTooLow{
+		Have: ver,
+		Want: req,
 	}
 }
 
-func SerializePath(path string) ([]byte, error) {
-	if !strings.HasPrefix(path, "m") {
-		return nil, errors.New(`Path should start with "m" (e.g "m/44\'/5757\'/5\'/0/3")`)
-	}
-
-	pathArray := strings.Split(path, "/")
+// buildAPDU assembles an APDU with the concatenation of data as payload. It fails with
+// ErrAPDUTooLong when the payload does not fit in the single byte length field.
+func buildAPDU(cla, ins, p1, p2 byte, data ...[]byte) ([]byte, error) {
+	return apdu.New(cla, ins, p1, p2, data...).Bytes()
+}
 
-	if len(pathArray) != 6 && len(pathArray) != 5 && len(pathArray) != 4 {
-		return nil, errors.New("Invalid path. (e.g \"m/44'/5757'/5'/0/3\")")
+// buildCommand works as buildAPDU, using an extended length for the upload chunks longer than
+// apdu.MaxDataLen when WithExtendedLength is set
+func (ledger *LedgerAvalanche) buildCommand(cla, ins, p1, p2 byte, data ...[]byte) ([]byte, error) {
+	command := apdu.New(cla, ins, p1, p2, data...)
+	if ledger.extendedLength && len(command.Data) > apdu.MaxDataLen {
+		return command.ExtendedBytes()
 	}
+	return command.Bytes()
+}
 
-	buf := make([]byte, 1+(len(pathArray)-1)*4)
-	buf[0] = byte(len(pathArray) - 1) // first byte is the path length
+// PathSerializer encodes derivation paths, HRPs and chain IDs in the wire format expected by the app.
+// LedgerAvalanche uses DefaultPathSerializer unless another one is set with WithPathSerializer.
+type PathSerializer interface {
+	SerializePath(path string) ([]byte, error)
+	SerializePathSuffix(path string) ([]byte, error)
+	SerializeHrp(hrp string) ([]byte, error)
+	SerializeChainID(chainID string) ([]byte, error)
+}
 
-	for i := 1; i < len(pathArray); i++ {
-		var value uint32
-		child := pathArray[i]
-		if strings.HasSuffix(child, "'") {
-			value += HARDENED
-			child = child[:len(child)-1]
-		}
+// DefaultPathSerializer implements PathSerializer with the package level functions
+type DefaultPathSerializer struct{}
 
-		childNumber, err := strconv.ParseUint(child, 10, 32)
-		if err != nil {
-			return nil, fmt.Errorf("Invalid path : %s is not a number. (e.g \"m/44'/461'/5'/0/3\")", child)
-		}
+func (DefaultPathSerializer) SerializePath(path string) ([]byte, error) {
+	return SerializePath(path)
+}
 
-		if childNumber >= HARDENED {
-			return nil, errors.New("Incorrect child value (bigger or equal to 0x80000000)")
-		}
+func (DefaultPathSerializer) SerializePathSuffix(path string) ([]byte, error) {
+	return SerializePathSuffix(path)
+}
 
-		value += uint32(childNumber)
+func (DefaultPathSerializer) SerializeHrp(hrp string) ([]byte, error) {
+	return SerializeHrp(hrp)
+}
 
-		binary.BigEndian.PutUint32(buf[1+4*(i-1):1+4*i], value)
-	}
+func (DefaultPathSerializer) SerializeChainID(chainID string) ([]byte, error) {
+	return SerializeChainID(chainID)
+}
 
-	return buf, nil
+func SerializePath(path string) ([]byte, error) {
+	return serialize.Path(path)
 }
 
-func SerializePathSuffix(path string) ([]byte, error) {
-	if strings.HasPrefix(path, "m") {
-		return nil, errors.New(`Path suffix do not start with "m" (e.g "0/3")`)
-	}
+// ParsePath parses a BIP32 path of any depth up to MAX_BIP32_PATH (e.g "m/44'/9000'/0'/0/3")
+// into its child numbers, see serialize.ParsePath
+func ParsePath(path string) ([]uint32, error) {
+	return serialize.ParsePath(path)
+}
 
-	pathArray := strings.Split(path, "/")
-	if len(pathArray) != 2 {
-		return nil, errors.New(`Invalid path suffix. (e.g "0/3")`)
-	}
+// FormatPath formats child numbers as a BIP32 path, marking hardened components with '
+func FormatPath(components []uint32) string {
+	return serialize.FormatPath(components)
+}
 
-	buf := make([]byte, 1+len(pathArray)*4)
-	buf[0] = byte(len(pathArray))
+// AvalanchePath builds the BIP44 path m/44'/9000'/account'/change/index
+func AvalanchePath(account, change, index uint32) (string, error) {
+	return bip44Path(AVAX_COIN_TYPE, account, change, index)
+}
 
-	for i, child := range pathArray {
-		value := 0
-		if strings.HasSuffix(child, "'") {
-			return nil, errors.New(`Invalid hardened path suffix. (e.g "0/3")`)
-		}
-		childNumber, err := strconv.Atoi(child)
-		if err != nil {
-			return nil, errors.New(`Invalid path: ` + child + ` is not a number. (e.g "0/3")`)
-		}
-		if childNumber >= HARDENED {
-			return nil, errors.New(`Incorrect child value (bigger or equal to 0x80000000)`)
+// EVMPath builds the BIP44 path m/44'/60'/account'/change/index used for C-chain addresses
+func EVMPath(account, change, index uint32) (string, error) {
+	return bip44Path(EVM_COIN_TYPE, account, change, index)
+}
+
+func bip44Path(coinType, account, change, index uint32) (string, error) {
+	for _, child := range []uint32{account, change, index} {
+		if child >= HARDENED {
+			return "", errors.New("Incorrect child value (bigger or equal to 0x80000000)")
 		}
-		value += childNumber
+	}
+	return fmt.Sprintf("m/44'/%d'/%d'/%d/%d", coinType, account, change, index), nil
+}
 
-		buf[1+4*i] = byte(value >> 24)
-		buf[2+4*i] = byte(value >> 16)
-		buf[3+4*i] = byte(value >> 8)
-		buf[4+4*i] = byte(value)
+// SplitPath splits a full path (e.g "m/44'/9000'/0'/0/3") into the account prefix ("m/44'/9000'/0'")
+// and the suffix ("0/3") used by the signing methods
+func SplitPath(path string) (prefix string, suffix string, err error) {
+	pathArray := strings.Split(path, "/")
+	if len(pathArray) != 6 {
+		return "", "", errors.New("Invalid path. (e.g \"m/44'/9000'/0'/0/0\")")
 	}
+	return strings.Join(pathArray[:4], "/"), strings.Join(pathArray[4:], "/"), nil
+}
 
-	return buf, nil
+func SerializePathSuffix(path string) ([]byte, error) {
+	return serialize.PathSuffix(path)
 }
 
 // SerializeChainID serializes a chain ID into a byte slice
 func SerializeChainID(chainID string) ([]byte, error) {
-	if chainID == "" {
-		return []byte{0}, nil
-	}
-
-	decoded, err := base58.Decode(chainID)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(decoded) == 36 {
-		// chop checksum off
-		decoded = decoded[:32]
-	} else if len(decoded) != 32 {
-		return nil, errors.New("ChainID was not 32 bytes long (encoded with base58)")
-	}
-
-	return append([]byte{byte(len(decoded))}, decoded...), nil
+	return serialize.ChainID(chainID)
 }
 
 // SerializeHrp serializes an HRP into a byte slice
 func SerializeHrp(hrp string) ([]byte, error) {
-	if hrp == "" {
-		return []byte{0}, nil
-	}
-
-	bufHrp := make([]byte, 0, len(hrp))
-	for _, c := range hrp {
-		if c < 33 || c > 126 {
-			return nil, errors.New("all characters in the HRP must be in the [33, 126] range")
-		}
-		bufHrp = append(bufHrp, byte(c))
-	}
-
-	return append([]byte{byte(len(bufHrp))}, bufHrp...), nil
+	return serialize.Hrp(hrp)
 }
 
 func RemoveDuplicates(elements []string) []string {
@@ -191,17 +194,91 @@ func RemoveDuplicates(elements []string) []string {
 }
 
 func ConcatMessageAndChangePath(message []byte, path []string) []byte {
-	msg := append([]byte{}, message...)
+	buffer, err := serializeChangePaths(DefaultPathSerializer{}, path)
+	if err != nil {
+		return nil
+	}
+	return append(buffer, message...)
+}
+
+// serializeChangePaths serializes the change path header that precedes a transaction
+func serializeChangePaths(serializer PathSerializer, path []string) ([]byte, error) {
 	if path == nil {
-		return append([]byte{0}, msg...)
+		return []byte{0}, nil
 	}
 	buffer := []byte{byte(len(path))}
 	for _, element := range path {
-		pathBuf, err := SerializePathSuffix(element)
+		pathBuf, err := serializer.SerializePathSuffix(element)
 		if err != nil {
-			return nil
+			return nil, err
 		}
 		buffer = append(buffer, pathBuf...)
 	}
-	return append(buffer, msg...)
+	return buffer, nil
+}
+
+// AvalancheMessageHash returns the digest signed by the app for a message:
+// sha256(AVAX_MSG_PREFIX || uint32 big-endian message length || message)
+func AvalancheMessageHash(message []byte) []byte {
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(message)))
+
+	h := sha256.New()
+	h.Write([]byte(AVAX_MSG_PREFIX))
+	h.Write(length)
+	h.Write(message)
+	return h.Sum(nil)
+}
+
+// ComputeSignHash returns the digest the app signs for a transaction passed to Sign: the
+// sha256 of the serialized unsigned transaction, excluding the change path header.
+// The same scheme applies to X-chain, P-chain and C-chain atomic (import/export) transactions.
+// Messages passed to SignMessage are hashed with AvalancheMessageHash instead.
+func ComputeSignHash(message []byte) ([]byte, error) {
+	if len(message) == 0 {
+		return nil, errors.New("empty transaction")
+	}
+	hash := sha256.Sum256(message)
+	return hash[:], nil
+}
+
+// FormatPublicKey encodes a compressed or uncompressed secp256k1 public key in the requested format.
+// It fails if the key is not a valid point on the curve.
+func FormatPublicKey(publicKey []byte, format PublicKeyFormat) ([]byte, error) {
+	key, err := btcec.ParsePubKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case Compressed:
+		return key.SerializeCompressed(), nil
+	case Uncompressed:
+		return key.SerializeUncompressed(), nil
+	default:
+		return nil, fmt.Errorf("unknown public key format %d", format)
+	}
+}
+
+// NormalizeLowS returns signature (r || s, optionally followed by the recovery id v) with s
+// in the lower half of the curve order, flipping v accordingly. Low-S signatures are the
+// canonical, non-malleable form. Signatures already in low-S form are returned unchanged.
+func NormalizeLowS(signature []byte) []byte {
+	if len(signature) < 64 {
+		return signature
+	}
+
+	var s btcec.ModNScalar
+	if s.SetByteSlice(signature[32:64]) || !s.IsOverHalfOrder() {
+		return signature
+	}
+	s.Negate()
+
+	normalized := append([]byte{}, signature...)
+	sBytes := s.Bytes()
+	copy(normalized[32:64], sBytes[:])
+	if len(normalized) > 64 {
+		normalized[64] ^= 1
+	}
+	return normalized
 }
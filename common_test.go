@@ -17,9 +17,15 @@
 package ledger_avalanche_go
 
 import (
+	"encoding/hex"
 	"fmt"
-	"github.com/stretchr/testify/assert"
+	"math/big"
 	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_PrintVersion(t *testing.T) {
@@ -39,6 +45,39 @@ func Test_SerializePath(t *testing.T) {
 	assert.Equal(t, expectedSerializedPath, serializedPath)
 }
 
+func Test_AvalanchePath(t *testing.T) {
+	path, err := AvalanchePath(0, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "m/44'/9000'/0'/0/0", path)
+
+	path, err = AvalanchePath(2, 1, 7)
+	require.NoError(t, err)
+	assert.Equal(t, "m/44'/9000'/2'/1/7", path)
+
+	_, err = SerializePath(path)
+	assert.NoError(t, err)
+
+	_, err = AvalanchePath(HARDENED, 0, 0)
+	assert.Error(t, err)
+	_, err = AvalanchePath(0, 0, HARDENED)
+	assert.Error(t, err)
+}
+
+func Test_EVMPath(t *testing.T) {
+	path, err := EVMPath(0, 0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "m/44'/60'/0'/0/3", path)
+	assert.NoError(t, ValidateEVMPath(path))
+	assert.NoError(t, ValidateEVMPath("m/44h/60H/0h/0/0"))
+
+	assert.Error(t, ValidateEVMPath("m/44'"))
+	assert.Error(t, ValidateEVMPath("m/44'/9000'/0'/0/0"))
+	assert.Error(t, ValidateEVMPath("m/44/60'/0'/0/0"))
+
+	_, err = EVMPath(0, HARDENED, 0)
+	assert.Error(t, err)
+}
+
 func Test_SerializePathSuffix(t *testing.T) {
 	suffixList := []string{"0/0", "4/8", "5/8"}
 	serSuffix0 := []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
@@ -91,3 +130,170 @@ func Test_RemoveDuplicates(t *testing.T) {
 func Test_ConcatMessageAndChangePath(t *testing.T) {
 
 }
+
+func Test_FormatPublicKey(t *testing.T) {
+	compressed, _ := hex.DecodeString("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2")
+	uncompressed, _ := hex.DecodeString("0439a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c23cbe7ded0e7ce6a594896b8f62888fdbc5c8821305e2ea42bf01e37300116281")
+
+	key, err := FormatPublicKey(compressed, Uncompressed)
+	require.NoError(t, err)
+	assert.Equal(t, uncompressed, key)
+
+	key, err = FormatPublicKey(uncompressed, Compressed)
+	require.NoError(t, err)
+	assert.Equal(t, compressed, key)
+
+	key, err = FormatPublicKey(compressed, Compressed)
+	require.NoError(t, err)
+	assert.Equal(t, compressed, key)
+}
+
+func Test_FormatPublicKeyNotOnCurve(t *testing.T) {
+	invalid, _ := hex.DecodeString("0439a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c23cbe7ded0e7ce6a594896b8f62888fdbc5c8821305e2ea42bf01e37300116282")
+
+	_, err := FormatPublicKey(invalid, Compressed)
+	assert.Error(t, err)
+}
+
+// prefixSerializer tags every serialized path so tests can tell it was used
+type prefixSerializer struct {
+	DefaultPathSerializer
+}
+
+func (s prefixSerializer) SerializePath(path string) ([]byte, error) {
+	serialized, err := SerializePath(path)
+	return append([]byte{0xEE}, serialized...), err
+}
+
+func Test_WithPathSerializer(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device, WithPathSerializer(prefixSerializer{}), AllowBlindSigning(true))
+
+	_, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	require.NoError(t, err)
+
+	expected, _ := SerializePath("m/44'/9000'/0'")
+	assert.Equal(t, append([]byte{0xEE}, expected...), device.sent[0][5:5+len(expected)+1])
+}
+
+// longHrpSerializer produces an HRP that cannot fit in an APDU along with the path and chain ID
+type longHrpSerializer struct {
+	DefaultPathSerializer
+}
+
+func (longHrpSerializer) SerializeHrp(string) ([]byte, error) {
+	return append([]byte{240}, make([]byte, 240)...), nil
+}
+
+func Test_GetPubKeyAPDUTooLong(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device, WithPathSerializer(longHrpSerializer{}))
+
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+	assert.ErrorIs(t, err, ErrAPDUTooLong)
+	assert.Empty(t, device.sent)
+}
+
+func Test_BuildAPDU(t *testing.T) {
+	message, err := buildAPDU(CLA, INS_GET_ADDR, 1, 0, []byte{1, 2}, []byte{3})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{CLA, INS_GET_ADDR, 1, 0, 3, 1, 2, 3}, message)
+
+	_, err = buildAPDU(CLA, INS_GET_ADDR, 0, 0, make([]byte, 200), make([]byte, 56))
+	assert.ErrorIs(t, err, ErrAPDUTooLong)
+}
+
+// highS turns a low-S signature into its malleated high-S counterpart
+func highS(signature []byte) []byte {
+	s := new(big.Int).SetBytes(signature[32:64])
+	s.Sub(btcec.S256().N, s)
+
+	malleated := append([]byte{}, signature...)
+	s.FillBytes(malleated[32:64])
+	malleated[64] ^= 1
+	return malleated
+}
+
+func Test_NormalizeLowS(t *testing.T) {
+	publicKey, _ := hex.DecodeString("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2")
+	signature, _ := hex.DecodeString("d6844ac6fc813791fcc9030566ae54b1b8f7a70d67fb3252d7a6096c8fe282153625aff848e6e787334f4bc7ee347a7be78857cec74d81ff61f594b261c731ff01")
+	hash := AvalancheMessageHash([]byte("Hello Avalanche!"))
+
+	malleated := highS(signature)
+	require.False(t, VerifySignature(publicKey, hash, malleated[:64]))
+
+	normalized := NormalizeLowS(malleated)
+	assert.Equal(t, signature, normalized)
+	assert.True(t, VerifySignature(publicKey, hash, normalized[:64]))
+
+	assert.Equal(t, signature, NormalizeLowS(signature))
+}
+
+func Test_ComputeSignHash(t *testing.T) {
+	// BIP-32 test vector 1 master key
+	privateKeyBytes, _ := hex.DecodeString("e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35")
+	privateKey, publicKey := btcec.PrivKeyFromBytes(privateKeyBytes)
+	tx, _ := hex.DecodeString("0000000000000000000100000000000000000000000000000000000000000000000000000000000000000000")
+
+	hash, err := ComputeSignHash(tx)
+	require.NoError(t, err)
+	assert.Equal(t, "5c063bd48c53bef37222f63e41f39a98986db4c6d5585baddd7517d811ad397b", hex.EncodeToString(hash))
+
+	// the device answers with r || s || v
+	compact, err := ecdsa.SignCompact(privateKey, hash, true)
+	require.NoError(t, err)
+	deviceSignature := append(append([]byte{}, compact[1:]...), compact[0]-27-4)
+
+	ledger := newMockLedger(&mockDevice{handler: replies([]byte{}, []byte{}, deviceSignature)})
+	response, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, tx, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, hash, response.Hash)
+	assert.True(t, VerifySignature(publicKey.SerializeCompressed(), hash, response.Signature["0/0"][:64]))
+
+	_, err = ComputeSignHash(nil)
+	assert.Error(t, err)
+}
+
+func Test_CheckVersion(t *testing.T) {
+	assert.NoError(t, CheckVersion(VersionInfo{0, 0, 6, 5}, VersionInfo{0, 0, 6, 5}))
+	assert.NoError(t, CheckVersion(VersionInfo{0, 1, 0, 0}, VersionInfo{0, 0, 6, 5}))
+
+	err := CheckVersion(VersionInfo{0, 0, 6, 4}, VersionInfo{0, 0, 6, 5})
+	var tooLow *ErrVersionTooLow
+	require.ErrorAs(t, err, &tooLow)
+	assert.Equal(t, VersionInfo{0, 0, 6, 4}, tooLow.Have)
+	assert.Equal(t, VersionInfo{0, 0, 6, 5}, tooLow.Want)
+	assert.Equal(t, "App Version required 0.6.5 - Version found: 0.6.4", err.Error())
+
+	var required *VersionRequiredError
+	require.ErrorAs(t, err, &required)
+	assert.Equal(t, VersionInfo{0, 0, 6, 5}, required.Required)
+}
+
+func Test_ParsePath(t *testing.T) {
+	components, err := ParsePath("m/44'/9000'/0'/0/3")
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{HARDENED + 44, HARDENED + 9000, HARDENED, 0, 3}, components)
+	assert.Equal(t, "m/44'/9000'/0'/0/3", FormatPath(components))
+
+	components, err = ParsePath("m/44h/9000H/1'/2'/0/1/2")
+	require.NoError(t, err)
+	assert.Equal(t, "m/44'/9000'/1'/2'/0/1/2", FormatPath(components))
+
+	serialized, err := SerializePath("m/0")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 0, 0, 0, 0}, serialized)
+
+	_, err = ParsePath("m/44'/x/0'")
+	assert.ErrorIs(t, err, ErrInvalidPathComponent)
+	_, err = ParsePath("m/44'/2147483648")
+	assert.ErrorIs(t, err, ErrInvalidPathComponent)
+
+	_, err = ParsePath("m")
+	assert.Error(t, err)
+	_, err = ParsePath("m/0/0/0/0/0/0/0/0/0/0/0")
+	assert.Error(t, err)
+	_, err = ParsePath("44'/9000'")
+	assert.Error(t, err)
+}
@@ -0,0 +1,157 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+)
+
+// Coordinator routes signing requests to the devices holding the keys, identified by their
+// wallet ID, e.g. the Ledgers of the cosigners of a multisig address attached to one host
+type Coordinator struct {
+	ledgers   []*LedgerAvalanche
+	walletIDs []string
+	byID      map[string]*LedgerAvalanche
+}
+
+// CosignerPaths are the full signing and change paths (e.g "m/44'/9000'/0'/0/3") of a transaction
+// held by the device with WalletID
+type CosignerPaths struct {
+	WalletID     []byte
+	SigningPaths []string
+	ChangePaths  []string
+}
+
+// NewCoordinator reads the wallet ID of each ledger. Two ledgers holding the same seed are refused.
+func NewCoordinator(ledgers ...*LedgerAvalanche) (*Coordinator, error) {
+	c := &Coordinator{byID: make(map[string]*LedgerAvalanche)}
+	for idx, ledger := range ledgers {
+		walletID, err := ledger.GetWalletID()
+		if err != nil {
+			return nil, fmt.Errorf("device %d: %w", idx, err)
+		}
+		id := hex.EncodeToString(walletID)
+		if _, ok := c.byID[id]; ok {
+			return nil, fmt.Errorf("device %d: wallet ID %s already attached", idx, id)
+		}
+		c.ledgers = append(c.ledgers, ledger)
+		c.walletIDs = append(c.walletIDs, id)
+		c.byID[id] = ledger
+	}
+	return c, nil
+}
+
+// ConnectAllDevices connects to the Avalanche app on every device listed by ListLedgerDevices,
+// or ListUSBDevices when opts include WithUSBFilters
+func ConnectAllDevices(opts ...Option) (*Coordinator, error) {
+	var ledgers []*LedgerAvalanche
+	for idx := range listDevicesFor(opts) {
+		ledger, err := FindLedgerAvalancheAppOnDevice(idx, opts...)
+		if err != nil {
+			closeAll(ledgers)
+			return nil, fmt.Errorf("device %d: %w", idx, err)
+		}
+		ledgers = append(ledgers, ledger)
+	}
+
+	c, err := NewCoordinator(ledgers...)
+	if err != nil {
+		closeAll(ledgers)
+		return nil, err
+	}
+	return c, nil
+}
+
+func closeAll(ledgers []*LedgerAvalanche) {
+	for _, ledger := range ledgers {
+		_ = ledger.Close()
+	}
+}
+
+// WalletIDs returns the wallet IDs of the devices, in the order they were attached
+func (c *Coordinator) WalletIDs() [][]byte {
+	walletIDs := make([][]byte, len(c.walletIDs))
+	for idx, id := range c.walletIDs {
+		walletIDs[idx], _ = hex.DecodeString(id)
+	}
+	return walletIDs
+}
+
+// Ledger returns the device holding the seed identified by walletID
+func (c *Coordinator) Ledger(walletID []byte) (*LedgerAvalanche, bool) {
+	ledger, ok := c.byID[hex.EncodeToString(walletID)]
+	return ledger, ok
+}
+
+// Sign has every cosigner sign the transaction with its device, in parallel, see SignMultiAccount.
+// The signatures are keyed by "<hex wallet ID>:<full path>", as cosigners may use the same paths,
+// and ordered by cosigner, then by signing path. Signing fails if any device fails.
+func (c *Coordinator) Sign(message []byte, cosigners []CosignerPaths) (*ResponseSign, error) {
+	ledgers := make([]*LedgerAvalanche, len(cosigners))
+	for idx, cosigner := range cosigners {
+		ledger, ok := c.Ledger(cosigner.WalletID)
+		if !ok {
+			return nil, fmt.Errorf("no device with wallet ID %x", cosigner.WalletID)
+		}
+		for _, other := range ledgers[:idx] {
+			if other == ledger {
+				return nil, fmt.Errorf("wallet ID %x is listed more than once", cosigner.WalletID)
+			}
+		}
+		ledgers[idx] = ledger
+	}
+
+	responses := make([]*ResponseSign, len(cosigners))
+	errs := make([]error, len(cosigners))
+	var wg sync.WaitGroup
+	for idx := range cosigners {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			cosigner := cosigners[idx]
+			responses[idx], errs[idx] = ledgers[idx].SignMultiAccount(cosigner.SigningPaths, message, cosigner.ChangePaths)
+		}(idx)
+	}
+	wg.Wait()
+
+	result := &ResponseSign{Signature: make(map[string][]byte)}
+	for idx, response := range responses {
+		if errs[idx] != nil {
+			return nil, fmt.Errorf("device %x: %w", cosigners[idx].WalletID, errs[idx])
+		}
+		for _, signature := range response.SignaturesOrdered {
+			signature.Path = fmt.Sprintf("%x:%s", cosigners[idx].WalletID, signature.Path)
+			result.Signature[signature.Path] = signature.Signature
+			result.SignaturesOrdered = append(result.SignaturesOrdered, signature)
+		}
+		result.Hash = response.Hash
+	}
+	return result, nil
+}
+
+// Close closes the connections with all devices
+func (c *Coordinator) Close() error {
+	var err error
+	for _, ledger := range c.ledgers {
+		if closeErr := ledger.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
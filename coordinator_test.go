@@ -0,0 +1,77 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cosignerDevice holds the seed identified by walletID and signs with signatures filled with mark
+func cosignerDevice(walletID []byte, mark byte) *mockDevice {
+	return &mockDevice{handler: func(command []byte) ([]byte, error) {
+		switch command[1] {
+		case INS_WALLET_ID:
+			return walletID, nil
+		case INS_SIGN_HASH:
+			return bytes.Repeat([]byte{mark}, SIGNATURE_LEN), nil
+		}
+		return []byte{}, nil
+	}}
+}
+
+func Test_CoordinatorSign(t *testing.T) {
+	alice, bob := []byte{1, 1, 1, 1, 1, 1}, []byte{2, 2, 2, 2, 2, 2}
+	coordinator, err := NewCoordinator(
+		newMockLedger(cosignerDevice(alice, 0xaa)),
+		newMockLedger(cosignerDevice(bob, 0xbb)),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{alice, bob}, coordinator.WalletIDs())
+
+	response, err := coordinator.Sign([]byte{0x01}, []CosignerPaths{
+		{WalletID: bob, SigningPaths: []string{"m/44'/9000'/0'/0/0"}},
+		{WalletID: alice, SigningPaths: []string{"m/44'/9000'/0'/0/0", "m/44'/9000'/1'/0/2"}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, response.SignaturesOrdered, 3)
+	assert.Equal(t, "020202020202:m/44'/9000'/0'/0/0", response.SignaturesOrdered[0].Path)
+	assert.Equal(t, "010101010101:m/44'/9000'/1'/0/2", response.SignaturesOrdered[2].Path)
+	assert.Equal(t, byte(0xbb), response.Signature["020202020202:m/44'/9000'/0'/0/0"][0])
+	assert.Equal(t, byte(0xaa), response.Signature["010101010101:m/44'/9000'/0'/0/0"][0])
+}
+
+func Test_CoordinatorRouting(t *testing.T) {
+	walletID := []byte{1, 1, 1, 1, 1, 1}
+
+	_, err := NewCoordinator(newMockLedger(cosignerDevice(walletID, 0)), newMockLedger(cosignerDevice(walletID, 0)))
+	assert.Error(t, err, "two devices with the same seed")
+
+	coordinator, err := NewCoordinator(newMockLedger(cosignerDevice(walletID, 0)))
+	require.NoError(t, err)
+
+	_, err = coordinator.Sign([]byte{0x01}, []CosignerPaths{{WalletID: []byte{9}, SigningPaths: []string{"m/44'/9000'/0'/0/0"}}})
+	assert.ErrorContains(t, err, "no device with wallet ID 09")
+
+	paths := CosignerPaths{WalletID: walletID, SigningPaths: []string{"m/44'/9000'/0'/0/0"}}
+	_, err = coordinator.Sign([]byte{0x01}, []CosignerPaths{paths, paths})
+	assert.Error(t, err)
+}
@@ -0,0 +1,76 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/zondax/ledger-avalanche-go/decode"
+)
+
+// ErrChainMismatch is returned by SignCrossChain when the chains of the transaction are not the expected ones
+var ErrChainMismatch = errors.New("transaction chains do not match the expected ones")
+
+// CrossChainIDs returns the source and destination chain IDs of an unsigned import or export
+// transaction issued on chain, whose codec defines its type IDs
+func CrossChainIDs(unsignedTx []byte, chain decode.Chain) (source, destination []byte, err error) {
+	summary, err := decode.Decode(unsignedTx, chain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch summary.Type {
+	case decode.ImportTx:
+		return summary.SourceChainID[:], summary.BlockchainID[:], nil
+	case decode.ExportTx:
+		return summary.BlockchainID[:], summary.DestinationChainID[:], nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s is not an import or export", ErrUnsupportedTransaction, summary.Type)
+}
+
+// SignCrossChain signs an import or export transaction issued on chain as Sign does, after
+// checking that it moves funds from sourceChainID to destinationChainID (base58 encoded, e.g as returned by
+// the info API). The app reads the chains from the transaction itself, as the sign
+// instruction takes no chain parameter, so this guards against signing a transaction
+// built for other chains than the caller intended.
+func (ledger *LedgerAvalanche) SignCrossChain(pathPrefix string, signingPaths []string, message []byte, changePaths []string, chain decode.Chain, sourceChainID, destinationChainID string) (*ResponseSign, error) {
+	source, destination, err := CrossChainIDs(message, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chain := range []struct {
+		name     string
+		expected string
+		found    []byte
+	}{
+		{"source", sourceChainID, source},
+		{"destination", destinationChainID, destination},
+	} {
+		expected, err := SerializeChainID(chain.expected)
+		if err != nil {
+			return nil, fmt.Errorf("%s chain: %w", chain.name, err)
+		}
+		if !bytes.Equal(expected[1:], chain.found) {
+			return nil, fmt.Errorf("%w: %s chain is %x", ErrChainMismatch, chain.name, chain.found)
+		}
+	}
+
+	return ledger.Sign(pathPrefix, signingPaths, message, changePaths)
+}
@@ -0,0 +1,118 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zondax/ledger-avalanche-go/decode"
+)
+
+const (
+	pChainID = "11111111111111111111111111111111LpoYY"
+	xChainID = "2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByM"
+	cChainID = "2q9e4r6Mu3U68nU1fYjgbR6JvwrRx36CohpAX5UQxse55x1Q5"
+)
+
+// Type IDs of the P- and C-chain codecs
+const (
+	pChainExportTxID = 18
+	cChainImportTxID = 0
+)
+
+func chainIDBytes(chainID string) []byte {
+	serialized, _ := SerializeChainID(chainID)
+	return serialized[1:]
+}
+
+// pChainExportTx serializes an unsigned P-chain ExportTx spending a stakeable locked input
+func pChainExportTx(destinationChainID string) []byte {
+	tx := binary.BigEndian.AppendUint16(nil, 0)
+	tx = binary.BigEndian.AppendUint32(tx, pChainExportTxID)
+	tx = binary.BigEndian.AppendUint32(tx, 1)
+	tx = append(tx, chainIDBytes(pChainID)...)
+	// no outputs
+	tx = binary.BigEndian.AppendUint32(tx, 0)
+	// one input
+	tx = binary.BigEndian.AppendUint32(tx, 1)
+	tx = append(tx, make([]byte, 32+4+32)...)
+	tx = binary.BigEndian.AppendUint32(tx, stakeableLockInputID)
+	tx = binary.BigEndian.AppendUint64(tx, 0)
+	tx = binary.BigEndian.AppendUint32(tx, secp256k1TransferInputID)
+	tx = binary.BigEndian.AppendUint64(tx, 1000)
+	tx = binary.BigEndian.AppendUint32(tx, 1)
+	tx = binary.BigEndian.AppendUint32(tx, 0)
+	// memo
+	tx = binary.BigEndian.AppendUint32(tx, 2)
+	tx = append(tx, "hi"...)
+	tx = append(tx, chainIDBytes(destinationChainID)...)
+	// no exported outputs
+	return binary.BigEndian.AppendUint32(tx, 0)
+}
+
+func Test_CrossChainIDs(t *testing.T) {
+	source, destination, err := CrossChainIDs(pChainExportTx(xChainID), decode.PChain)
+	require.NoError(t, err)
+	assert.Equal(t, chainIDBytes(pChainID), source)
+	assert.Equal(t, chainIDBytes(xChainID), destination)
+
+	// C-chain import from the P-chain
+	tx := binary.BigEndian.AppendUint16(nil, 0)
+	tx = binary.BigEndian.AppendUint32(tx, cChainImportTxID)
+	tx = binary.BigEndian.AppendUint32(tx, 1)
+	tx = append(tx, chainIDBytes(cChainID)...)
+	tx = append(tx, chainIDBytes(pChainID)...)
+	// no imported inputs nor outputs
+	tx = binary.BigEndian.AppendUint32(tx, 0)
+	tx = binary.BigEndian.AppendUint32(tx, 0)
+	source, destination, err = CrossChainIDs(tx, decode.CChain)
+	require.NoError(t, err)
+	assert.Equal(t, chainIDBytes(pChainID), source)
+	assert.Equal(t, chainIDBytes(cChainID), destination)
+
+	// the same type ID is an X-chain BaseTx
+	_, _, err = CrossChainIDs(tx, decode.XChain)
+	assert.Error(t, err)
+
+	_, _, err = CrossChainIDs(baseTx(), decode.PChain)
+	assert.ErrorIs(t, err, ErrUnsupportedTransaction)
+	_, _, err = CrossChainIDs(pChainExportTx(xChainID)[:80], decode.PChain)
+	assert.ErrorIs(t, err, ErrMalformedTransaction)
+}
+
+func Test_SignCrossChain(t *testing.T) {
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[1] == INS_SIGN_HASH {
+			return make([]byte, SIGNATURE_LEN), nil
+		}
+		return []byte{}, nil
+	}}
+	ledger := newMockLedger(device)
+	tx := pChainExportTx(cChainID)
+
+	_, err := ledger.SignCrossChain("m/44'/9000'/0'", []string{"0/0"}, tx, nil, decode.PChain, pChainID, xChainID)
+	assert.ErrorIs(t, err, ErrChainMismatch)
+	assert.Empty(t, device.sent)
+
+	response, err := ledger.SignCrossChain("m/44'/9000'/0'", []string{"0/0"}, tx, nil, decode.PChain, pChainID, cChainID)
+	require.NoError(t, err)
+	assert.Len(t, response.SignaturesOrdered, 1)
+}
@@ -0,0 +1,68 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AvalancheAppName is the name of the Avalanche app as listed in the dashboard
+const AvalancheAppName = "Avalanche"
+
+// appPollInterval is the delay between two attempts of WaitForApp
+var appPollInterval = 250 * time.Millisecond
+
+// OpenApp asks the dashboard to launch the app called name, e.g. AvalancheAppName. The device may
+// ask the user to confirm, and fails with ErrAppNotInstalled when no such app is installed.
+// Over USB the device reconnects once the app starts, so use WaitForApp to connect to it.
+func (ledger *LedgerAvalanche) OpenApp(name string) error {
+	message, err := buildAPDU(CLA_DASHBOARD, INS_OPEN_APP, 0, 0, []byte(name))
+	if err != nil {
+		return err
+	}
+	_, err = ledger.exchangeContext(context.Background(), message, ledger.confirmationTimeout, ErrConfirmationTimeout)
+	return err
+}
+
+// QuitApp exits the running app, returning to the dashboard
+func (ledger *LedgerAvalanche) QuitApp() error {
+	_, err := ledger.exchange([]byte{CLA_BOLOS, INS_QUIT_APP, 0, 0, 0})
+	return err
+}
+
+// WaitForApp connects to the Avalanche app as FindLedgerAvalancheApp does, retrying until the
+// app is open or ctx is done, e.g. after OpenApp. A device given with WithDevice is closed by
+// each failed attempt, so WaitForApp is meant for devices it connects to.
+func WaitForApp(ctx context.Context, opts ...Option) (*LedgerAvalanche, error) {
+	ticker := time.NewTicker(appPollInterval)
+	defer ticker.Stop()
+
+	for {
+		ledger, err := FindLedgerAvalancheApp(opts...)
+		if err == nil {
+			return ledger, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w (%v)", ctx.Err(), err)
+		case <-ticker.C:
+		}
+	}
+}
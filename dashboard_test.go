@@ -0,0 +1,84 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zondax/ledger-go"
+)
+
+func Test_OpenApp(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	require.NoError(t, ledger.OpenApp(AvalancheAppName))
+	require.NoError(t, ledger.QuitApp())
+	assert.Equal(t, [][]byte{
+		append([]byte{CLA_DASHBOARD, INS_OPEN_APP, 0, 0, 9}, "Avalanche"...),
+		{CLA_BOLOS, INS_QUIT_APP, 0, 0, 0},
+	}, device.sent)
+}
+
+func Test_OpenAppNotInstalled(t *testing.T) {
+	device := &mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, statusError(AppNotInstalled)
+	}}
+	ledger := newMockLedger(device)
+
+	err := ledger.OpenApp("Bitcoin")
+	assert.ErrorIs(t, err, ErrAppNotInstalled)
+}
+
+func Test_WaitForApp(t *testing.T) {
+	appPollInterval = time.Millisecond
+	t.Cleanup(func() { appPollInterval = 250 * time.Millisecond })
+
+	attempts := 0
+	withConnectDevice(t, func(int) (ledger_go.LedgerDevice, error) {
+		attempts++
+		if attempts < 3 {
+			return &mockDevice{handler: func([]byte) ([]byte, error) {
+				return nil, statusError(ClaNotSupported)
+			}}, nil
+		}
+		return &mockDevice{handler: replies([]byte{0, 0, 6, 5}, []byte{0, 0, 6, 5})}, nil
+	})
+
+	ledger, err := WaitForApp(context.Background())
+	require.NoError(t, err)
+	assert.NotNil(t, ledger)
+	assert.Equal(t, 3, attempts)
+}
+
+func Test_WaitForAppCanceled(t *testing.T) {
+	withConnectDevice(t, func(int) (ledger_go.LedgerDevice, error) {
+		return nil, errors.New("LedgerHID device (idx 0) not found")
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := WaitForApp(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "not found")
+}
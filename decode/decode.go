@@ -0,0 +1,448 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package decode parses unsigned Avalanche transactions into a Summary of what they do, to show
+// users what the device will display before they are asked to sign.
+package decode
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// IDLength is the length of transaction, asset, blockchain and subnet IDs
+	IDLength = 32
+	// ShortIDLength is the length of addresses, node IDs and C-chain accounts
+	ShortIDLength = 20
+)
+
+// ErrUnsupportedTransaction is returned for transaction types Decode does not know
+var ErrUnsupportedTransaction = errors.New("unsupported transaction")
+
+// Chain is the chain a transaction is issued on, which the type IDs depend on
+type Chain uint8
+
+// The chains of the primary network
+const (
+	PChain Chain = iota
+	XChain
+	CChain
+)
+
+func (c Chain) String() string {
+	switch c {
+	case PChain:
+		return "P"
+	case XChain:
+		return "X"
+	case CChain:
+		return "C"
+	}
+	return fmt.Sprintf("Chain(%d)", uint8(c))
+}
+
+// TxType is the kind of a transaction
+type TxType string
+
+// The transactions Decode supports
+const (
+	BaseTx                       TxType = "BaseTx"
+	ImportTx                     TxType = "ImportTx"
+	ExportTx                     TxType = "ExportTx"
+	CreateSubnetTx               TxType = "CreateSubnetTx"
+	AddValidatorTx               TxType = "AddValidatorTx"
+	AddDelegatorTx               TxType = "AddDelegatorTx"
+	AddPermissionlessValidatorTx TxType = "AddPermissionlessValidatorTx"
+	AddPermissionlessDelegatorTx TxType = "AddPermissionlessDelegatorTx"
+)
+
+// txTypes maps the type IDs of each chain codec to the transactions Decode supports
+var txTypes = map[Chain]map[uint32]TxType{
+	PChain: {
+		12: AddValidatorTx,
+		14: AddDelegatorTx,
+		16: CreateSubnetTx,
+		17: ImportTx,
+		18: ExportTx,
+		25: AddPermissionlessValidatorTx,
+		26: AddPermissionlessDelegatorTx,
+		34: BaseTx,
+	},
+	XChain: {
+		0: BaseTx,
+		3: ImportTx,
+		4: ExportTx,
+	},
+	CChain: {
+		0: ImportTx,
+		1: ExportTx,
+	},
+}
+
+// Type IDs of outputs, inputs, owners and signers, shared by the codecs
+const (
+	secp256k1TransferInputID  = 5
+	secp256k1TransferOutputID = 7
+	secp256k1OutputOwnersID   = 11
+	stakeableLockInputID      = 21
+	stakeableLockOutputID     = 22
+	emptySignerID             = 27
+	proofOfPossessionSignerID = 28
+	blsPublicKeyLength        = 48
+	blsSignatureLength        = 96
+)
+
+// Owner is who may spend an output: Threshold of Addresses, once Locktime is past
+type Owner struct {
+	Locktime  uint64
+	Threshold uint32
+	Addresses [][ShortIDLength]byte
+}
+
+// OutputKind tells what an output is for
+type OutputKind uint8
+
+const (
+	// OutputTransfer is an output of the transaction, e.g. a payment or the change
+	OutputTransfer OutputKind = iota
+	// OutputStake is staked, and returned once the staking period is over
+	OutputStake
+	// OutputExported is exported to DestinationChainID
+	OutputExported
+	// OutputEVM credits the C-chain account EVMAddress
+	OutputEVM
+)
+
+// Output is an amount of an asset created by the transaction
+type Output struct {
+	Kind    OutputKind
+	AssetID [IDLength]byte
+	Amount  uint64
+	// Owner is the owner of UTXOs, EVMAddress the credited C-chain account
+	Owner      Owner
+	EVMAddress [ShortIDLength]byte
+	// StakeableLocktime is the time until which the output may only be staked, if locked
+	StakeableLocktime uint64
+}
+
+// InputKind tells where an input comes from
+type InputKind uint8
+
+const (
+	// InputTransfer spends a UTXO of the chain
+	InputTransfer InputKind = iota
+	// InputImported spends a UTXO exported from SourceChainID
+	InputImported
+	// InputEVM debits the C-chain account EVMAddress
+	InputEVM
+)
+
+// Input is an amount of an asset consumed by the transaction
+type Input struct {
+	Kind    InputKind
+	AssetID [IDLength]byte
+	Amount  uint64
+	// TxID and OutputIndex identify the UTXO spent
+	TxID              [IDLength]byte
+	OutputIndex       uint32
+	StakeableLocktime uint64
+	// EVMAddress and Nonce identify the debited C-chain account
+	EVMAddress [ShortIDLength]byte
+	Nonce      uint64
+}
+
+// Validator is the validator added, or delegated to, by a staking transaction
+type Validator struct {
+	NodeID   [ShortIDLength]byte
+	Start    time.Time
+	End      time.Time
+	Weight   uint64
+	SubnetID [IDLength]byte
+	// BLSPublicKey is registered by permissionless validators of the primary network
+	BLSPublicKey []byte
+}
+
+// Summary is what a transaction does
+type Summary struct {
+	Chain        Chain
+	Type         TxType
+	NetworkID    uint32
+	BlockchainID [IDLength]byte
+	Inputs       []Input
+	Outputs      []Output
+	Memo         []byte
+
+	// SourceChainID is the chain of the imported inputs, DestinationChainID the chain of the
+	// exported outputs
+	SourceChainID      [IDLength]byte
+	DestinationChainID [IDLength]byte
+
+	Validator *Validator
+	// RewardsOwner receives the validation or delegation rewards, DelegationRewardsOwner the
+	// share of delegators rewards of a validator
+	RewardsOwner           *Owner
+	DelegationRewardsOwner *Owner
+	// DelegationShares is the share of the rewards of delegators a validator takes, in millionths
+	DelegationShares uint32
+	// SubnetOwner controls the subnet created
+	SubnetOwner *Owner
+
+	// Fees are the amounts of each asset consumed but not produced, i.e. burnt
+	Fees map[[IDLength]byte]uint64
+}
+
+// Decode parses the unsigned transaction issued on chain, as serialized for signing
+func Decode(unsignedTx []byte, chain Chain) (summary *Summary, rerr error) {
+	defer recoverMalformed(&rerr)
+
+	r := newReader(unsignedTx)
+	if codecVersion := r.uint16(); codecVersion != 0 {
+		return nil, fmt.Errorf("%w: codec version %d", ErrUnsupportedTransaction, codecVersion)
+	}
+	typeID := r.uint32()
+	txType, ok := txTypes[chain][typeID]
+	if !ok {
+		return nil, fmt.Errorf("%w: type %d on the %s-chain", ErrUnsupportedTransaction, typeID, chain)
+	}
+
+	s := &Summary{Chain: chain, Type: txType}
+	var err error
+	if chain == CChain {
+		err = s.readAtomicEVM(r)
+	} else {
+		err = s.read(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if r.offset != len(r.data) {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrMalformedTransaction, len(r.data)-r.offset)
+	}
+
+	if s.Fees, err = s.fees(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// read reads the fields of a P- or X-chain transaction, following its base transaction
+func (s *Summary) read(r *reader) error {
+	// [network ID | blockchain ID | outputs | inputs | memo]
+	s.NetworkID = r.uint32()
+	s.BlockchainID = r.id()
+	if err := s.readOutputs(r, OutputTransfer); err != nil {
+		return err
+	}
+	if err := s.readInputs(r, InputTransfer); err != nil {
+		return err
+	}
+	s.Memo = r.bytes(int(r.uint32()))
+
+	var err error
+	switch s.Type {
+	case ImportTx:
+		s.SourceChainID = r.id()
+		err = s.readInputs(r, InputImported)
+
+	case ExportTx:
+		s.DestinationChainID = r.id()
+		err = s.readOutputs(r, OutputExported)
+
+	case CreateSubnetTx:
+		s.SubnetOwner, err = readOwner(r)
+
+	case AddValidatorTx, AddDelegatorTx:
+		// [validator | stake | rewards owner (| shares)]
+		s.Validator = readValidator(r)
+		if err = s.readOutputs(r, OutputStake); err != nil {
+			return err
+		}
+		if s.RewardsOwner, err = readOwner(r); err != nil {
+			return err
+		}
+		if s.Type == AddValidatorTx {
+			s.DelegationShares = r.uint32()
+		}
+
+	case AddPermissionlessValidatorTx, AddPermissionlessDelegatorTx:
+		// [validator | subnet (| signer) | stake | rewards owner (| delegation rewards owner | shares)]
+		s.Validator = readValidator(r)
+		s.Validator.SubnetID = r.id()
+		if s.Type == AddPermissionlessValidatorTx {
+			if err = s.readSigner(r); err != nil {
+				return err
+			}
+		}
+		if err = s.readOutputs(r, OutputStake); err != nil {
+			return err
+		}
+		if s.RewardsOwner, err = readOwner(r); err != nil {
+			return err
+		}
+		if s.Type == AddPermissionlessValidatorTx {
+			if s.DelegationRewardsOwner, err = readOwner(r); err != nil {
+				return err
+			}
+			s.DelegationShares = r.uint32()
+		}
+	}
+	return err
+}
+
+// readAtomicEVM reads a C-chain import or export transaction
+func (s *Summary) readAtomicEVM(r *reader) error {
+	s.NetworkID = r.uint32()
+	s.BlockchainID = r.id()
+
+	if s.Type == ImportTx {
+		// [source chain | imported inputs | outputs], the outputs being [address | amount | asset ID]
+		s.SourceChainID = r.id()
+		if err := s.readInputs(r, InputImported); err != nil {
+			return err
+		}
+		for i, count := 0, int(r.uint32()); i < count; i++ {
+			output := Output{Kind: OutputEVM, EVMAddress: r.shortID(), Amount: r.uint64()}
+			output.AssetID = r.id()
+			s.Outputs = append(s.Outputs, output)
+		}
+		return nil
+	}
+
+	// [destination chain | inputs | exported outputs], the inputs being
+	// [address | amount | asset ID | nonce]
+	s.DestinationChainID = r.id()
+	for i, count := 0, int(r.uint32()); i < count; i++ {
+		input := Input{Kind: InputEVM, EVMAddress: r.shortID(), Amount: r.uint64()}
+		input.AssetID = r.id()
+		input.Nonce = r.uint64()
+		s.Inputs = append(s.Inputs, input)
+	}
+	return s.readOutputs(r, OutputExported)
+}
+
+// readOutputs reads transferable outputs: [asset ID | type ID | output]
+func (s *Summary) readOutputs(r *reader, kind OutputKind) error {
+	for i, count := 0, int(r.uint32()); i < count; i++ {
+		output := Output{Kind: kind, AssetID: r.id()}
+		typeID := r.uint32()
+		if typeID == stakeableLockOutputID {
+			// [locktime | type ID | output]
+			output.StakeableLocktime = r.uint64()
+			typeID = r.uint32()
+		}
+		if typeID != secp256k1TransferOutputID {
+			return fmt.Errorf("%w: output type %d", ErrUnsupportedTransaction, typeID)
+		}
+
+		// [amount | owner]
+		output.Amount = r.uint64()
+		output.Owner = readOwnerFields(r)
+		s.Outputs = append(s.Outputs, output)
+	}
+	return nil
+}
+
+// readInputs reads transferable inputs: [tx ID | output index | asset ID | type ID | input]
+func (s *Summary) readInputs(r *reader, kind InputKind) error {
+	for i, count := 0, int(r.uint32()); i < count; i++ {
+		input := Input{Kind: kind, TxID: r.id(), OutputIndex: r.uint32()}
+		input.AssetID = r.id()
+		typeID := r.uint32()
+		if typeID == stakeableLockInputID {
+			// [locktime | type ID | input]
+			input.StakeableLocktime = r.uint64()
+			typeID = r.uint32()
+		}
+		if typeID != secp256k1TransferInputID {
+			return fmt.Errorf("%w: input type %d", ErrUnsupportedTransaction, typeID)
+		}
+
+		// [amount | signature indices]
+		input.Amount = r.uint64()
+		r.bytes(4 * int(r.uint32()))
+		s.Inputs = append(s.Inputs, input)
+	}
+	return nil
+}
+
+// readSigner reads the BLS key registered by a permissionless validator, if any
+func (s *Summary) readSigner(r *reader) error {
+	switch typeID := r.uint32(); typeID {
+	case emptySignerID:
+	case proofOfPossessionSignerID:
+		s.Validator.BLSPublicKey = r.bytes(blsPublicKeyLength)
+		r.bytes(blsSignatureLength)
+	default:
+		return fmt.Errorf("%w: signer type %d", ErrUnsupportedTransaction, typeID)
+	}
+	return nil
+}
+
+// readValidator reads [node ID | start | end | weight]
+func readValidator(r *reader) *Validator {
+	v := &Validator{NodeID: r.shortID()}
+	v.Start = time.Unix(int64(r.uint64()), 0)
+	v.End = time.Unix(int64(r.uint64()), 0)
+	v.Weight = r.uint64()
+	return v
+}
+
+// readOwner reads an owner preceded by its type ID
+func readOwner(r *reader) (*Owner, error) {
+	if typeID := r.uint32(); typeID != secp256k1OutputOwnersID {
+		return nil, fmt.Errorf("%w: owner type %d", ErrUnsupportedTransaction, typeID)
+	}
+	owner := readOwnerFields(r)
+	return &owner, nil
+}
+
+// readOwnerFields reads [locktime | threshold | addresses]
+func readOwnerFields(r *reader) Owner {
+	owner := Owner{Locktime: r.uint64(), Threshold: r.uint32()}
+	for i, count := 0, int(r.uint32()); i < count; i++ {
+		owner.Addresses = append(owner.Addresses, r.shortID())
+	}
+	return owner
+}
+
+// fees returns the amount of each asset consumed by the inputs and not produced by the outputs.
+// Amounts of an asset adding up past a uint64 fail with ErrMalformedTransaction.
+func (s *Summary) fees() (map[[IDLength]byte]uint64, error) {
+	consumed := make(map[[IDLength]byte]uint64)
+	for _, input := range s.Inputs {
+		if consumed[input.AssetID]+input.Amount < input.Amount {
+			return nil, fmt.Errorf("%w: inputs of asset %x overflow", ErrMalformedTransaction, input.AssetID)
+		}
+		consumed[input.AssetID] += input.Amount
+	}
+	produced := make(map[[IDLength]byte]uint64)
+	for _, output := range s.Outputs {
+		if produced[output.AssetID]+output.Amount < output.Amount {
+			return nil, fmt.Errorf("%w: outputs of asset %x overflow", ErrMalformedTransaction, output.AssetID)
+		}
+		produced[output.AssetID] += output.Amount
+	}
+
+	fees := make(map[[IDLength]byte]uint64)
+	for assetID, amount := range consumed {
+		if amount > produced[assetID] {
+			fees[assetID] = amount - produced[assetID]
+		}
+	}
+	return fees, nil
+}
@@ -0,0 +1,185 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package decode_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/avax"
+	"github.com/zondax/ledger-avalanche-go/decode"
+	"github.com/zondax/ledger-avalanche-go/txbuild"
+)
+
+var (
+	testAssetID = [decode.IDLength]byte{0xaa}
+	testStart   = time.Unix(1700000000, 0)
+)
+
+func stakeParams() txbuild.StakeParams {
+	return txbuild.StakeParams{
+		NetworkID:      5,
+		AVAXAssetID:    testAssetID,
+		NodeID:         avax.ShortID{0x0d},
+		Amount:         25,
+		Start:          testStart,
+		End:            testStart.Add(time.Hour),
+		RewardsAddress: avax.ShortID{0x0e},
+		ChangeAddress:  avax.ShortID{0x0c},
+		Fee:            1,
+		UTXOs: []txbuild.UTXO{
+			{TxID: [decode.IDLength]byte{0x01}, OutputIndex: 2, AssetID: testAssetID, Amount: 30, Owner: avax.ShortID{0x0f}},
+		},
+	}
+}
+
+func Test_DecodeAddPermissionlessDelegatorTx(t *testing.T) {
+	tx, err := txbuild.NewAddPermissionlessDelegatorTx(stakeParams())
+	require.NoError(t, err)
+
+	s, err := decode.Decode(tx.Bytes, decode.PChain)
+	require.NoError(t, err)
+
+	assert.Equal(t, decode.AddPermissionlessDelegatorTx, s.Type)
+	assert.Equal(t, uint32(5), s.NetworkID)
+	assert.Equal(t, [decode.IDLength]byte{}, s.BlockchainID)
+	assert.Equal(t, []decode.Input{{Kind: decode.InputTransfer, AssetID: testAssetID, Amount: 30, TxID: [decode.IDLength]byte{0x01}, OutputIndex: 2}}, s.Inputs)
+	assert.Equal(t, []decode.Output{
+		{Kind: decode.OutputTransfer, AssetID: testAssetID, Amount: 4, Owner: decode.Owner{Threshold: 1, Addresses: [][decode.ShortIDLength]byte{{0x0c}}}},
+		{Kind: decode.OutputStake, AssetID: testAssetID, Amount: 25, Owner: decode.Owner{Threshold: 1, Addresses: [][decode.ShortIDLength]byte{{0x0c}}}},
+	}, s.Outputs)
+	assert.Empty(t, s.Memo)
+
+	require.NotNil(t, s.Validator)
+	assert.Equal(t, [decode.ShortIDLength]byte{0x0d}, s.Validator.NodeID)
+	assert.True(t, testStart.Equal(s.Validator.Start))
+	assert.True(t, testStart.Add(time.Hour).Equal(s.Validator.End))
+	assert.Equal(t, uint64(25), s.Validator.Weight)
+	assert.Nil(t, s.Validator.BLSPublicKey)
+	assert.Equal(t, &decode.Owner{Threshold: 1, Addresses: [][decode.ShortIDLength]byte{{0x0e}}}, s.RewardsOwner)
+	assert.Nil(t, s.DelegationRewardsOwner)
+
+	assert.Equal(t, map[[decode.IDLength]byte]uint64{testAssetID: 1}, s.Fees)
+}
+
+func Test_DecodeAddPermissionlessValidatorTx(t *testing.T) {
+	pop := &ledger.BLSProofOfPossession{PublicKey: [ledger.BLSPublicKeyLength]byte{0x01}}
+	tx, err := txbuild.NewAddPermissionlessValidatorTx(stakeParams(), pop, 20000)
+	require.NoError(t, err)
+
+	s, err := decode.Decode(tx.Bytes, decode.PChain)
+	require.NoError(t, err)
+
+	assert.Equal(t, decode.AddPermissionlessValidatorTx, s.Type)
+	assert.Equal(t, pop.PublicKey[:], s.Validator.BLSPublicKey)
+	assert.Equal(t, uint32(20000), s.DelegationShares)
+	assert.Equal(t, s.RewardsOwner, s.DelegationRewardsOwner)
+	assert.Equal(t, map[[decode.IDLength]byte]uint64{testAssetID: 1}, s.Fees)
+}
+
+func Test_DecodeAtomicTxs(t *testing.T) {
+	utxos := []txbuild.UTXO{{TxID: [decode.IDLength]byte{0x01}, AssetID: testAssetID, Amount: 10}}
+
+	tx, err := txbuild.NewExportTx(txbuild.ExportParams{Chain: txbuild.XChain, AVAXAssetID: testAssetID,
+		BlockchainID: [decode.IDLength]byte{0x0b}, DestinationChainID: [decode.IDLength]byte{0x0c}, Amount: 7, Fee: 1, UTXOs: utxos})
+	require.NoError(t, err)
+	s, err := decode.Decode(tx.Bytes, decode.XChain)
+	require.NoError(t, err)
+	assert.Equal(t, decode.ExportTx, s.Type)
+	assert.Equal(t, [decode.IDLength]byte{0x0b}, s.BlockchainID)
+	assert.Equal(t, [decode.IDLength]byte{0x0c}, s.DestinationChainID)
+	require.Len(t, s.Outputs, 2)
+	assert.Equal(t, decode.OutputExported, s.Outputs[1].Kind)
+	assert.Equal(t, uint64(7), s.Outputs[1].Amount)
+	assert.Equal(t, map[[decode.IDLength]byte]uint64{testAssetID: 1}, s.Fees)
+
+	tx, err = txbuild.NewImportTx(txbuild.ImportParams{Chain: txbuild.CChain, AVAXAssetID: testAssetID,
+		SourceChainID: [decode.IDLength]byte{0x0b}, UTXOs: utxos, Fee: 2, ToAccount: [txbuild.EVMAddressLength]byte{0xee}})
+	require.NoError(t, err)
+	s, err = decode.Decode(tx.Bytes, decode.CChain)
+	require.NoError(t, err)
+	assert.Equal(t, decode.ImportTx, s.Type)
+	assert.Equal(t, [decode.IDLength]byte{0x0b}, s.SourceChainID)
+	assert.Equal(t, decode.InputImported, s.Inputs[0].Kind)
+	assert.Equal(t, []decode.Output{{Kind: decode.OutputEVM, AssetID: testAssetID, Amount: 8, EVMAddress: [decode.ShortIDLength]byte{0xee}}}, s.Outputs)
+	assert.Equal(t, map[[decode.IDLength]byte]uint64{testAssetID: 2}, s.Fees)
+
+	tx, err = txbuild.NewExportTx(txbuild.ExportParams{Chain: txbuild.CChain, AVAXAssetID: testAssetID, Amount: 7, Fee: 1,
+		From: txbuild.EVMAccount{Address: [txbuild.EVMAddressLength]byte{0xee}, Nonce: 3}})
+	require.NoError(t, err)
+	s, err = decode.Decode(tx.Bytes, decode.CChain)
+	require.NoError(t, err)
+	assert.Equal(t, decode.ExportTx, s.Type)
+	assert.Equal(t, []decode.Input{{Kind: decode.InputEVM, AssetID: testAssetID, Amount: 8, EVMAddress: [decode.ShortIDLength]byte{0xee}, Nonce: 3}}, s.Inputs)
+	assert.Equal(t, map[[decode.IDLength]byte]uint64{testAssetID: 1}, s.Fees)
+}
+
+func Test_DecodeErrors(t *testing.T) {
+	tx, err := txbuild.NewAddPermissionlessDelegatorTx(stakeParams())
+	require.NoError(t, err)
+
+	_, err = decode.Decode(tx.Bytes[:len(tx.Bytes)-1], decode.PChain)
+	assert.ErrorIs(t, err, decode.ErrMalformedTransaction)
+
+	_, err = decode.Decode(append(tx.Bytes, 0x00), decode.PChain)
+	assert.ErrorIs(t, err, decode.ErrMalformedTransaction)
+
+	_, err = decode.Decode(tx.Bytes, decode.XChain)
+	assert.ErrorIs(t, err, decode.ErrUnsupportedTransaction)
+
+	_, err = decode.Decode([]byte{0x00, 0x01, 0x00, 0x00, 0x00, 0x22}, decode.PChain)
+	assert.ErrorIs(t, err, decode.ErrUnsupportedTransaction)
+
+	_, err = decode.Decode(nil, decode.PChain)
+	assert.ErrorIs(t, err, decode.ErrMalformedTransaction)
+}
+
+// evmExportTx serializes a C-chain ExportTx debiting amounts of testAssetID, exporting nothing
+func evmExportTx(amounts ...uint64) []byte {
+	tx := binary.BigEndian.AppendUint16(nil, 0)
+	tx = binary.BigEndian.AppendUint32(tx, 1)
+	tx = binary.BigEndian.AppendUint32(tx, 5)
+	tx = append(tx, make([]byte, 2*decode.IDLength)...)
+	tx = binary.BigEndian.AppendUint32(tx, uint32(len(amounts)))
+	for nonce, amount := range amounts {
+		tx = append(tx, make([]byte, decode.ShortIDLength)...)
+		tx = binary.BigEndian.AppendUint64(tx, amount)
+		tx = append(tx, testAssetID[:]...)
+		tx = binary.BigEndian.AppendUint64(tx, uint64(nonce))
+	}
+	return binary.BigEndian.AppendUint32(tx, 0)
+}
+
+func Test_DecodeFeesOverflow(t *testing.T) {
+	s, err := decode.Decode(evmExportTx(math.MaxUint64), decode.CChain)
+	require.NoError(t, err)
+	assert.Equal(t, map[[decode.IDLength]byte]uint64{testAssetID: math.MaxUint64}, s.Fees)
+
+	_, err = decode.Decode(evmExportTx(math.MaxUint64, 1), decode.CChain)
+	assert.ErrorIs(t, err, decode.ErrMalformedTransaction)
+}
+
+func Test_ChainString(t *testing.T) {
+	assert.Equal(t, "P", decode.PChain.String())
+	assert.Equal(t, "C", decode.CChain.String())
+	assert.Equal(t, "Chain(7)", decode.Chain(7).String())
+}
@@ -0,0 +1,77 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package decode
+
+import (
+	"encoding/binary"
+	"errors"
+	"runtime"
+)
+
+// ErrMalformedTransaction is returned when a transaction ends before all its fields were read,
+// or has bytes left once they were
+var ErrMalformedTransaction = errors.New("malformed transaction")
+
+// reader reads the fields of a serialized transaction. Reading past the end panics, which
+// Decode turns into ErrMalformedTransaction with recoverMalformed.
+type reader struct {
+	data   []byte
+	offset int
+}
+
+func newReader(data []byte) *reader {
+	// without spare capacity, reading past the end is always out of range
+	return &reader{data: data[:len(data):len(data)]}
+}
+
+func (r *reader) bytes(n int) []byte {
+	b := r.data[r.offset : r.offset+n : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *reader) id() (id [IDLength]byte) {
+	copy(id[:], r.bytes(IDLength))
+	return id
+}
+
+func (r *reader) shortID() (id [ShortIDLength]byte) {
+	copy(id[:], r.bytes(ShortIDLength))
+	return id
+}
+
+func (r *reader) uint16() uint16 {
+	return binary.BigEndian.Uint16(r.bytes(2))
+}
+
+func (r *reader) uint32() uint32 {
+	return binary.BigEndian.Uint32(r.bytes(4))
+}
+
+func (r *reader) uint64() uint64 {
+	return binary.BigEndian.Uint64(r.bytes(8))
+}
+
+// recoverMalformed turns the panic of a read past the end into ErrMalformedTransaction
+func recoverMalformed(err *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(runtime.Error); !ok {
+			panic(r)
+		}
+		*err = ErrMalformedTransaction
+	}
+}
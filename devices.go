@@ -0,0 +1,110 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"fmt"
+
+	"github.com/zondax/hid"
+	"github.com/zondax/ledger-go"
+)
+
+// DeviceInfo describes a Ledger device connected over USB
+type DeviceInfo struct {
+	// Path is the platform-specific path of the device, stable while it stays plugged in
+	Path      string
+	Product   string
+	Serial    string
+	VendorID  uint16
+	ProductID uint16
+}
+
+// ledgerProductIDs are the products that may report an empty usage page, as in ledger-go
+var ledgerProductIDs = map[uint16]int{
+	0x4011: 0, // Ledger Nano X
+	0x1011: 0, // Ledger Nano S
+	0x1:    0, // Ledger Nano S
+	0x5011: 0, // Ledger Nano S Plus
+	0x5:    0, // Ledger Nano S Plus
+}
+
+// isLedgerDevice matches the HID interfaces ledger-go connects to
+func isLedgerDevice(d hid.DeviceInfo) bool {
+	if d.UsagePage == ledger_go.UsagePageLedgerNanoS {
+		return true
+	}
+	interfaceID, supported := ledgerProductIDs[d.ProductID]
+	return supported && interfaceID == d.Interface
+}
+
+// ListLedgerDevices returns the connected Ledger devices. The index of a device in the list
+// is the one expected by FindLedgerAvalancheAppOnDevice.
+func ListLedgerDevices() []DeviceInfo {
+	return ledgerDevices(enumerateHID(ledger_go.VendorLedger, 0))
+}
+
+func ledgerDevices(found []hid.DeviceInfo) []DeviceInfo {
+	var devices []DeviceInfo
+	for _, d := range found {
+		if isLedgerDevice(d) {
+			devices = append(devices, newDeviceInfo(d))
+		}
+	}
+	return devices
+}
+
+func newDeviceInfo(d hid.DeviceInfo) DeviceInfo {
+	return DeviceInfo{
+		Path:      d.Path,
+		Product:   d.Product,
+		Serial:    d.Serial,
+		VendorID:  d.VendorID,
+		ProductID: d.ProductID,
+	}
+}
+
+// ConnectByPath finds the Avax user app running in the ledger device at path, as reported by
+// ListLedgerDevices, or ListUSBDevices when opts include WithUSBFilters
+func ConnectByPath(path string, opts ...Option) (*LedgerAvalanche, error) {
+	index, err := deviceIndex(listDevicesFor(opts), path)
+	if err != nil {
+		return nil, err
+	}
+	return FindLedgerAvalancheAppOnDevice(index, opts...)
+}
+
+// listDevicesFor returns the devices in the order of the indices FindLedgerAvalancheAppOnDevice
+// expects under opts, which follow the USB filters if any
+func listDevicesFor(opts []Option) []DeviceInfo {
+	config := &LedgerAvalanche{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if len(config.usbFilters) > 0 {
+		return ListUSBDevices(config.usbFilters...)
+	}
+	return listDevices()
+}
+
+func deviceIndex(devices []DeviceInfo, path string) (int, error) {
+	for i, d := range devices {
+		if d.Path == path {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("ledger device %q not found", path)
+}
@@ -0,0 +1,79 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zondax/hid"
+	"github.com/zondax/ledger-go"
+)
+
+func Test_LedgerDevices(t *testing.T) {
+	found := []hid.DeviceInfo{
+		{Path: "1-1:1.0", ProductID: 0x4011, Interface: 0, Product: "Nano X", Serial: "0001"},
+		{Path: "1-1:1.1", ProductID: 0x4011, Interface: 1, Product: "Nano X", Serial: "0001"},
+		{Path: "IOService:/nanos", ProductID: 0x1234, UsagePage: ledger_go.UsagePageLedgerNanoS, Product: "Nano S"},
+		{Path: "1-2:1.0", ProductID: 0x1234, Interface: 0, Product: "Keyboard"},
+	}
+
+	devices := ledgerDevices(found)
+	require.Len(t, devices, 2)
+	assert.Equal(t, DeviceInfo{Path: "1-1:1.0", Product: "Nano X", Serial: "0001", ProductID: 0x4011}, devices[0])
+	assert.Equal(t, "IOService:/nanos", devices[1].Path)
+
+	index, err := deviceIndex(devices, "IOService:/nanos")
+	require.NoError(t, err)
+	assert.Equal(t, 1, index)
+
+	_, err = deviceIndex(devices, "1-2:1.0")
+	assert.Error(t, err)
+}
+
+func Test_ListLedgerDevices(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+
+	for i, d := range ListLedgerDevices() {
+		t.Logf("%d: %s %s (%s)", i, d.Product, d.Serial, d.Path)
+	}
+}
+
+func Test_ListDevicesForUSBFilters(t *testing.T) {
+	withUSB(t, []hid.DeviceInfo{
+		{Path: "0001:0004:00", VendorID: ledger_go.VendorLedger, ProductID: 0x4011},
+		{Path: "0001:0005:00", VendorID: 0x1234, ProductID: 0x42},
+	}, nil)
+	withConnectDevice(t, func(int) (ledger_go.LedgerDevice, error) {
+		t.Fatal("the filters are ignored")
+		return nil, nil
+	})
+
+	// the filter excludes the first device: indices follow the filtered list
+	filters := WithUSBFilters(USBFilter{VendorID: 0x1234})
+	devices := listDevicesFor([]Option{filters})
+	require.Len(t, devices, 1)
+	assert.Equal(t, "0001:0005:00", devices[0].Path)
+
+	_, err := ConnectByPath("0001:0004:00", filters)
+	assert.ErrorContains(t, err, "not found")
+
+	assert.Equal(t, "0001:0004:00", listDevicesFor(nil)[0].Path)
+}
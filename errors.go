@@ -0,0 +1,355 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/zondax/ledger-avalanche-go/apdu"
+	"github.com/zondax/ledger-avalanche-go/decode"
+	"github.com/zondax/ledger-avalanche-go/serialize"
+	"github.com/zondax/ledger-go"
+)
+
+// ErrMalformedResponse is returned when the device answers with a payload that cannot be parsed
+var ErrMalformedResponse = errors.New("malformed response from device")
+
+// ErrAddressMismatch is returned when the address hash returned by the device does not
+// belong to the returned public key
+var ErrAddressMismatch = errors.New("address hash does not match the public key")
+
+// ErrInvalidPathComponent is returned when a component of a BIP32 path is not a valid child number
+var ErrInvalidPathComponent = serialize.ErrInvalidPathComponent
+
+// ErrInvalidHrp is returned for an HRP that cannot be serialized, or is not made of lowercase
+// letters and digits unless PermissiveInputs is set
+var ErrInvalidHrp = serialize.ErrInvalidHrp
+
+// ErrInvalidChainID is returned for a chain ID that cannot be serialized, or is neither an alias
+// (P, X, C) nor a CB58 chain ID with a valid checksum unless PermissiveInputs is set
+var ErrInvalidChainID = serialize.ErrInvalidChainID
+
+// ErrSignatureMismatch is returned when a signature returned by the device was not made by
+// the key of its path, see WithSignatureVerification
+var ErrSignatureMismatch = errors.New("signature does not match the key of its path")
+
+// ErrDeviceDisconnected is returned when the transport fails to exchange an APDU with the device,
+// e.g. because it was unplugged or the handle went stale after the OS suspended
+var ErrDeviceDisconnected = errors.New("device disconnected")
+
+// ErrNoSigningPaths is returned when a signature is requested without any signing path
+var ErrNoSigningPaths = errors.New("no signing paths")
+
+// ErrInvalidTransactionSize is returned by SignReader for a negative size, or one that does not
+// fit in an int
+var ErrInvalidTransactionSize = errors.New("invalid transaction size")
+
+// ErrTooManySigningPaths is returned when a transaction has more signing paths than allowed
+// by WithMaxSigningPaths. The transaction should be split into smaller ones.
+var ErrTooManySigningPaths = errors.New("too many signing paths, split the transaction")
+
+// ErrHashMismatch is returned with BindTransactionHash when the app signed another hash than the
+// one of the transaction, e.g. because the upload was corrupted
+var ErrHashMismatch = errors.New("device signed another transaction hash")
+
+// ErrWalletIDMismatch is returned when a device holds a different seed than the expected one,
+// e.g. after reconnecting or with WithExpectedWalletID
+var ErrWalletIDMismatch = errors.New("device has a different wallet ID")
+
+// ErrReconnectNotSupported is returned by Reconnect when the ledger was not connected to a HID
+// device by FindLedgerAvalancheApp, e.g. when created with NewLedgerAvalanche or WithDevice
+var ErrReconnectNotSupported = errors.New("reconnecting is only supported for HID devices found by the ledger")
+
+// ErrNonReleaseApp is returned when RequireReleaseApp is set and the app is a test or debug build
+var ErrNonReleaseApp = errors.New("the app is not a release build")
+
+// ErrNotSupported is returned when the app running on the device does not implement a feature
+var ErrNotSupported = errors.New("not supported by the app")
+
+// ErrBlindSigningDisabled is returned by SignHash unless blind signing is enabled with AllowBlindSigning
+var ErrBlindSigningDisabled = errors.New("blind signing of hashes is disabled")
+
+// ErrBusy is returned when an operation is requested while a signing flow is in progress
+// on the same LedgerAvalanche, e.g. from another goroutine
+var ErrBusy = errors.New("device is busy signing")
+
+// ErrAPDUTooLong is returned when a command payload does not fit in a single APDU
+var ErrAPDUTooLong = apdu.ErrDataTooLong
+
+// ErrConnectTimeout is returned when connecting to a device takes longer than WithConnectTimeout allows
+var ErrConnectTimeout = errors.New("timeout connecting to the device")
+
+// ErrExchangeTimeout is returned when the device does not answer in time while data is uploaded
+var ErrExchangeTimeout = errors.New("timeout waiting for the device")
+
+// ErrConfirmationTimeout is returned when the user does not approve an operation on the device in time
+var ErrConfirmationTimeout = errors.New("timeout waiting for the user confirmation")
+
+// ErrUserTimeout is ErrConfirmationTimeout, under the name used by WithUserActionTimeout
+var ErrUserTimeout = ErrConfirmationTimeout
+
+// ErrAppNotOpen matches an APDUError reporting that the Avalanche app is not running on the device
+var ErrAppNotOpen = errors.New("the Avalanche app is not open")
+
+// ErrUserRejected matches an APDUError reporting that the user rejected the operation on the device
+var ErrUserRejected = errors.New("rejected by the user")
+
+// ErrAppNotInstalled matches an APDUError reporting that the app to open is not installed
+var ErrAppNotInstalled = errors.New("app not installed on the device")
+
+// ErrLocked matches an APDUError reporting that the device is locked
+var ErrLocked = errors.New("device is locked")
+
+// statusSentinels maps status words to the sentinel errors they match with errors.Is.
+// ConditionsNotSatisfied is left out: the app also answers it to a command out of sequence,
+// which leaves a partial upload to abort.
+var statusSentinels = map[LedgerError]error{
+	ClaNotSupported:        ErrAppNotOpen,
+	AppDoesNotSeemToBeOpen: ErrAppNotOpen,
+	AppNotRunning:          ErrAppNotOpen,
+	TransactionRejected:    ErrUserRejected,
+	OpenAppRejected:        ErrUserRejected,
+	AppNotInstalled:        ErrAppNotInstalled,
+	DeviceLocked:           ErrLocked,
+}
+
+// ErrMalformedTransaction is returned when an unsigned transaction given to the library cannot
+// be decoded, e.g. as it is truncated
+var ErrMalformedTransaction = decode.ErrMalformedTransaction
+
+// ErrUnsupportedTransaction is returned for a transaction type or a field type the library does
+// not decode
+var ErrUnsupportedTransaction = decode.ErrUnsupportedTransaction
+
+// recoverMalformedResponse converts a runtime panic raised while slicing a device response
+// into ErrMalformedResponse. It must be deferred by functions with a named error result.
+func recoverMalformedResponse(err *error) {
+	if r := recover(); r != nil {
+		if _, ok := r.(runtime.Error); !ok {
+			panic(r)
+		}
+		*err = ErrMalformedResponse
+	}
+}
+
+// ErrorTranslator produces the message shown for a device status word.
+// Returning an empty string falls back to the default English message.
+type ErrorTranslator func(code LedgerError) string
+
+// APDUError is returned when the device answers with a status word other than NoErrors
+type APDUError struct {
+	Code LedgerError
+	// Message is the detail sent by the app along with the status word, if any
+	Message string
+	// Offset is the byte of the transaction where the app failed to parse it, valid if HasOffset is set
+	Offset    int
+	HasOffset bool
+
+	translate ErrorTranslator
+}
+
+func (e *APDUError) Error() string {
+	msg := ""
+	if e.translate != nil {
+		msg = e.translate(e.Code)
+	}
+	if msg == "" {
+		msg = e.Message
+	}
+	if msg == "" {
+		msg = DefaultErrorMessage(e.Code)
+	}
+
+	if e.HasOffset {
+		return fmt.Sprintf("%s (parsing failed at byte %d)", msg, e.Offset)
+	}
+	return msg
+}
+
+// Is reports whether the status word matches target, one of ErrAppNotOpen, ErrAppNotInstalled,
+// ErrUserRejected or ErrLocked
+func (e *APDUError) Is(target error) bool {
+	sentinel, ok := statusSentinels[e.Code]
+	return ok && sentinel == target
+}
+
+// Description returns the English description of the status word, ignoring translations
+// and the detail sent by the app
+func (e *APDUError) Description() string {
+	return DefaultErrorMessage(e.Code)
+}
+
+// Retriable reports whether the same request may succeed later without changes,
+// e.g. once the device is unlocked or no longer busy
+func (e *APDUError) Retriable() bool {
+	return e.Code == DeviceIsBusy || e.Code == DeviceLocked
+}
+
+var parseOffsetRegexp = regexp.MustCompile(`(?i)offset[:= ]*(\d+)`)
+
+// withPayload adds the detail the app sent along with the status word, extracting the
+// parsing offset when the app reports it (e.g "Unexpected type at offset 57")
+func (e *APDUError) withPayload(payload []byte) *APDUError {
+	e.Message = string(payload)
+	if match := parseOffsetRegexp.FindStringSubmatch(e.Message); match != nil {
+		if offset, err := strconv.Atoi(match[1]); err == nil {
+			e.Offset = offset
+			e.HasOffset = true
+		}
+	}
+	return e
+}
+
+// ChunkError reports which chunk of a transaction upload failed. Start and End are the
+// byte range of the chunk in the uploaded payload, which starts with the change path header
+// when signing a transaction.
+type ChunkError struct {
+	Index int
+	Count int
+	Start int
+	End   int
+	Err   error
+}
+
+func (e *ChunkError) Error() string {
+	return fmt.Sprintf("failed uploading chunk %d of %d (bytes %d-%d): %v", e.Index+1, e.Count, e.Start, e.End-1, e.Err)
+}
+
+func (e *ChunkError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultErrorMessage returns the English description of a device status word
+func DefaultErrorMessage(code LedgerError) string {
+	if msg, ok := errorMessages[code]; ok {
+		return msg
+	}
+	return fmt.Sprintf("device error 0x%04x", uint16(code))
+}
+
+// String returns the English description of the status word, as DefaultErrorMessage
+func (e LedgerError) String() string {
+	return DefaultErrorMessage(e)
+}
+
+// IsUserRejection reports whether the user refused the operation on the device
+func (e LedgerError) IsUserRejection() bool {
+	return statusSentinels[e] == ErrUserRejected
+}
+
+// IsDeviceLocked reports whether the operation failed because the device is locked
+func (e LedgerError) IsDeviceLocked() bool {
+	return e == DeviceLocked
+}
+
+// IsAppNotOpen reports whether the operation failed because the Avalanche app is not open
+func (e LedgerError) IsAppNotOpen() bool {
+	return statusSentinels[e] == ErrAppNotOpen
+}
+
+var errorMessages = map[LedgerError]string{
+	NoErrors:               "[APDU_CODE_OK] No errors",
+	DeviceIsBusy:           "[APDU_CODE_BUSY] Device is busy",
+	DeviceLocked:           "[APDU_CODE_DEVICE_LOCKED] Device is locked",
+	ErrorDerivingKeys:      "[APDU_CODE_ERROR_DERIVING_KEYS] Error deriving keys",
+	OpenAppRejected:        "[APDU_CODE_OPEN_APP_REJECTED] Opening the app was rejected",
+	AppNotInstalled:        "[APDU_CODE_APP_NOT_INSTALLED] App not installed",
+	AppNotRunning:          "[APDU_CODE_APP_NOT_RUNNING] No app is running on the device",
+	DeviceInRecoveryMode:   "[APDU_CODE_RECOVERY_MODE] Device is in recovery mode",
+	NotEnoughMemory:        "[APDU_CODE_NOT_ENOUGH_MEMORY] Not enough memory on the device",
+	IncorrectP1P2:          "[APDU_CODE_INCORRECT_P1_P2] Incorrect P1 or P2",
+	ReferencedDataNotFound: "[APDU_CODE_DATA_NOT_FOUND] Referenced data not found",
+	UnknownAPDU:            "[APDU_CODE_UNKNOWN_APDU] Unknown APDU",
+	Halted:                 "[APDU_CODE_HALTED] Device halted, reconnect it",
+}
+
+// KnownLedgerErrors returns the status words with a description, including NoErrors
+func KnownLedgerErrors() []LedgerError {
+	codes := make([]LedgerError, 0, len(errorMessages))
+	for code := range errorMessages {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
+
+// ledgerGoStatusWords are the status words ledger-go reports with a descriptive message
+var ledgerGoStatusWords = []LedgerError{
+	ExecutionError, WrongLength, EmptyBuffer, OutputBufferTooSmall, DataInvalidated,
+	ConditionsNotSatisfied, TransactionRejected, DataIsInvalid, InvalidP1P2,
+	InstructionNotSupported, ClaNotSupported, AppDoesNotSeemToBeOpen, UnknownError, SignVerifyError,
+}
+
+func init() {
+	for _, code := range ledgerGoStatusWords {
+		errorMessages[code] = ledger_go.ErrorMessage(uint16(code))
+	}
+}
+
+// parseStatusWord recovers the status word from an error returned by a ledger-go transport
+func parseStatusWord(err error) (LedgerError, bool) {
+	msg := err.Error()
+	for _, code := range ledgerGoStatusWords {
+		if msg == ledger_go.ErrorMessage(uint16(code)) {
+			return code, true
+		}
+	}
+
+	if hexCode := strings.TrimPrefix(msg, "Error code: "); hexCode != msg {
+		code, parseErr := strconv.ParseUint(hexCode, 16, 16)
+		if parseErr == nil {
+			return LedgerError(code), true
+		}
+	}
+	return 0, false
+}
+
+// ledgerGoCommandErrors are reported by ledger-go when rejecting a command before sending it
+var ledgerGoCommandErrors = []string{
+	"APDU commands should not be smaller than 5",
+	"APDU[data length] mismatch",
+}
+
+// wrapTransportError reports a transport failure as ErrDeviceDisconnected
+func wrapTransportError(err error) error {
+	for _, msg := range ledgerGoCommandErrors {
+		if err.Error() == msg {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %v", ErrDeviceDisconnected, err)
+}
+
+// isStatus reports whether err is an APDUError carrying one of codes
+func isStatus(err error, codes ...LedgerError) bool {
+	var apduErr *APDUError
+	if !errors.As(err, &apduErr) {
+		return false
+	}
+	for _, code := range codes {
+		if apduErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
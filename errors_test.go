@@ -0,0 +1,198 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zondax/ledger-go"
+)
+
+func Test_GetPubKeyMalformedResponse(t *testing.T) {
+	// public key length points past the end of the response
+	response := append([]byte{0xFF}, bytes.Repeat([]byte{0x02}, 40)...)
+	ledger := newMockLedger(&mockDevice{handler: replies(response)})
+
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func Test_VerifyMultipleSignaturesMalformedSignature(t *testing.T) {
+	pubKeyResponse := append([]byte{33}, bytes.Repeat([]byte{0x02}, 33+20)...)
+	ledger := newMockLedger(&mockDevice{handler: replies(pubKeyResponse)})
+
+	response := ResponseSign{Signature: map[string][]byte{"0/0": {}}}
+	err := ledger.VerifyMultipleSignatures(response, make([]byte, HASH_LEN), "m/44'/9000'/0'", []string{"0/0"}, "", "")
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func Test_APDUErrorTranslator(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, statusError(TransactionRejected)
+	}})
+
+	_, err := ledger.GetVersion()
+	var apduErr *APDUError
+	require.ErrorAs(t, err, &apduErr)
+	assert.Equal(t, TransactionRejected, apduErr.Code)
+	assert.Equal(t, "[APDU_CODE_COMMAND_NOT_ALLOWED] Command not allowed / User Rejected (no current EF)", err.Error())
+
+	ledger.SetErrorTranslator(func(code LedgerError) string {
+		if code == TransactionRejected {
+			return "Transacción rechazada"
+		}
+		return ""
+	})
+	_, err = ledger.GetVersion()
+	assert.Equal(t, "Transacción rechazada", err.Error())
+}
+
+func Test_APDUErrorUnknownCode(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, statusError(0x6123)
+	}})
+	ledger.SetErrorTranslator(func(LedgerError) string { return "" })
+
+	_, err := ledger.GetVersion()
+	var apduErr *APDUError
+	require.ErrorAs(t, err, &apduErr)
+	assert.Equal(t, LedgerError(0x6123), apduErr.Code)
+	assert.Equal(t, "device error 0x6123", err.Error())
+}
+
+func Test_APDUErrorSentinels(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[2] == PAYLOAD_LAST {
+			return nil, statusError(TransactionRejected)
+		}
+		return []byte{}, nil
+	}})
+
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil)
+	assert.ErrorIs(t, err, ErrUserRejected)
+	assert.NotErrorIs(t, err, ErrLocked)
+
+	var apduErr *APDUError
+	require.ErrorAs(t, err, &apduErr)
+	assert.False(t, apduErr.Retriable())
+	assert.Equal(t, ledger_go.ErrorMessage(uint16(TransactionRejected)), apduErr.Description())
+
+	locked := &APDUError{Code: DeviceLocked}
+	assert.ErrorIs(t, locked, ErrLocked)
+	assert.True(t, locked.Retriable())
+
+	ledger = newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, statusError(AppDoesNotSeemToBeOpen)
+	}}, AllowBlindSigning(true))
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, ErrAppNotOpen)
+}
+
+func Test_LedgerErrorHelpers(t *testing.T) {
+	for _, code := range []LedgerError{TransactionRejected, OpenAppRejected} {
+		assert.True(t, code.IsUserRejection(), code)
+		assert.ErrorIs(t, &APDUError{Code: code}, ErrUserRejected)
+	}
+	assert.False(t, DataIsInvalid.IsUserRejection())
+	assert.False(t, ConditionsNotSatisfied.IsUserRejection())
+
+	assert.True(t, DeviceLocked.IsDeviceLocked())
+	assert.False(t, DeviceIsBusy.IsDeviceLocked())
+
+	for _, code := range []LedgerError{ClaNotSupported, AppDoesNotSeemToBeOpen, AppNotRunning} {
+		assert.True(t, code.IsAppNotOpen(), code)
+	}
+}
+
+func Test_LedgerErrorString(t *testing.T) {
+	assert.Equal(t, "[APDU_CODE_DEVICE_LOCKED] Device is locked", DeviceLocked.String())
+	assert.Equal(t, ledger_go.ErrorMessage(uint16(DataIsInvalid)), fmt.Sprint(DataIsInvalid))
+	assert.Equal(t, "device error 0x6123", LedgerError(0x6123).String())
+
+	codes := KnownLedgerErrors()
+	assert.Contains(t, codes, NoErrors)
+	assert.Contains(t, codes, AppDoesNotSeemToBeOpen)
+	for i, code := range codes {
+		assert.NotContains(t, code.String(), "device error", code)
+		if i > 0 {
+			assert.Less(t, codes[i-1], code)
+		}
+	}
+}
+
+func Test_SignParseOffset(t *testing.T) {
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[2] == PAYLOAD_LAST {
+			return []byte("Unexpected output type at offset 57"), statusError(DataIsInvalid)
+		}
+		return []byte{}, nil
+	}}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil)
+	var apduErr *APDUError
+	require.ErrorAs(t, err, &apduErr)
+	assert.Equal(t, DataIsInvalid, apduErr.Code)
+	assert.True(t, apduErr.HasOffset)
+	assert.Equal(t, 57, apduErr.Offset)
+	assert.Equal(t, "Unexpected output type at offset 57 (parsing failed at byte 57)", apduErr.Error())
+}
+
+func Test_SignErrorWithoutOffset(t *testing.T) {
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[2] == PAYLOAD_LAST {
+			return []byte("Unexpected buffer end"), statusError(DataIsInvalid)
+		}
+		return []byte{}, nil
+	}}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil)
+	var apduErr *APDUError
+	require.ErrorAs(t, err, &apduErr)
+	assert.False(t, apduErr.HasOffset)
+	assert.Equal(t, "Unexpected buffer end", apduErr.Error())
+}
+
+func Test_SignChunkError(t *testing.T) {
+	device := &mockDevice{}
+	device.handler = func(apdu []byte) ([]byte, error) {
+		// fail on the third chunk
+		if len(device.sent) == 4 {
+			return nil, statusError(ExecutionError)
+		}
+		return []byte{}, nil
+	}
+	ledger := newMockLedger(device)
+
+	// the change path header holds one path suffix: 1 + 9 bytes
+	message := make([]byte, 7*CHUNK_SIZE-10)
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, message, nil)
+
+	var chunkErr *ChunkError
+	require.ErrorAs(t, err, &chunkErr)
+	assert.Equal(t, 2, chunkErr.Index)
+	assert.Equal(t, 7, chunkErr.Count)
+	assert.Equal(t, 500, chunkErr.Start)
+	assert.Equal(t, 750, chunkErr.End)
+	assert.True(t, isStatus(err, ExecutionError))
+	assert.Contains(t, err.Error(), "failed uploading chunk 3 of 7 (bytes 500-749)")
+}
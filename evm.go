@@ -0,0 +1,255 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ValidateEVMPath checks that path is a valid derivation path for the EVM coin type (e.g "m/44'/60'/0'/0/0")
+func ValidateEVMPath(path string) error {
+	childNumbers, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	if len(childNumbers) < 2 || childNumbers[0] != 44|HARDENED || childNumbers[1] != 60|HARDENED {
+		return errors.New(`EVM path should use coin type 60 (e.g "m/44'/60'/0'/0/0")`)
+	}
+	return nil
+}
+
+// ethereumRecoveryOffset is added to the recovery id in the v byte of Ethereum signatures
+const ethereumRecoveryOffset = 27
+
+// ToEthereumSignature converts a signature from the device, laid out as in Avalanche
+// credentials (r || s || v with v in {0, 1}), to the Ethereum layout where v is 27 or 28
+func ToEthereumSignature(sig []byte) ([]byte, error) {
+	if len(sig) != SIGNATURE_LEN {
+		return nil, fmt.Errorf("signature should be %d bytes, got %d", SIGNATURE_LEN, len(sig))
+	}
+	if sig[64] > 1 {
+		return nil, fmt.Errorf("invalid recovery id %d", sig[64])
+	}
+
+	converted := append([]byte{}, sig...)
+	converted[64] += ethereumRecoveryOffset
+	return converted, nil
+}
+
+// FromEthereumSignature converts an Ethereum signature (r || s || v with v in {27, 28})
+// to the Avalanche credential layout where v is the recovery id
+func FromEthereumSignature(sig []byte) ([]byte, error) {
+	if len(sig) != SIGNATURE_LEN {
+		return nil, fmt.Errorf("signature should be %d bytes, got %d", SIGNATURE_LEN, len(sig))
+	}
+	if sig[64] != ethereumRecoveryOffset && sig[64] != ethereumRecoveryOffset+1 {
+		return nil, fmt.Errorf("invalid v value %d", sig[64])
+	}
+
+	converted := append([]byte{}, sig...)
+	converted[64] -= ethereumRecoveryOffset
+	return converted, nil
+}
+
+// GetEVMAddress returns the EIP-55 checksummed C-chain address of the key at path
+func (ledger *LedgerAvalanche) GetEVMAddress(path string, show bool) (string, error) {
+	if err := ValidateEVMPath(path); err != nil {
+		return "", err
+	}
+
+	publicKey, _, err := ledger.GetPubKeyWithFormat(path, show, "", "", Uncompressed)
+	if err != nil {
+		return "", err
+	}
+
+	return EVMAddress(publicKey)
+}
+
+// SignEVMTransaction signs a C-chain transaction, RLP encoded as for Ethereum, with the key at path.
+// For legacy EIP-155 transactions the device only reports the low byte of v, which is ambiguous
+// for chain IDs above 109 such as the C-chain; use R, S and the public key to recover the parity.
+func (ledger *LedgerAvalanche) SignEVMTransaction(path string, rawTx []byte) (*EVMSignature, error) {
+	return ledger.SignEVMTransactionWithTokens(path, rawTx, nil)
+}
+
+// SignEVMTransactionWithTokens works as SignEVMTransaction, first providing the signed descriptors
+// of the tokens the transaction involves (see ProvideTokenInfo) so the device shows their ticker
+// and amounts with the right decimals instead of contract addresses and raw values
+func (ledger *LedgerAvalanche) SignEVMTransactionWithTokens(path string, rawTx []byte, tokens []TokenInfo) (_ *EVMSignature, err error) {
+	defer ledger.observe(OperationSignEVMTransaction, time.Now(), &err)
+
+	if err := ValidateEVMPath(path); err != nil {
+		return nil, err
+	}
+	if len(rawTx) == 0 {
+		return nil, errors.New("empty transaction")
+	}
+
+	serializedPath, err := ledger.serializer.SerializePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, release, err := ledger.beginSigning(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := ledger.checkCommandVersion(ctx, CLA_ETH, INS_SIGN_EVM_TX); err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		if err := ledger.provideTokenInfo(ctx, token); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := ledger.uploadEVMPayload(ctx, INS_SIGN_EVM_TX, append(serializedPath, rawTx...))
+	if err != nil {
+		return nil, err
+	}
+	return parseEVMSignature(response)
+}
+
+// SignEVMMessage signs message with the key at path as described in EIP-191 (personal_sign):
+// the device signs keccak256("\x19Ethereum Signed Message:\n" || len(message) || message).
+// V is 27 or 28.
+func (ledger *LedgerAvalanche) SignEVMMessage(path string, message []byte) (_ *EVMSignature, err error) {
+	defer ledger.observe(OperationSignEVMMessage, time.Now(), &err)
+
+	if err := ValidateEVMPath(path); err != nil {
+		return nil, err
+	}
+
+	serializedPath, err := ledger.serializer.SerializePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// [path | message length | message]
+	ctx, release, err := ledger.beginSigning(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if err := ledger.checkCommandVersion(ctx, CLA_ETH, INS_SIGN_EVM_MSG); err != nil {
+		return nil, err
+	}
+
+	payload := binary.BigEndian.AppendUint32(serializedPath, uint32(len(message)))
+	response, err := ledger.uploadEVMPayload(ctx, INS_SIGN_EVM_MSG, append(payload, message...))
+	if err != nil {
+		return nil, err
+	}
+	return parseEVMSignature(response)
+}
+
+// uploadEVMPayload streams payload to the Ethereum instruction ins in chunks as large as
+// an APDU allows and returns the answer to the last one. ctx should hold the signing flow.
+func (ledger *LedgerAvalanche) uploadEVMPayload(ctx context.Context, ins byte, payload []byte) ([]byte, error) {
+	var response []byte
+	for offset := 0; offset < len(payload); offset += MAX_APDU_DATA_LEN {
+		end := offset + MAX_APDU_DATA_LEN
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		p1 := byte(P1_EVM_MORE_CHUNKS)
+		if offset == 0 {
+			p1 = P1_EVM_FIRST_CHUNK
+		}
+
+		chunks := (len(payload) + MAX_APDU_DATA_LEN - 1) / MAX_APDU_DATA_LEN
+		ledger.reportProgress(SignUploading, offset/MAX_APDU_DATA_LEN+1, chunks)
+
+		// Once the last chunk is received the device waits for the user to review it
+		timeout, timeoutErr := ledger.exchangeTimeout, ErrExchangeTimeout
+		if end == len(payload) {
+			timeout, timeoutErr = ledger.confirmationTimeout, ErrConfirmationTimeout
+			ledger.reportProgress(SignAwaitingConfirmation, 0, 0)
+		}
+
+		message, err := buildAPDU(CLA_ETH, ins, p1, 0, payload[offset:end])
+		if err != nil {
+			return nil, err
+		}
+		response, err = ledger.exchangeContext(ctx, message, timeout, timeoutErr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return response, nil
+}
+
+// parseEVMSignature parses a [v | r | s] response
+func parseEVMSignature(response []byte) (*EVMSignature, error) {
+	if len(response) != SIGNATURE_LEN {
+		return nil, ErrMalformedResponse
+	}
+	return &EVMSignature{
+		V: response[0],
+		R: append([]byte{}, response[1:33]...),
+		S: append([]byte{}, response[33:65]...),
+	}, nil
+}
+
+// EVMAddress returns the EIP-55 checksummed address of a public key:
+// the last 20 bytes of the Keccak-256 of the uncompressed key
+func EVMAddress(publicKey []byte) (string, error) {
+	uncompressed, err := FormatPublicKey(publicKey, Uncompressed)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write(uncompressed[1:])
+	address := h.Sum(nil)[12:]
+
+	return checksumAddress(address), nil
+}
+
+// checksumAddress encodes an address as described in EIP-55
+func checksumAddress(address []byte) string {
+	lower := hex.EncodeToString(address)
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	hash := h.Sum(nil)
+
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0F
+		}
+		if c >= 'a' && nibble >= 8 {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+
+	return "0x" + string(checksummed)
+}
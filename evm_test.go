@@ -0,0 +1,157 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/sha3"
+)
+
+// Public key of the private key 0x01
+const generatorPublicKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+func Test_EVMAddress(t *testing.T) {
+	publicKey, _ := hex.DecodeString(generatorPublicKey)
+
+	address, err := EVMAddress(publicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", address)
+}
+
+func Test_GetEVMAddress(t *testing.T) {
+	publicKey, _ := hex.DecodeString(generatorPublicKey)
+	response := append(append([]byte{33}, publicKey...), bytes.Repeat([]byte{0x11}, 20)...)
+	ledger := newMockLedger(&mockDevice{handler: replies(response)})
+
+	address, err := ledger.GetEVMAddress("m/44'/60'/0'/0/0", false)
+	require.NoError(t, err)
+	assert.Equal(t, "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf", address)
+}
+
+func Test_GetEVMAddressWrongCoinType(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.GetEVMAddress("m/44'/9000'/0'/0/0", false)
+	assert.Error(t, err)
+	assert.Empty(t, device.sent)
+}
+
+func Test_EthereumSignature(t *testing.T) {
+	signature, _ := hex.DecodeString("d6844ac6fc813791fcc9030566ae54b1b8f7a70d67fb3252d7a6096c8fe282153625aff848e6e787334f4bc7ee347a7be78857cec74d81ff61f594b261c731ff01")
+
+	ethSignature, err := ToEthereumSignature(signature)
+	require.NoError(t, err)
+	assert.Equal(t, signature[:64], ethSignature[:64])
+	assert.Equal(t, byte(28), ethSignature[64])
+
+	roundTrip, err := FromEthereumSignature(ethSignature)
+	require.NoError(t, err)
+	assert.Equal(t, signature, roundTrip)
+}
+
+func Test_EthereumSignatureInvalid(t *testing.T) {
+	signature := make([]byte, SIGNATURE_LEN)
+
+	_, err := ToEthereumSignature(signature[:64])
+	assert.Error(t, err)
+	_, err = FromEthereumSignature(append(signature, 0))
+	assert.Error(t, err)
+
+	signature[64] = 27
+	_, err = ToEthereumSignature(signature)
+	assert.Error(t, err)
+
+	signature[64] = 1
+	_, err = FromEthereumSignature(signature)
+	assert.Error(t, err)
+}
+
+func Test_SignEVMTransaction(t *testing.T) {
+	rawTx := bytes.Repeat([]byte{0xAA}, 300)
+	response := append([]byte{0x1b}, bytes.Repeat([]byte{0x01}, 32)...)
+	response = append(response, bytes.Repeat([]byte{0x02}, 32)...)
+
+	device := &mockDevice{handler: replies([]byte{}, response)}
+	ledger := newMockLedger(device)
+
+	signature, err := ledger.SignEVMTransaction("m/44'/60'/0'/0/0", rawTx)
+	require.NoError(t, err)
+	assert.Equal(t, EVMSignature{V: 0x1b, R: bytes.Repeat([]byte{0x01}, 32), S: bytes.Repeat([]byte{0x02}, 32)}, *signature)
+
+	path, _ := SerializePath("m/44'/60'/0'/0/0")
+	payload := append(path, rawTx...)
+	require.Len(t, device.sent, 2)
+	assert.Equal(t, append([]byte{CLA_ETH, INS_SIGN_EVM_TX, P1_EVM_FIRST_CHUNK, 0, MAX_APDU_DATA_LEN}, payload[:MAX_APDU_DATA_LEN]...), device.sent[0])
+	assert.Equal(t, append([]byte{CLA_ETH, INS_SIGN_EVM_TX, P1_EVM_MORE_CHUNKS, 0, byte(len(payload) - MAX_APDU_DATA_LEN)}, payload[MAX_APDU_DATA_LEN:]...), device.sent[1])
+}
+
+func Test_SignEVMTransactionInvalid(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.SignEVMTransaction("m/44'/9000'/0'/0/0", []byte{0xc0})
+	assert.Error(t, err)
+	_, err = ledger.SignEVMTransaction("m/44'/60'/0'/0/0", nil)
+	assert.Error(t, err)
+	assert.Empty(t, device.sent)
+
+	ledger = newMockLedger(&mockDevice{handler: replies([]byte{0x1b})})
+	_, err = ledger.SignEVMTransaction("m/44'/60'/0'/0/0", []byte{0xc0})
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func Test_SignEVMMessage(t *testing.T) {
+	message := bytes.Repeat([]byte("Hello Avalanche! "), 30)
+	privateKeyBytes, _ := hex.DecodeString("e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35")
+	privateKey, publicKey := btcec.PrivKeyFromBytes(privateKeyBytes)
+
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d", len(message))))
+	h.Write(message)
+	hash := h.Sum(nil)
+
+	// the device answers with v | r | s, v being 27 or 28
+	compact, err := ecdsa.SignCompact(privateKey, hash, false)
+	require.NoError(t, err)
+
+	device := &mockDevice{handler: replies([]byte{}, []byte{}, compact)}
+	ledger := newMockLedger(device)
+
+	signature, err := ledger.SignEVMMessage("m/44'/60'/0'/0/0", message)
+	require.NoError(t, err)
+
+	require.Len(t, device.sent, 3)
+	path, _ := SerializePath("m/44'/60'/0'/0/0")
+	assert.Equal(t, byte(P1_EVM_FIRST_CHUNK), device.sent[0][2])
+	assert.Equal(t, []byte{0, 0, 0x01, 0xfe}, device.sent[0][5+len(path):5+len(path)+4], "message length")
+	assert.Equal(t, byte(P1_EVM_MORE_CHUNKS), device.sent[2][2])
+
+	recovered, _, err := ecdsa.RecoverCompact(append(append([]byte{signature.V}, signature.R...), signature.S...), hash)
+	require.NoError(t, err)
+	expected, _ := EVMAddress(publicKey.SerializeCompressed())
+	address, _ := EVMAddress(recovered.SerializeCompressed())
+	assert.Equal(t, expected, address)
+}
@@ -0,0 +1,43 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"fmt"
+)
+
+// appFeature is an optional instruction of the app, available since a version of it
+type appFeature struct {
+	name string
+	// since is the first app version implementing the feature, nil if none does yet
+	since *VersionInfo
+}
+
+// checkFeature fails with ErrNotSupported unless the app running on the device implements feature
+func (ledger *LedgerAvalanche) checkFeature(feature appFeature) error {
+	if feature.since == nil {
+		return fmt.Errorf("%w: %s", ErrNotSupported, feature.name)
+	}
+	if err := ledger.CheckMinVersion(*feature.since); err != nil {
+		return fmt.Errorf("%w: %s (%v)", ErrNotSupported, feature.name, err)
+	}
+	return nil
+}
+
+// txHashFeature is the app reporting the hash it signed along with the first signature, which
+// BindTransactionHash relies on. Current apps answer with the bare signature.
+var txHashFeature = appFeature{name: "signed hash reporting"}
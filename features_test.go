@@ -0,0 +1,34 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckFeature(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: replies([]byte{0, 0, 6, 5}, []byte{0, 0, 6, 5})})
+
+	assert.ErrorIs(t, ledger.checkFeature(appFeature{name: "future"}), ErrNotSupported)
+	assert.NoError(t, ledger.checkFeature(appFeature{name: "old", since: &VersionInfo{0, 0, 6, 0}}))
+
+	err := ledger.checkFeature(appFeature{name: "new", since: &VersionInfo{0, 0, 7, 0}})
+	assert.ErrorIs(t, err, ErrNotSupported)
+	assert.Contains(t, err.Error(), "0.7.0")
+}
@@ -0,0 +1,155 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JSONFormatVersion is the version of the JSON encoding of ResponseSign. It is increased on
+// any incompatible change, and UnmarshalJSON refuses other versions.
+const JSONFormatVersion = 1
+
+// ErrUnsupportedJSONVersion is returned when decoding a ResponseSign of another format version
+var ErrUnsupportedJSONVersion = errors.New("unsupported JSON format version")
+
+type responseSignJSON struct {
+	Version    int             `json:"version"`
+	Hash       hexBytes        `json:"hash"`
+	Signatures []PathSignature `json:"signatures"`
+}
+
+type pathSignatureJSON struct {
+	Path      string   `json:"path"`
+	Signature hexBytes `json:"signature"`
+	Hash      hexBytes `json:"hash,omitempty"`
+}
+
+type versionInfoJSON struct {
+	AppMode uint8 `json:"app_mode"`
+	Major   uint8 `json:"major"`
+	Minor   uint8 `json:"minor"`
+	Patch   uint8 `json:"patch"`
+}
+
+// hexBytes is encoded in JSON as a lowercase hex string, without 0x prefix
+type hexBytes []byte
+
+func (b hexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(b))
+}
+
+func (b *hexBytes) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	decoded, err := hex.DecodeString(text)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// MarshalJSON encodes the response as
+// {"version": 1, "hash": "<hex>", "signatures": [{"path": "0/0", "signature": "<hex>"}]},
+// the signatures in request order
+func (response ResponseSign) MarshalJSON() ([]byte, error) {
+	signatures := response.SignaturesOrdered
+	if signatures == nil {
+		signatures = []PathSignature{}
+	}
+	return json.Marshal(responseSignJSON{
+		Version:    JSONFormatVersion,
+		Hash:       response.Hash,
+		Signatures: signatures,
+	})
+}
+
+// UnmarshalJSON decodes a response encoded by MarshalJSON, filling Signature by path
+func (response *ResponseSign) UnmarshalJSON(data []byte) error {
+	var decoded responseSignJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Version != JSONFormatVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedJSONVersion, decoded.Version)
+	}
+
+	*response = ResponseSign{
+		Hash:              decoded.Hash,
+		Signature:         make(map[string][]byte, len(decoded.Signatures)),
+		SignaturesOrdered: decoded.Signatures,
+	}
+	for _, signature := range decoded.Signatures {
+		response.Signature[signature.Path] = signature.Signature
+	}
+	return nil
+}
+
+// MarshalJSON encodes the signature as {"path": "0/0", "signature": "<hex>", "hash": "<hex>"}
+func (p PathSignature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(pathSignatureJSON{Path: p.Path, Signature: p.Signature, Hash: p.Hash})
+}
+
+// UnmarshalJSON decodes a signature encoded by MarshalJSON
+func (p *PathSignature) UnmarshalJSON(data []byte) error {
+	var decoded pathSignatureJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*p = newPathSignature(decoded.Path, decoded.Signature, decoded.Hash)
+	return nil
+}
+
+// MarshalJSON encodes the version as {"app_mode": 0, "major": 0, "minor": 6, "patch": 5}
+func (c VersionInfo) MarshalJSON() ([]byte, error) {
+	return json.Marshal(versionInfoJSON(c))
+}
+
+// UnmarshalJSON decodes a version encoded by MarshalJSON
+func (c *VersionInfo) UnmarshalJSON(data []byte) error {
+	var decoded versionInfoJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*c = VersionInfo(decoded)
+	return nil
+}
+
+// MarshalJSON encodes the signature as a hex string of its [r | s | v] bytes
+func (sig Signature) MarshalJSON() ([]byte, error) {
+	return hexBytes(sig[:]).MarshalJSON()
+}
+
+// UnmarshalJSON decodes a signature encoded by MarshalJSON
+func (sig *Signature) UnmarshalJSON(data []byte) error {
+	var decoded hexBytes
+	if err := decoded.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	parsed, err := ParseSignature(decoded)
+	if err != nil {
+		return err
+	}
+	*sig = parsed
+	return nil
+}
@@ -0,0 +1,86 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResponseSignJSON(t *testing.T) {
+	hash := bytes.Repeat([]byte{0x11}, HASH_LEN)
+	first := append(bytes.Repeat([]byte{0xaa}, 64), 0x01)
+	second := append(bytes.Repeat([]byte{0xbb}, 64), 0x00)
+	response := &ResponseSign{
+		Hash:      hash,
+		Signature: map[string][]byte{"0/1": second, "0/0": first},
+		SignaturesOrdered: []PathSignature{
+			newPathSignature("0/1", second, hash),
+			newPathSignature("0/0", first, hash),
+		},
+	}
+
+	data, err := json.Marshal(response)
+	require.NoError(t, err)
+	expected := `{"version":1,"hash":"` + strings.Repeat("11", 32) + `","signatures":[` +
+		`{"path":"0/1","signature":"` + strings.Repeat("bb", 64) + `00","hash":"` + strings.Repeat("11", 32) + `"},` +
+		`{"path":"0/0","signature":"` + strings.Repeat("aa", 64) + `01","hash":"` + strings.Repeat("11", 32) + `"}]}`
+	assert.JSONEq(t, expected, string(data))
+
+	var decoded ResponseSign
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, *response, decoded)
+}
+
+func Test_ResponseSignJSONVersion(t *testing.T) {
+	var decoded ResponseSign
+	err := json.Unmarshal([]byte(`{"version":2,"hash":"","signatures":[]}`), &decoded)
+	assert.ErrorIs(t, err, ErrUnsupportedJSONVersion)
+
+	err = json.Unmarshal([]byte(`{"version":1,"hash":"zz","signatures":[]}`), &decoded)
+	assert.Error(t, err)
+}
+
+func Test_VersionInfoJSON(t *testing.T) {
+	data, err := json.Marshal(VersionInfo{AppMode: 1, Major: 0, Minor: 6, Patch: 5})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"app_mode":1,"major":0,"minor":6,"patch":5}`, string(data))
+
+	var decoded VersionInfo
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, VersionInfo{AppMode: 1, Major: 0, Minor: 6, Patch: 5}, decoded)
+}
+
+func Test_SignatureJSON(t *testing.T) {
+	var sig Signature
+	sig[0], sig[64] = 0x12, 0x01
+
+	data, err := json.Marshal(sig)
+	require.NoError(t, err)
+	assert.Equal(t, `"12`+strings.Repeat("00", 63)+`01"`, string(data))
+
+	var decoded Signature
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, sig, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`"1234"`), &decoded))
+}
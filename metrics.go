@@ -0,0 +1,50 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import "time"
+
+// Names of the operations reported to Metrics
+const (
+	OperationGetVersion         = "get_version"
+	OperationGetWalletID        = "get_wallet_id"
+	OperationGetPubKey          = "get_pubkey"
+	OperationGetExtendedPubKey  = "get_extended_pubkey"
+	OperationSign               = "sign"
+	OperationSignHash           = "sign_hash"
+	OperationSignMessage        = "sign_message"
+	OperationSignEVMTransaction = "sign_evm_transaction"
+	OperationSignEVMMessage     = "sign_evm_message"
+	// OperationExchange is a single APDU exchange, reported by MetricsMiddleware
+	OperationExchange = "exchange"
+)
+
+// Metrics receives the outcome of every operation with the device, e.g. to alert on rising
+// signing failures or slow devices. See the metrics/prometheus package for an adapter.
+type Metrics interface {
+	// OnOperation is called once the operation name completed after dur, err being nil
+	// on success. It must not block.
+	OnOperation(name string, dur time.Duration, err error)
+}
+
+// observe reports the operation name started at start, to be deferred by functions with a
+// named error result
+func (ledger *LedgerAvalanche) observe(name string, start time.Time, err *error) {
+	if ledger.metrics != nil {
+		ledger.metrics.OnOperation(name, time.Since(start), *err)
+	}
+}
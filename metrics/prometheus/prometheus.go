@@ -0,0 +1,190 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package prometheus exposes the operations of a LedgerAvalanche as Prometheus metrics. It
+// writes the Prometheus text format itself, so it adds no dependency:
+//
+//	metrics := prometheus.New("avalanche_ledger")
+//	ledger, err := avax.FindLedgerAvalancheApp(avax.WithMetrics(metrics))
+//	http.Handle("/metrics", metrics)
+package prometheus
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	avax "github.com/zondax/ledger-avalanche-go"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the duration histogram. Signing includes
+// the time the user takes to review the transaction, hence the long tail.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Results of an operation, the result label of the operations counter
+const (
+	ResultSuccess  = "success"
+	ResultRejected = "rejected"
+	ResultTimeout  = "timeout"
+	ResultError    = "error"
+)
+
+// Metrics counts the operations by result and records their duration. It implements
+// avax.Metrics and serves the metrics over HTTP.
+type Metrics struct {
+	namespace string
+	buckets   []float64
+
+	mu         sync.Mutex
+	operations map[string]*operationStats
+}
+
+var _ avax.Metrics = (*Metrics)(nil)
+
+type operationStats struct {
+	results map[string]uint64
+	// bucketCounts holds the observations of each bucket, not cumulated
+	bucketCounts []uint64
+	count        uint64
+	sum          float64
+}
+
+// New returns the metrics named <namespace>_operations_total and
+// <namespace>_operation_duration_seconds, using DefaultBuckets
+func New(namespace string) *Metrics {
+	return NewWithBuckets(namespace, DefaultBuckets)
+}
+
+// NewWithBuckets works as New with the given histogram upper bounds, in increasing order
+func NewWithBuckets(namespace string, buckets []float64) *Metrics {
+	return &Metrics{
+		namespace:  namespace,
+		buckets:    append([]float64{}, buckets...),
+		operations: map[string]*operationStats{},
+	}
+}
+
+// Result classifies the error of an operation, distinguishing the user refusing it or not
+// answering in time from device failures
+func Result(err error) string {
+	switch {
+	case err == nil:
+		return ResultSuccess
+	case errors.Is(err, avax.ErrUserRejected), errors.Is(err, avax.ErrBlindSigningDisabled):
+		return ResultRejected
+	case errors.Is(err, avax.ErrConfirmationTimeout), errors.Is(err, avax.ErrExchangeTimeout),
+		errors.Is(err, context.DeadlineExceeded):
+		return ResultTimeout
+	}
+	return ResultError
+}
+
+// OnOperation records an operation, see avax.Metrics
+func (m *Metrics) OnOperation(name string, dur time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats, ok := m.operations[name]
+	if !ok {
+		stats = &operationStats{results: map[string]uint64{}, bucketCounts: make([]uint64, len(m.buckets))}
+		m.operations[name] = stats
+	}
+
+	stats.results[Result(err)]++
+	seconds := dur.Seconds()
+	stats.count++
+	stats.sum += seconds
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			stats.bucketCounts[i]++
+			break
+		}
+	}
+}
+
+// WriteTo writes the metrics in the Prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.operations))
+	for name := range m.operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := &countingWriter{w: bufio.NewWriter(w)}
+	total := m.namespace + "_operations_total"
+	fmt.Fprintf(out, "# HELP %s Operations with the Ledger device, by result.\n", total)
+	fmt.Fprintf(out, "# TYPE %s counter\n", total)
+	for _, name := range names {
+		results := m.operations[name].results
+		for _, result := range []string{ResultSuccess, ResultRejected, ResultTimeout, ResultError} {
+			if count, ok := results[result]; ok {
+				fmt.Fprintf(out, "%s{operation=%q,result=%q} %d\n", total, name, result, count)
+			}
+		}
+	}
+
+	duration := m.namespace + "_operation_duration_seconds"
+	fmt.Fprintf(out, "# HELP %s Duration of the operations with the Ledger device.\n", duration)
+	fmt.Fprintf(out, "# TYPE %s histogram\n", duration)
+	for _, name := range names {
+		stats := m.operations[name]
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += stats.bucketCounts[i]
+			fmt.Fprintf(out, "%s_bucket{operation=%q,le=%q} %d\n", duration, name, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(out, "%s_bucket{operation=%q,le=\"+Inf\"} %d\n", duration, name, stats.count)
+		fmt.Fprintf(out, "%s_sum{operation=%q} %s\n", duration, name, formatFloat(stats.sum))
+		fmt.Fprintf(out, "%s_count{operation=%q} %d\n", duration, name, stats.count)
+	}
+
+	if err := out.w.Flush(); err != nil {
+		return out.n, err
+	}
+	return out.n, nil
+}
+
+// ServeHTTP serves the metrics to a Prometheus scraper
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter counts the bytes written for WriteTo
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,81 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package prometheus
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	avax "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/mock"
+)
+
+func Test_Result(t *testing.T) {
+	assert.Equal(t, ResultSuccess, Result(nil))
+	assert.Equal(t, ResultRejected, Result(fmt.Errorf("signing: %w", avax.ErrUserRejected)))
+	assert.Equal(t, ResultTimeout, Result(avax.ErrConfirmationTimeout))
+	assert.Equal(t, ResultError, Result(errors.New("hidapi: failed to write to device")))
+}
+
+func Test_WriteTo(t *testing.T) {
+	metrics := NewWithBuckets("avax", []float64{0.5, 2})
+	metrics.OnOperation(avax.OperationSign, 100*time.Millisecond, nil)
+	metrics.OnOperation(avax.OperationSign, time.Second, avax.ErrUserRejected)
+	metrics.OnOperation(avax.OperationSign, 3*time.Second, nil)
+
+	var out strings.Builder
+	n, err := metrics.WriteTo(&out)
+	require.NoError(t, err)
+	assert.Equal(t, int64(out.Len()), n)
+	assert.Equal(t, `# HELP avax_operations_total Operations with the Ledger device, by result.
+# TYPE avax_operations_total counter
+avax_operations_total{operation="sign",result="success"} 2
+avax_operations_total{operation="sign",result="rejected"} 1
+# HELP avax_operation_duration_seconds Duration of the operations with the Ledger device.
+# TYPE avax_operation_duration_seconds histogram
+avax_operation_duration_seconds_bucket{operation="sign",le="0.5"} 1
+avax_operation_duration_seconds_bucket{operation="sign",le="2"} 2
+avax_operation_duration_seconds_bucket{operation="sign",le="+Inf"} 3
+avax_operation_duration_seconds_sum{operation="sign"} 4.1
+avax_operation_duration_seconds_count{operation="sign"} 3
+`, out.String())
+}
+
+func Test_ServeHTTP(t *testing.T) {
+	metrics := New("avalanche_ledger")
+	ledger, err := mock.NewMockLedgerFromMnemonic("equip will roof matter pink blind book anxiety banner elbow sun young", avax.WithMetrics(metrics))
+	require.NoError(t, err)
+
+	_, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte("transaction"), nil)
+	require.NoError(t, err)
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, 32))
+	require.ErrorIs(t, err, avax.ErrBlindSigningDisabled)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body := recorder.Body.String()
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, `avalanche_ledger_operations_total{operation="sign",result="success"} 1`)
+	assert.Contains(t, body, `avalanche_ledger_operations_total{operation="sign_hash",result="rejected"} 1`)
+	assert.Contains(t, body, `avalanche_ledger_operation_duration_seconds_count{operation="sign"} 1`)
+}
@@ -0,0 +1,63 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type operation struct {
+	name string
+	err  error
+}
+
+// recordingMetrics records the operations reported
+type recordingMetrics struct {
+	mu         sync.Mutex
+	operations []operation
+}
+
+func (m *recordingMetrics) OnOperation(name string, dur time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.operations = append(m.operations, operation{name, err})
+}
+
+func Test_Metrics(t *testing.T) {
+	metrics := &recordingMetrics{}
+	ledger := newMockLedger(&mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[1] == INS_GET_VERSION {
+			return nil, statusError(DeviceLocked)
+		}
+		return []byte{}, nil
+	}}, WithMetrics(metrics))
+
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil)
+	require.NoError(t, err)
+	_, err = ledger.GetVersion()
+	require.Error(t, err)
+
+	require.Len(t, metrics.operations, 2)
+	assert.Equal(t, operation{OperationSign, nil}, metrics.operations[0])
+	assert.Equal(t, OperationGetVersion, metrics.operations[1].name)
+	assert.ErrorIs(t, metrics.operations[1].err, ErrLocked)
+}
@@ -0,0 +1,147 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"sync"
+	"time"
+)
+
+// Exchanger sends an APDU to the device and returns its response, as the Exchange method of
+// the transport does: without the status word, which is reported as an error
+type Exchanger func(command []byte) ([]byte, error)
+
+// Middleware wraps the exchanges with the transport, e.g. to log, retry or throttle them
+// without changing the operations built on them
+type Middleware func(next Exchanger) Exchanger
+
+// buildTransport wraps the exchanges with the device in the middlewares of the ledger, the
+// first one being the outermost, around the logging of SetAPDULogger. It is built once, so
+// middlewares may keep state across exchanges.
+func (ledger *LedgerAvalanche) buildTransport() Exchanger {
+	exchange := ledger.apduLogMiddleware(func(command []byte) ([]byte, error) {
+		// the device may change, e.g. on Reconnect
+		return ledger.api.Exchange(command)
+	})
+	for i := len(ledger.middlewares) - 1; i >= 0; i-- {
+		exchange = ledger.middlewares[i](exchange)
+	}
+	return exchange
+}
+
+// apduLogMiddleware passes every APDU exchanged to the logger of SetAPDULogger, read on each
+// exchange as it may change. Being the innermost, it logs each attempt of RetryMiddleware.
+func (ledger *LedgerAvalanche) apduLogMiddleware(next Exchanger) Exchanger {
+	return func(command []byte) ([]byte, error) {
+		response, err := next(command)
+		if logger := ledger.currentAPDULogger(); logger != nil {
+			logAPDUs(logger, command, response, err)
+		}
+		return response, err
+	}
+}
+
+// LoggingMiddleware passes every APDU exchanged to logger, see APDULogger
+func LoggingMiddleware(logger APDULogger) Middleware {
+	return func(next Exchanger) Exchanger {
+		return func(command []byte) ([]byte, error) {
+			response, err := next(command)
+			logAPDUs(logger, command, response, err)
+			return response, err
+		}
+	}
+}
+
+// isIdempotent reports whether command may be sent again without effect on the app: the
+// version, wallet ID and public key requests that show nothing on the device. A repeated upload
+// chunk or signature request would corrupt the signing flow, or sign again.
+func isIdempotent(command []byte) bool {
+	if len(command) < 4 || command[0] != CLA {
+		return false
+	}
+	switch command[1] {
+	case INS_GET_VERSION, INS_WALLET_ID:
+		return true
+	case INS_GET_ADDR, INS_GET_EXTENDED_PUBLIC_KEY:
+		return command[2] == P1_ONLY_RETRIEVE
+	}
+	return false
+}
+
+// RetryMiddleware makes up to attempts tries of an exchange failing in the transport, waiting
+// backoff between them. Only idempotent commands are retried, e.g. GetVersion or GetPubKey
+// without showing the address: signing commands fail on the first transport error, as
+// AutoReconnect does with ErrSignNotRetried. Exchanges answered with an error status word are
+// not retried.
+func RetryMiddleware(attempts int, backoff time.Duration) Middleware {
+	return func(next Exchanger) Exchanger {
+		return func(command []byte) (response []byte, err error) {
+			if !isIdempotent(command) {
+				return next(command)
+			}
+			for attempt := 0; attempt < attempts || attempt == 0; attempt++ {
+				if attempt > 0 && backoff > 0 {
+					time.Sleep(backoff)
+				}
+				if response, err = next(command); err == nil {
+					return response, nil
+				}
+				if _, isStatus := parseStatusWord(err); isStatus {
+					return response, err
+				}
+			}
+			return response, err
+		}
+	}
+}
+
+// RateLimitMiddleware spaces the exchanges by at least interval
+func RateLimitMiddleware(interval time.Duration) Middleware {
+	return func(next Exchanger) Exchanger {
+		var mu sync.Mutex
+		var last time.Time
+		return func(command []byte) ([]byte, error) {
+			mu.Lock()
+			if wait := interval - time.Since(last); !last.IsZero() && wait > 0 {
+				time.Sleep(wait)
+			}
+			last = time.Now()
+			mu.Unlock()
+			return next(command)
+		}
+	}
+}
+
+// MetricsMiddleware reports every exchange to metrics as OperationExchange, error status words
+// being reported as *APDUError
+func MetricsMiddleware(metrics Metrics) Middleware {
+	return func(next Exchanger) Exchanger {
+		return func(command []byte) ([]byte, error) {
+			start := time.Now()
+			response, err := next(command)
+
+			reported := err
+			if err != nil {
+				if code, ok := parseStatusWord(err); ok {
+					reported = &APDUError{Code: code}
+				}
+			}
+			metrics.OnOperation(OperationExchange, time.Since(start), reported)
+			return response, err
+		}
+	}
+}
@@ -0,0 +1,164 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WithMiddlewareOrder(t *testing.T) {
+	var calls []string
+	named := func(name string) Middleware {
+		return func(next Exchanger) Exchanger {
+			return func(command []byte) ([]byte, error) {
+				calls = append(calls, name)
+				return next(command)
+			}
+		}
+	}
+	ledger := newMockLedger(&mockDevice{handler: replies([]byte{0, 1, 2, 3})}, WithMiddleware(named("outer"), named("inner")))
+
+	_, err := ledger.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer", "inner"}, calls)
+}
+
+func Test_LoggingMiddleware(t *testing.T) {
+	var trace bytes.Buffer
+	ledger := newMockLedger(&mockDevice{handler: replies([]byte{0, 1, 2, 3})},
+		WithMiddleware(LoggingMiddleware(NewAPDUTraceLogger(&trace, false))))
+
+	_, err := ledger.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "=> 8000000000\n<= 000102039000\n", trace.String())
+}
+
+func Test_RetryMiddleware(t *testing.T) {
+	attempts := 0
+	device := &mockDevice{handler: func([]byte) ([]byte, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, errors.New("hidapi: read error")
+		}
+		return []byte{0, 1, 2, 3}, nil
+	}}
+
+	_, err := newMockLedger(device, WithMiddleware(RetryMiddleware(3, time.Millisecond))).GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	_, err = newMockLedger(device, WithMiddleware(RetryMiddleware(2, 0))).GetVersion()
+	assert.ErrorIs(t, err, ErrDeviceDisconnected)
+	assert.Equal(t, 2, attempts)
+
+	// the device answered: retrying would repeat the command
+	attempts = 0
+	rejecting := &mockDevice{handler: func([]byte) ([]byte, error) {
+		attempts++
+		return nil, statusError(TransactionRejected)
+	}}
+	_, err = newMockLedger(rejecting, WithMiddleware(RetryMiddleware(3, 0))).GetVersion()
+	assert.ErrorIs(t, err, ErrUserRejected)
+	assert.Equal(t, 1, attempts)
+}
+
+func Test_RetryMiddlewareIdempotentOnly(t *testing.T) {
+	failing := &mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, errors.New("hidapi: read error")
+	}}
+	ledger := newMockLedger(failing, WithMiddleware(RetryMiddleware(3, 0)), AllowBlindSigning(true))
+
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	assert.ErrorIs(t, err, ErrDeviceDisconnected)
+	assert.Len(t, failing.sent, 3)
+
+	// showing the address asks the user again
+	failing.sent = nil
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", true, "", "")
+	assert.ErrorIs(t, err, ErrDeviceDisconnected)
+	assert.Len(t, failing.sent, 1)
+
+	failing.sent = nil
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, ErrDeviceDisconnected)
+	assert.Len(t, failing.sent, 1)
+
+	for _, command := range [][]byte{
+		{CLA, INS_SIGN, PAYLOAD_ADD, 0, 0},
+		{CLA, INS_SIGN_HASH, LAST_MESSAGE, 0, 0},
+		{CLA_ETH, INS_GET_VERSION, 0, 0, 0},
+	} {
+		assert.False(t, isIdempotent(command), command)
+	}
+}
+
+func Test_APDULoggerLogsEachAttempt(t *testing.T) {
+	var trace bytes.Buffer
+	attempts := 0
+	device := &mockDevice{handler: func([]byte) ([]byte, error) {
+		attempts++
+		if attempts < 2 {
+			return nil, errors.New("hidapi: read error")
+		}
+		return []byte{0, 1, 2, 3}, nil
+	}}
+	ledger := newMockLedger(device, WithMiddleware(RetryMiddleware(2, 0)), WithAPDULogger(NewAPDUTraceLogger(&trace, false)))
+
+	_, err := ledger.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "=> 8000000000\n=> 8000000000\n<= 000102039000\n", trace.String())
+}
+
+func Test_RateLimitMiddleware(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{}, WithMiddleware(RateLimitMiddleware(20*time.Millisecond)))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, _ = ledger.GetVersion()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func Test_MetricsMiddleware(t *testing.T) {
+	metrics := &recordingMetrics{}
+	responses := replies([]byte{0, 1, 2, 3})
+	calls := 0
+	device := &mockDevice{handler: func(command []byte) ([]byte, error) {
+		calls++
+		if calls == 2 {
+			return nil, statusError(TransactionRejected)
+		}
+		return responses(command)
+	}}
+	ledger := newMockLedger(device, WithMiddleware(MetricsMiddleware(metrics)))
+
+	_, err := ledger.GetVersion()
+	require.NoError(t, err)
+	_, err = ledger.GetVersion()
+	require.Error(t, err)
+
+	require.Len(t, metrics.operations, 2)
+	assert.Equal(t, operation{OperationExchange, nil}, metrics.operations[0])
+	assert.ErrorIs(t, metrics.operations[1].err, ErrUserRejected)
+}
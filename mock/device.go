@@ -0,0 +1,230 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package mock
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	avax "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-go"
+)
+
+// Device emulates the Avalanche app running on a Ledger device holding a given seed.
+// It answers the same APDUs as the app, so it can be used wherever a ledger_go.LedgerDevice is expected.
+// Every review is approved unless RejectReviews is set. EVM instructions are not supported.
+type Device struct {
+	// Version is reported by GET_VERSION
+	Version avax.VersionInfo
+	// RejectReviews makes the emulated user reject every address and transaction review
+	RejectReviews bool
+
+	mu      sync.Mutex
+	master  *extendedKey
+	ins     byte
+	prefix  string
+	payload []byte
+	hash    []byte
+	closed  bool
+}
+
+// NewDevice returns an emulated device holding the BIP-32 master key of seed
+func NewDevice(seed []byte) (*Device, error) {
+	master, err := masterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &Device{Version: avax.VersionInfo{Major: 0, Minor: 6, Patch: 5}, master: master}, nil
+}
+
+// statusError mimics the error ledger-go returns for a status word other than 0x9000
+func statusError(code avax.LedgerError) error {
+	return errors.New(ledger_go.ErrorMessage(uint16(code)))
+}
+
+func (d *Device) Exchange(command []byte) ([]byte, error) {
+	if len(command) < 5 {
+		return nil, errors.New("APDU commands should not be smaller than 5")
+	}
+	if int(command[4]) != len(command)-5 {
+		return nil, errors.New("APDU[data length] mismatch")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil, errors.New("device closed")
+	}
+
+	cla, ins, p1, data := command[0], command[1], command[2], command[5:]
+	switch {
+	case cla == avax.CLA_BOLOS && ins == avax.INS_GET_APP_INFO:
+		return d.appInfo(), nil
+	case cla != avax.CLA:
+		return nil, statusError(avax.ClaNotSupported)
+	}
+
+	switch ins {
+	case avax.INS_GET_VERSION:
+		return []byte{d.Version.AppMode, d.Version.Major, d.Version.Minor, d.Version.Patch, 0}, nil
+	case avax.INS_WALLET_ID:
+		walletID := sha256.Sum256(d.master.privateKey().PubKey().SerializeCompressed())
+		return walletID[:6], nil
+	case avax.INS_GET_ADDR, avax.INS_GET_EXTENDED_PUBLIC_KEY:
+		return d.getAddress(ins, p1, data)
+	case avax.INS_SIGN, avax.INS_SIGN_MSG:
+		return d.upload(ins, p1, data)
+	case avax.INS_SIGN_HASH:
+		return d.signHash(p1, data)
+	default:
+		return nil, statusError(avax.InstructionNotSupported)
+	}
+}
+
+func (d *Device) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	return nil
+}
+
+func (d *Device) appInfo() []byte {
+	name, version := "Avalanche", d.Version.String()
+	response := append([]byte{1, byte(len(name))}, name...)
+	response = append(append(response, byte(len(version))), version...)
+	return append(response, 1, 0)
+}
+
+// getAddress answers [hrp | chainID | path] with [publicKeyLen | publicKey | hash],
+// or [publicKeyLen | publicKey | chainCode] for the extended public key
+func (d *Device) getAddress(ins, p1 byte, data []byte) ([]byte, error) {
+	for i := 0; i < 2; i++ {
+		if len(data) < 1+int(data[0]) {
+			return nil, statusError(avax.DataIsInvalid)
+		}
+		data = data[1+int(data[0]):]
+	}
+	path, _, err := parsePath(data)
+	if err != nil {
+		return nil, statusError(avax.DataIsInvalid)
+	}
+
+	if p1 == avax.P1_SHOW_ADDRESS_IN_DEVICE && d.RejectReviews {
+		return nil, statusError(avax.TransactionRejected)
+	}
+
+	key, err := d.master.derive(path)
+	if err != nil {
+		return nil, statusError(avax.ErrorDerivingKeys)
+	}
+	publicKey := key.privateKey().PubKey().SerializeCompressed()
+
+	response := append([]byte{byte(len(publicKey))}, publicKey...)
+	if ins == avax.INS_GET_EXTENDED_PUBLIC_KEY {
+		return append(response, key.chainCode...), nil
+	}
+	return append(response, avax.AddressHash(publicKey)...), nil
+}
+
+// upload receives a transaction or message in chunks and, once it is complete, keeps
+// the hash to sign until the signatures are collected
+func (d *Device) upload(ins, p1 byte, data []byte) ([]byte, error) {
+	switch p1 {
+	case avax.PAYLOAD_INIT:
+		prefix, _, err := parsePath(data)
+		if err != nil {
+			return nil, statusError(avax.DataIsInvalid)
+		}
+		d.ins, d.prefix, d.payload, d.hash = ins, prefix, nil, nil
+		return []byte{}, nil
+	case avax.PAYLOAD_ADD, avax.PAYLOAD_LAST:
+		if d.ins != ins || d.prefix == "" {
+			return nil, statusError(avax.ConditionsNotSatisfied)
+		}
+		d.payload = append(d.payload, data...)
+	default:
+		return nil, statusError(avax.InvalidP1P2)
+	}
+
+	if p1 == avax.PAYLOAD_ADD {
+		return []byte{}, nil
+	}
+	if d.RejectReviews {
+		d.prefix = ""
+		return nil, statusError(avax.TransactionRejected)
+	}
+
+	if ins == avax.INS_SIGN_MSG {
+		d.hash = avax.AvalancheMessageHash(d.payload)
+		return []byte{}, nil
+	}
+
+	// The change path header [count | suffix...] goes before the transaction
+	headerLen := 1
+	if len(d.payload) > 0 {
+		headerLen += int(d.payload[0]) * 9
+	}
+	if len(d.payload) <= headerLen {
+		d.prefix = ""
+		return []byte("Unexpected buffer end"), statusError(avax.DataIsInvalid)
+	}
+	hash := sha256.Sum256(d.payload[headerLen:])
+	d.hash = hash[:]
+	return []byte{}, nil
+}
+
+// signHash either receives [prefix | hash] to sign or signs the held hash with the key at a path suffix
+func (d *Device) signHash(p1 byte, data []byte) ([]byte, error) {
+	if p1 == avax.FIRST_MESSAGE {
+		prefix, hash, err := parsePath(data)
+		if err != nil || len(hash) != avax.HASH_LEN {
+			return nil, statusError(avax.DataIsInvalid)
+		}
+		if d.RejectReviews {
+			return nil, statusError(avax.TransactionRejected)
+		}
+		d.prefix, d.hash = prefix, append([]byte{}, hash...)
+		return []byte{}, nil
+	}
+
+	if d.hash == nil {
+		return nil, statusError(avax.ConditionsNotSatisfied)
+	}
+	suffix, _, err := parsePath(data)
+	if err != nil {
+		return nil, statusError(avax.DataIsInvalid)
+	}
+	key, err := d.master.derive(d.prefix + strings.TrimPrefix(suffix, "m"))
+	if err != nil {
+		return nil, statusError(avax.ErrorDerivingKeys)
+	}
+
+	// [recovery | r | s] to [r | s | recovery id]
+	compact, err := ecdsa.SignCompact(key.privateKey(), d.hash, true)
+	if err != nil {
+		return nil, statusError(avax.SignVerifyError)
+	}
+	signature := append(compact[1:], compact[0]-27-4)
+
+	if p1 == avax.LAST_MESSAGE {
+		d.prefix, d.hash = "", nil
+	}
+	return signature, nil
+}
@@ -0,0 +1,125 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package mock
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	avax "github.com/zondax/ledger-avalanche-go"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const hardened = 0x80000000
+
+// extendedKey is a BIP-32 extended private key
+type extendedKey struct {
+	key       btcec.ModNScalar
+	chainCode []byte
+}
+
+// SeedFromMnemonic returns the BIP-39 seed of a mnemonic, without passphrase
+func SeedFromMnemonic(mnemonic string) []byte {
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"), 2048, 64, sha512.New)
+}
+
+func masterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	return newExtendedKey(mac.Sum(nil))
+}
+
+func newExtendedKey(i []byte) (*extendedKey, error) {
+	var key btcec.ModNScalar
+	if key.SetByteSlice(i[:32]) || key.IsZero() {
+		return nil, errors.New("invalid derived key")
+	}
+	return &extendedKey{key: key, chainCode: i[32:]}, nil
+}
+
+func (k *extendedKey) privateKey() *btcec.PrivateKey {
+	return &btcec.PrivateKey{Key: k.key}
+}
+
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= hardened {
+		keyBytes := k.key.Bytes()
+		data = append(append(data, 0), keyBytes[:]...)
+	} else {
+		data = append(data, k.privateKey().PubKey().SerializeCompressed()...)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	i := mac.Sum(nil)
+
+	var tweak btcec.ModNScalar
+	if tweak.SetByteSlice(i[:32]) {
+		return nil, errors.New("invalid derived key")
+	}
+	tweak.Add(&k.key)
+	tweakBytes := tweak.Bytes()
+	return newExtendedKey(append(tweakBytes[:], i[32:]...))
+}
+
+// derive returns the key at path (e.g "m/44'/9000'/0'/0/0")
+func (k *extendedKey) derive(path string) (*extendedKey, error) {
+	elements := strings.Split(path, "/")
+	if elements[0] != "m" {
+		return nil, errors.New(`path should start with "m"`)
+	}
+
+	key := k
+	for _, element := range elements[1:] {
+		var index uint32
+		if strings.HasSuffix(element, "'") {
+			index = hardened
+			element = element[:len(element)-1]
+		}
+		child, err := strconv.ParseUint(element, 10, 31)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err = key.child(index + uint32(child))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// parsePath decodes a path serialized as [count | child (uint32 big-endian)...]
+func parsePath(data []byte) (string, []byte, error) {
+	if len(data) < 1 || len(data) < 1+4*int(data[0]) {
+		return "", nil, errors.New("invalid path")
+	}
+
+	count := int(data[0])
+	components := make([]uint32, count)
+	for i := range components {
+		components[i] = binary.BigEndian.Uint32(data[1+4*i:])
+	}
+	return avax.FormatPath(components), data[1+4*count:], nil
+}
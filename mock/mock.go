@@ -0,0 +1,48 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package mock provides an in-memory Avalanche app, driven by a deterministic seed, for testing
+// code built on ledger_avalanche_go without a physical device or an emulator.
+package mock
+
+import (
+	avax "github.com/zondax/ledger-avalanche-go"
+)
+
+// MockLedger is a LedgerAvalanche connected to an emulated device, so it offers the same methods
+type MockLedger struct {
+	*avax.LedgerAvalanche
+	Device *Device
+}
+
+var _ avax.AvalancheSigner = (*MockLedger)(nil)
+
+// NewMockLedger returns a MockLedger whose keys are derived from seed as the device would
+func NewMockLedger(seed []byte, opts ...avax.Option) (*MockLedger, error) {
+	device, err := NewDevice(seed)
+	if err != nil {
+		return nil, err
+	}
+	return &MockLedger{
+		LedgerAvalanche: avax.NewLedgerAvalanche(device, opts...),
+		Device:          device,
+	}, nil
+}
+
+// NewMockLedgerFromMnemonic returns a MockLedger holding the seed of a BIP-39 mnemonic
+func NewMockLedgerFromMnemonic(mnemonic string, opts ...avax.Option) (*MockLedger, error) {
+	return NewMockLedger(SeedFromMnemonic(mnemonic), opts...)
+}
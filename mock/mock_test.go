@@ -0,0 +1,197 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package mock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	avax "github.com/zondax/ledger-avalanche-go"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// Ledger Test Mnemonic
+const testMnemonic = "equip will roof matter pink blind book anxiety banner elbow sun young"
+
+func newTestLedger(t *testing.T, opts ...avax.Option) *MockLedger {
+	ledger, err := NewMockLedgerFromMnemonic(testMnemonic, opts...)
+	require.NoError(t, err)
+	return ledger
+}
+
+func Test_GetPubKey(t *testing.T) {
+	ledger := newTestLedger(t)
+
+	publicKey, hash, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, "02c6f477ff8e7136de982f898f6bfe93136bbe8dada6c17d0cd369acce90036ac4", hex.EncodeToString(publicKey))
+
+	// the address hash is ripemd160(sha256(publicKey))
+	sha := sha256.Sum256(publicKey)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	assert.Equal(t, h.Sum(nil), hash)
+}
+
+func Test_DeriveAddresses(t *testing.T) {
+	ledger := newTestLedger(t)
+
+	xpub, err := ledger.GetExtendedPubKey("m/44'/9000'/0'/0", "", "")
+	require.NoError(t, err)
+
+	addresses, err := avax.DeriveAddresses(xpub, "", 0, 3)
+	require.NoError(t, err)
+	for i, address := range addresses {
+		publicKey, hash, err := ledger.GetPubKey(address.Path, false, "", "")
+		require.NoError(t, err)
+		assert.Equal(t, publicKey, address.PublicKey, "index %d", i)
+		assert.Equal(t, hash, address.Hash, "index %d", i)
+	}
+}
+
+func Test_SeedFromMnemonic(t *testing.T) {
+	// BIP-39 test vector, without passphrase
+	seed := SeedFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	assert.Equal(t,
+		"5eb00bbddcf069084889a8ab9155568165f5c453ccb85e70811aaed6f6da5fc19a5ac40b389cd370d086206dec8aa6c43daea6690f20ad3d8d48b2d2ce9e38e4",
+		hex.EncodeToString(seed))
+}
+
+func Test_BIP32(t *testing.T) {
+	// BIP-32 test vector 1
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := masterKey(seed)
+	require.NoError(t, err)
+
+	key, err := master.derive("m/0'/1/2'/2/1000000000")
+	require.NoError(t, err)
+	assert.Equal(t,
+		"022a471424da5e657499d1ff51cb43c47481a03b1e77f951fe64cec9f5a48f7011",
+		hex.EncodeToString(key.privateKey().PubKey().SerializeCompressed()))
+}
+
+func Test_Sign(t *testing.T) {
+	ledger := newTestLedger(t)
+	tx := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x22}
+
+	response, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, tx, []string{"1/0"})
+	require.NoError(t, err)
+
+	hash, _ := avax.ComputeSignHash(tx)
+	assert.Equal(t, hash, response.Hash)
+	for _, signature := range response.SignaturesOrdered {
+		publicKey, _, err := ledger.GetPubKey("m/44'/9000'/0'/"+signature.Path, false, "", "")
+		require.NoError(t, err)
+		assert.True(t, avax.VerifySignature(publicKey, hash, signature.Signature[:64]), signature.Path)
+	}
+}
+
+func Test_SignHashAndMessage(t *testing.T) {
+	ledger := newTestLedger(t, avax.AllowBlindSigning(true))
+	publicKey, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	require.NoError(t, err)
+
+	hash := make([]byte, avax.HASH_LEN)
+	response, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, hash)
+	require.NoError(t, err)
+	assert.True(t, avax.VerifySignature(publicKey, hash, response.Signature["0/0"][:64]))
+
+	message := []byte("Hello Avalanche!")
+	response, err = ledger.SignMessage("m/44'/9000'/0'/0/0", message)
+	require.NoError(t, err)
+	assert.True(t, avax.VerifySignature(publicKey, avax.AvalancheMessageHash(message), response.Signature["0/0"][:64]))
+}
+
+func Test_SignatureVerification(t *testing.T) {
+	ledger, err := NewMockLedgerFromMnemonic(testMnemonic, avax.WithSignatureVerification(), avax.AllowBlindSigning(true))
+	require.NoError(t, err)
+
+	tx := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x22}
+	_, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, tx, nil)
+	require.NoError(t, err)
+
+	_, err = ledger.SignMessage("m/44'/9000'/0'/0/0", []byte("Hello Avalanche!"))
+	require.NoError(t, err)
+
+	response, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, avax.HASH_LEN))
+	require.NoError(t, err)
+
+	publicKey, err := avax.RecoverPublicKey(response.Signature["0/0"], response.Hash)
+	require.NoError(t, err)
+	expected, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, expected, publicKey.SerializeCompressed())
+}
+
+func Test_RejectReviews(t *testing.T) {
+	ledger := newTestLedger(t)
+	ledger.Device.RejectReviews = true
+
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte{0x01}, nil)
+	assert.Error(t, err)
+
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", true, "", "")
+	var apduErr *avax.APDUError
+	require.ErrorAs(t, err, &apduErr)
+	assert.Equal(t, avax.TransactionRejected, apduErr.Code)
+}
+
+func Test_AvalancheSigner(t *testing.T) {
+	var signer avax.AvalancheSigner = newTestLedger(t, avax.AllowBlindSigning(true))
+	defer signer.Close()
+
+	publicKey, _, err := signer.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	require.NoError(t, err)
+
+	hash := make([]byte, avax.HASH_LEN)
+	response, err := signer.SignHash("m/44'/9000'/0'", []string{"0/0"}, hash)
+	require.NoError(t, err)
+	assert.True(t, avax.VerifySignature(publicKey, hash, response.Signature["0/0"][:64]))
+}
+
+func Test_VerifyWalletID(t *testing.T) {
+	ledger := newTestLedger(t)
+	walletID, err := ledger.GetWalletID()
+	require.NoError(t, err)
+	require.NoError(t, ledger.VerifyWalletID(walletID))
+
+	// another seed is plugged in
+	other, err := NewMockLedger(SeedFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, other.VerifyWalletID(walletID), avax.ErrWalletIDMismatch)
+}
+
+func Test_SignAvalancheMessage(t *testing.T) {
+	ledger := newTestLedger(t)
+	message := "Sign in to example.com, nonce 42"
+
+	response, err := ledger.SignAvalancheMessage("m/44'/9000'/0'", []string{"0/0", "0/1"}, message)
+	require.NoError(t, err)
+	assert.Equal(t, avax.AvalancheMessageHash([]byte(message)), response.Hash)
+
+	for _, signature := range response.SignaturesOrdered {
+		publicKey, _, err := ledger.GetPubKey("m/44'/9000'/0'/"+signature.Path, false, "", "")
+		require.NoError(t, err)
+		assert.True(t, avax.VerifySignature(publicKey, response.Hash, signature.Signature[:64]), signature.Path)
+	}
+
+	_, err = ledger.SignAvalancheMessage("m/44'/9000'/0'", nil, message)
+	assert.ErrorIs(t, err, avax.ErrNoSigningPaths)
+}
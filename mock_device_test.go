@@ -0,0 +1,70 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/zondax/ledger-go"
+)
+
+// mockDevice records every APDU it receives and answers through handler
+type mockDevice struct {
+	mu      sync.Mutex
+	sent    [][]byte
+	handler func(apdu []byte) ([]byte, error)
+	closed  bool
+}
+
+func (d *mockDevice) Exchange(command []byte) ([]byte, error) {
+	d.mu.Lock()
+	d.sent = append(d.sent, append([]byte{}, command...))
+	d.mu.Unlock()
+	if d.handler == nil {
+		return []byte{}, nil
+	}
+	return d.handler(command)
+}
+
+func (d *mockDevice) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	return nil
+}
+
+// replies answers each APDU with the next canned response, and with an empty payload once exhausted
+func replies(responses ...[]byte) func([]byte) ([]byte, error) {
+	idx := 0
+	return func([]byte) ([]byte, error) {
+		if idx >= len(responses) {
+			return []byte{}, nil
+		}
+		idx++
+		return responses[idx-1], nil
+	}
+}
+
+// statusError mimics the error ledger-go returns for a status word other than 0x9000
+func statusError(code LedgerError) error {
+	return errors.New(ledger_go.ErrorMessage(uint16(code)))
+}
+
+func newMockLedger(device *mockDevice, opts ...Option) *LedgerAvalanche {
+	return newLedgerAvalanche(device, opts...)
+}
@@ -0,0 +1,126 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownUTXOAddress is returned when the owner of a UTXO is not mapped to a derivation path
+var ErrUnknownUTXOAddress = errors.New("UTXO address has no known derivation path")
+
+// ErrChangePathWithoutAccount is returned by SignMultiAccount for a change path under an account
+// that signs no input, which the device would show as an external output
+var ErrChangePathWithoutAccount = errors.New("change path under an account without signing paths")
+
+// OwnedUTXO is a UTXO annotated with the address that owns it
+type OwnedUTXO struct {
+	ID      string
+	Address string
+}
+
+// ResolveSigningPaths maps each UTXO to the full derivation path of its owner using
+// addressPaths (address -> path, e.g as discovered with ScanAccount). The paths are returned
+// in the order of utxos.
+func ResolveSigningPaths(utxos []OwnedUTXO, addressPaths map[string]string) ([]string, error) {
+	paths := make([]string, 0, len(utxos))
+	for _, utxo := range utxos {
+		path, ok := addressPaths[utxo.Address]
+		if !ok {
+			return nil, fmt.Errorf("%w: UTXO %s owned by %s", ErrUnknownUTXOAddress, utxo.ID, utxo.Address)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// SignUTXOs signs a transaction spending utxos, which may belong to different accounts,
+// resolving their signing paths with addressPaths. See SignMultiAccount.
+func (ledger *LedgerAvalanche) SignUTXOs(message []byte, utxos []OwnedUTXO, addressPaths map[string]string, changePaths []string) (*ResponseSign, error) {
+	signingPaths, err := ResolveSigningPaths(utxos, addressPaths)
+	if err != nil {
+		return nil, err
+	}
+	return ledger.SignMultiAccount(signingPaths, message, changePaths)
+}
+
+// SignMultiAccount signs a transaction whose inputs belong to several accounts. signingPaths and
+// changePaths are full paths (e.g "m/44'/9000'/1'/0/3"); they are grouped by account prefix and
+// the transaction is signed once per account, so the user approves it once per account.
+// Every change path must belong to an account with signing paths, see
+// ErrChangePathWithoutAccount. The signatures are keyed by full path.
+func (ledger *LedgerAvalanche) SignMultiAccount(signingPaths []string, message []byte, changePaths []string) (*ResponseSign, error) {
+	signingPaths = RemoveDuplicates(signingPaths)
+	if len(signingPaths) == 0 {
+		return nil, ErrNoSigningPaths
+	}
+
+	prefixes, signingSuffixes, err := groupByPrefix(signingPaths)
+	if err != nil {
+		return nil, err
+	}
+	changePrefixes, changeSuffixes, err := groupByPrefix(changePaths)
+	if err != nil {
+		return nil, err
+	}
+	for _, prefix := range changePrefixes {
+		if _, ok := signingSuffixes[prefix]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrChangePathWithoutAccount, prefix+"/"+changeSuffixes[prefix][0])
+		}
+	}
+
+	signatures := make(map[string][]byte)
+	byPath := make(map[string]PathSignature)
+	var hash []byte
+	for _, prefix := range prefixes {
+		response, err := ledger.Sign(prefix, signingSuffixes[prefix], message, changeSuffixes[prefix])
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", prefix, err)
+		}
+		for _, signature := range response.SignaturesOrdered {
+			signature.Path = prefix + "/" + signature.Path
+			signatures[signature.Path] = signature.Signature
+			byPath[signature.Path] = signature
+		}
+		hash = response.Hash
+	}
+
+	ordered := make([]PathSignature, 0, len(signingPaths))
+	for _, path := range signingPaths {
+		ordered = append(ordered, byPath[path])
+	}
+
+	return &ResponseSign{Hash: hash, Signature: signatures, SignaturesOrdered: ordered}, nil
+}
+
+// groupByPrefix groups full paths by account prefix, keeping the order in which prefixes appear
+func groupByPrefix(paths []string) ([]string, map[string][]string, error) {
+	var prefixes []string
+	suffixes := make(map[string][]string)
+	for _, path := range paths {
+		prefix, suffix, err := SplitPath(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := suffixes[prefix]; !ok {
+			prefixes = append(prefixes, prefix)
+		}
+		suffixes[prefix] = append(suffixes[prefix], suffix)
+	}
+	return prefixes, suffixes, nil
+}
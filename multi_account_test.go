@@ -0,0 +1,120 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ResolveSigningPaths(t *testing.T) {
+	addressPaths := map[string]string{
+		"avax1first":  "m/44'/9000'/0'/0/0",
+		"avax1second": "m/44'/9000'/1'/0/4",
+	}
+	utxos := []OwnedUTXO{
+		{ID: "utxo0", Address: "avax1second"},
+		{ID: "utxo1", Address: "avax1first"},
+		{ID: "utxo2", Address: "avax1second"},
+	}
+
+	paths, err := ResolveSigningPaths(utxos, addressPaths)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m/44'/9000'/1'/0/4", "m/44'/9000'/0'/0/0", "m/44'/9000'/1'/0/4"}, paths)
+
+	_, err = ResolveSigningPaths(append(utxos, OwnedUTXO{ID: "utxo3", Address: "avax1unknown"}), addressPaths)
+	assert.ErrorIs(t, err, ErrUnknownUTXOAddress)
+}
+
+func Test_SignUTXOs(t *testing.T) {
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[1] == INS_SIGN_HASH {
+			// echo the path suffix so signatures can be told apart
+			return apdu[5:], nil
+		}
+		return []byte{}, nil
+	}}
+	ledger := newMockLedger(device)
+
+	addressPaths := map[string]string{
+		"avax1first":  "m/44'/9000'/0'/0/0",
+		"avax1second": "m/44'/9000'/1'/0/4",
+	}
+	utxos := []OwnedUTXO{{ID: "utxo0", Address: "avax1second"}, {ID: "utxo1", Address: "avax1first"}}
+
+	response, err := ledger.SignUTXOs([]byte{0x01}, utxos, addressPaths, nil)
+	require.NoError(t, err)
+
+	account1, _ := SerializePath("m/44'/9000'/1'")
+	account0, _ := SerializePath("m/44'/9000'/0'")
+	var initialized [][]byte
+	for _, apdu := range device.sent {
+		if apdu[1] == INS_SIGN && apdu[2] == PAYLOAD_INIT {
+			initialized = append(initialized, apdu[5:])
+		}
+	}
+	assert.Equal(t, [][]byte{account1, account0}, initialized)
+
+	suffix04, _ := SerializePathSuffix("0/4")
+	require.Len(t, response.SignaturesOrdered, 2)
+	assert.Equal(t, "m/44'/9000'/1'/0/4", response.SignaturesOrdered[0].Path)
+	assert.Equal(t, suffix04, response.Signature["m/44'/9000'/1'/0/4"])
+	assert.Contains(t, response.Signature, "m/44'/9000'/0'/0/0")
+}
+
+func Test_SignMultiAccount(t *testing.T) {
+	device := &mockDevice{}
+	ledger := newMockLedger(device)
+
+	signingPaths := []string{"m/44'/9000'/0'/0/1", "m/44'/9000'/1'/0/0", "m/44'/9000'/0'/0/1", "m/44'/9000'/0'/0/2"}
+	response, err := ledger.SignMultiAccount(signingPaths, []byte{0x01}, []string{"m/44'/9000'/1'/1/0"})
+	require.NoError(t, err)
+
+	// one round per account, the change path only going to its own account: the number of paths
+	// in the header preceding the transaction counts the signing and change paths of the account
+	var pathCounts []byte
+	signed := 0
+	for _, apdu := range device.sent {
+		if apdu[1] == INS_SIGN && apdu[2] == PAYLOAD_LAST {
+			pathCounts = append(pathCounts, apdu[5])
+		}
+		if apdu[1] == INS_SIGN_HASH {
+			signed++
+		}
+	}
+	assert.Equal(t, []byte{2, 2}, pathCounts)
+	assert.Equal(t, 3, signed)
+
+	require.Len(t, response.SignaturesOrdered, 3)
+	assert.Equal(t, "m/44'/9000'/0'/0/1", response.SignaturesOrdered[0].Path)
+	assert.Equal(t, "m/44'/9000'/1'/0/0", response.SignaturesOrdered[1].Path)
+	assert.Equal(t, "m/44'/9000'/0'/0/2", response.SignaturesOrdered[2].Path)
+	assert.Len(t, response.Signature, 3)
+
+	_, err = ledger.SignMultiAccount(nil, []byte{0x01}, nil)
+	assert.ErrorIs(t, err, ErrNoSigningPaths)
+
+	// the change would be shown as an external output of the only account signing
+	device = &mockDevice{}
+	ledger = newMockLedger(device)
+	_, err = ledger.SignMultiAccount([]string{"m/44'/9000'/0'/0/1"}, []byte{0x01}, []string{"m/44'/9000'/2'/1/0"})
+	assert.ErrorIs(t, err, ErrChangePathWithoutAccount)
+	assert.ErrorContains(t, err, "m/44'/9000'/2'/1/0")
+	assert.Empty(t, device.sent)
+}
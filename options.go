@@ -0,0 +1,323 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"time"
+
+	"github.com/zondax/ledger-go"
+)
+
+const (
+	DefaultExchangeTimeout     = 30 * time.Second
+	DefaultConfirmationTimeout = 5 * time.Minute
+	DefaultMaxSigningPaths     = 128
+)
+
+// MinAppVersion is the oldest version of the Avalanche app supported by default
+var MinAppVersion = VersionInfo{0, 0, 6, 5}
+
+// Option configures a LedgerAvalanche
+type Option func(*LedgerAvalanche)
+
+// WithExchangeTimeout bounds how long the device may take to answer each APDU while a
+// transaction is being uploaded. Zero disables the timeout.
+func WithExchangeTimeout(timeout time.Duration) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.exchangeTimeout = timeout
+	}
+}
+
+// WithConfirmationTimeout bounds how long to wait for the user to review and approve an
+// operation on the device, after which ErrConfirmationTimeout is returned. Zero disables the timeout.
+func WithConfirmationTimeout(timeout time.Duration) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.confirmationTimeout = timeout
+	}
+}
+
+// WithUserActionTimeout works as WithConfirmationTimeout: once timeout elapses without the
+// user approving a signature or an address shown on the device, ErrUserTimeout is returned
+func WithUserActionTimeout(timeout time.Duration) Option {
+	return WithConfirmationTimeout(timeout)
+}
+
+// WithPathSerializer replaces the encoding of paths, HRPs and chain IDs sent to the app
+func WithPathSerializer(serializer PathSerializer) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.serializer = serializer
+	}
+}
+
+// WithEnforceLowS controls whether signatures returned by the device are normalized to
+// the low-S form (see NormalizeLowS). Enabled by default.
+func WithEnforceLowS(enforce bool) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.enforceLowS = enforce
+	}
+}
+
+// WithMaxSigningPaths bounds the number of signing paths of a single transaction, as each
+// one takes a round-trip to the device. Zero disables the limit.
+func WithMaxSigningPaths(max int) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.maxSigningPaths = max
+	}
+}
+
+// WithSignatureVerification checks every signature returned by the device before returning it:
+// the public key recovered from the signature must be the one the device reports for its path,
+// otherwise ErrSignatureMismatch is returned. It catches firmware bugs and transport corruption
+// at the cost of a public key request per signature. SignAndCollect cannot be verified, as it
+// does not know the path prefix.
+func WithSignatureVerification() Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.verifySignatures = true
+	}
+}
+
+// BindTransactionHash compares the hash of the transaction computed while uploading it with
+// the hash the app reports signing along with the first signature, failing with ErrHashMismatch
+// before any other signature is collected if the upload was altered in transit. With apps that
+// do not report the hash, signing fails with ErrNotSupported before the transaction is uploaded.
+func BindTransactionHash(bind bool) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.bindTxHash = bind
+	}
+}
+
+// RequireOnDeviceConfirmation shows every requested address on the device for the user to
+// confirm, regardless of the show flag passed to GetPubKey and the methods built on it.
+// Note that address discovery (ScanAccount) then needs a confirmation per address.
+func RequireOnDeviceConfirmation() Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.requireConfirmation = true
+	}
+}
+
+// WithProgressFunc reports the progress of signing flows to fn: the chunks uploaded, when the
+// user is expected to review the operation on the device and the signatures collected.
+// fn is called synchronously, from the goroutine signing.
+func WithProgressFunc(fn ProgressFunc) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.progress = fn
+	}
+}
+
+// WithDevice makes FindLedgerAvalancheApp use device, e.g. a custom transport or an emulator,
+// instead of connecting to a HID device
+func WithDevice(device ledger_go.LedgerDevice) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.api = device
+	}
+}
+
+// WithMinAppVersion sets the oldest version of the Avalanche app accepted when connecting
+// (default MinAppVersion)
+func WithMinAppVersion(version VersionInfo) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.minVersion = version
+	}
+}
+
+// SkipVersionCheck connects without checking that a supported version of the Avalanche app
+// is running, e.g. to reach a device with another app open
+func SkipVersionCheck() Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.skipVersionCheck = true
+	}
+}
+
+// WithPubKeyCache memoizes the public keys returned by GetPubKey and GetAddress for ttl, or for
+// the whole connection if ttl is zero, so repeated lookups do not reach the device. Requests
+// showing the address on the device are never answered from the cache.
+func WithPubKeyCache(ttl time.Duration) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.pubKeyCache = newPubKeyCache(ttl)
+	}
+}
+
+// WithMetrics sets the Metrics receiving the outcome of the operations
+func WithMetrics(metrics Metrics) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.metrics = metrics
+	}
+}
+
+// WithExtendedLength uploads chunks longer than 255 bytes, see WithChunkSize, with an ISO 7816
+// extended length. Only set it for transports and apps accepting extended lengths: the Ledger
+// apps do not.
+func WithExtendedLength(enable bool) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.extendedLength = enable
+	}
+}
+
+// WithChunkSize sets the size of the chunks transactions and messages are uploaded in, e.g. to
+// use larger frames on transports supporting them. Zero uses the size advertised by the
+// transport (see MaxAPDUReporter), or CHUNK_SIZE. Chunks longer than 255 bytes need
+// WithExtendedLength and are cut to 255 bytes otherwise.
+func WithChunkSize(size int) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.chunkSize = size
+	}
+}
+
+// RequireReleaseApp refuses to connect to an app that is not a release build, e.g. a test
+// build with known keys installed by mistake on a production device
+func RequireReleaseApp(require bool) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.requireReleaseApp = require
+	}
+}
+
+// WithBLEScanner makes FindLedgerAvalancheApp and FindLedgerAvalancheAppOnDevice connect over
+// Bluetooth to the devices found by scanner, in the order it returns them, instead of USB
+func WithBLEScanner(scanner BLEScanner) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.bleScanner = scanner
+	}
+}
+
+// WithUSBFilters connects to the HID devices selected by filters instead of the Ledger devices
+// known to ledger-go, e.g. to reach a newer model. The device index then follows the order of
+// ListUSBDevices(filters...).
+func WithUSBFilters(filters ...USBFilter) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.usbFilters = append([]USBFilter{}, filters...)
+	}
+}
+
+// WithConnectTimeout bounds how long connecting to a HID device may take, after which
+// ErrConnectTimeout is returned. Zero disables the timeout.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.connectTimeout = timeout
+	}
+}
+
+// WithConnectRetry makes up to attempts tries to connect to a HID device, e.g. while it is
+// being plugged, waiting backoff before the second one and doubling the wait on every further attempt
+func WithConnectRetry(attempts int, backoff time.Duration) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.connectAttempts = attempts
+		ledger.connectBackoff = backoff
+	}
+}
+
+// WithMiddleware wraps every exchange with the transport in middlewares, the first one being
+// the outermost, e.g. WithMiddleware(MetricsMiddleware(m), RetryMiddleware(3, time.Second))
+func WithMiddleware(middlewares ...Middleware) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.middlewares = append(ledger.middlewares, middlewares...)
+	}
+}
+
+// WithAPDULogger sets the function receiving every APDU exchanged with the device, see SetAPDULogger
+func WithAPDULogger(logger APDULogger) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.apduLogger = logger
+	}
+}
+
+// WithExpectedWalletID makes FindLedgerAvalancheApp and Reconnect fail with ErrWalletIDMismatch
+// when the device holds another seed than the one identified by walletID, see GetWalletID
+func WithExpectedWalletID(walletID []byte) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.expectedWalletID = walletID
+	}
+}
+
+// AllowBlindSigning sets whether SignHash may sign hashes, which the user cannot relate to
+// a transaction on the device (default false: SignHash fails with ErrBlindSigningDisabled)
+func AllowBlindSigning(allow bool) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.allowBlindSigning = allow
+	}
+}
+
+// PermissiveInputs sets whether the HRPs and chain IDs given to GetPubKey and GetAddress are
+// only checked to be serializable. By default they are validated strictly with
+// serialize.ValidateHrp and serialize.ValidateChainID, rather than being sent to a device that
+// would reject them without a useful error.
+func PermissiveInputs(permissive bool) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.permissiveInputs = permissive
+	}
+}
+
+// WithAuditLogger sets the function receiving an AuditEntry for every SignHash request,
+// including the refused ones
+func WithAuditLogger(logger AuditLogger) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.auditLogger = logger
+	}
+}
+
+// WithVersionPolicy sets how the minimum version of the app is enforced (default
+// VersionPolicyStrict), e.g. VersionPolicyPerCommand to read addresses from older apps while
+// refusing to sign with them
+func WithVersionPolicy(policy VersionPolicy) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.versionPolicy = policy
+	}
+}
+
+// WithVersionWarning sets the function receiving the version error of an app older than the
+// minimum version, when the version policy accepts it
+func WithVersionWarning(fn VersionWarningFunc) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.versionWarning = fn
+	}
+}
+
+// WithCommandMinVersion sets the oldest version of the app accepted for instruction ins of class
+// cla (CLA or CLA_ETH) under VersionPolicyPerCommand, replacing the minimum version for signing
+// instructions
+func WithCommandMinVersion(cla, ins byte, version VersionInfo) Option {
+	return func(ledger *LedgerAvalanche) {
+		if ledger.commandVersions == nil {
+			ledger.commandVersions = make(map[command]VersionInfo)
+		}
+		ledger.commandVersions[command{cla, ins}] = version
+	}
+}
+
+// WithKeepAlive makes FindLedgerAvalancheApp start a goroutine pinging the app every interval,
+// reconnecting when the device went away, e.g. after it slept. It is stopped by Close.
+func WithKeepAlive(interval time.Duration) Option {
+	return func(ledger *LedgerAvalanche) {
+		ledger.keepAliveInterval = interval
+	}
+}
+
+func newLedgerAvalanche(api ledger_go.LedgerDevice, opts ...Option) *LedgerAvalanche {
+	ledger := &LedgerAvalanche{
+		api:                 api,
+		serializer:          DefaultPathSerializer{},
+		exchangeTimeout:     DefaultExchangeTimeout,
+		confirmationTimeout: DefaultConfirmationTimeout,
+		enforceLowS:         true,
+		maxSigningPaths:     DefaultMaxSigningPaths,
+		minVersion:          MinAppVersion,
+	}
+	for _, opt := range opts {
+		opt(ledger)
+	}
+	ledger.transport = ledger.buildTransport()
+	return ledger
+}
@@ -0,0 +1,66 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"time"
+)
+
+// AuditEntry records a request to sign a hash blindly, whether it was allowed or refused
+type AuditEntry struct {
+	Time         time.Time
+	PathPrefix   string
+	SigningPaths []string
+	Hash         []byte
+	// Reason is the justification given with WithReason, if any
+	Reason  string
+	Allowed bool
+}
+
+// AuditLogger receives an AuditEntry for every SignHash request
+type AuditLogger func(entry AuditEntry)
+
+// reasonKey is the context key of the reason given with WithReason
+type reasonKey struct{}
+
+// WithReason attaches the reason of a blind signing request to ctx, recorded in the AuditEntry
+// of SignHashContext
+func WithReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, reasonKey{}, reason)
+}
+
+// checkBlindSigning audits a request to sign hash and refuses it with ErrBlindSigningDisabled
+// unless blind signing was allowed with AllowBlindSigning
+func (ledger *LedgerAvalanche) checkBlindSigning(ctx context.Context, pathPrefix string, signingPaths []string, hash []byte) error {
+	if ledger.auditLogger != nil {
+		reason, _ := ctx.Value(reasonKey{}).(string)
+		ledger.auditLogger(AuditEntry{
+			Time:         time.Now(),
+			PathPrefix:   pathPrefix,
+			SigningPaths: append([]string{}, signingPaths...),
+			Hash:         append([]byte{}, hash...),
+			Reason:       reason,
+			Allowed:      ledger.allowBlindSigning,
+		})
+	}
+
+	if !ledger.allowBlindSigning {
+		return ErrBlindSigningDisabled
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BlindSigningDisabledByDefault(t *testing.T) {
+	var entries []AuditEntry
+	device := &mockDevice{}
+	ledger := newMockLedger(device, WithAuditLogger(func(entry AuditEntry) {
+		entries = append(entries, entry)
+	}))
+
+	_, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, ErrBlindSigningDisabled)
+	assert.Empty(t, device.sent, "no APDU should reach the device")
+
+	require.Len(t, entries, 1)
+	assert.False(t, entries[0].Allowed)
+	assert.Equal(t, []string{"0/0"}, entries[0].SigningPaths)
+}
+
+func Test_BlindSigningAudit(t *testing.T) {
+	var entries []AuditEntry
+	device := &mockDevice{handler: replies([]byte{}, make([]byte, SIGNATURE_LEN))}
+	ledger := newMockLedger(device, AllowBlindSigning(true), WithAuditLogger(func(entry AuditEntry) {
+		entries = append(entries, entry)
+	}))
+
+	hash := make([]byte, HASH_LEN)
+	hash[0] = 0x01
+	ctx := WithReason(context.Background(), "validator reward claim")
+	_, err := ledger.SignHashContext(ctx, "m/44'/9000'/0'", []string{"0/0"}, hash)
+	require.NoError(t, err)
+
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Allowed)
+	assert.Equal(t, "validator reward claim", entries[0].Reason)
+	assert.Equal(t, "m/44'/9000'/0'", entries[0].PathPrefix)
+	assert.Equal(t, hash, entries[0].Hash)
+	assert.False(t, entries[0].Time.IsZero())
+}
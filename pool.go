@@ -0,0 +1,304 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrPoolClosed is returned by Pool.Checkout once the pool is closed
+var ErrPoolClosed = errors.New("pool closed")
+
+// ErrPoolEmpty is returned by Pool.Checkout once every device of the pool was removed after
+// failing its health check
+var ErrPoolEmpty = errors.New("no healthy device in the pool")
+
+// ErrNotCheckedOut is returned by Pool.Checkin for a device that is not checked out of the pool
+var ErrNotCheckedOut = errors.New("device not checked out of the pool")
+
+// Pool shares the devices attached to a host, one LedgerAvalanche per physical device, between
+// goroutines, e.g. the requests of a signing service. A device is used by a single caller between
+// Checkout and Checkin; callers find all devices busy wait for one in the order they arrived.
+type Pool struct {
+	healthCheck func(*LedgerAvalanche) error
+
+	mu sync.Mutex
+	// ledgers holds every device of the pool, idle or checked out
+	ledgers    []*LedgerAvalanche
+	idle       []*LedgerAvalanche
+	checkedOut map[*LedgerAvalanche]bool
+	waiters    []chan *LedgerAvalanche
+	closed     bool
+}
+
+// PoolOption configures a Pool
+type PoolOption func(*Pool)
+
+// PoolHealthCheck sets the check run on a device before it is checked out (default: reading the
+// app version). A device failing it is reconnected when pinned to its wallet ID, see ConnectPool,
+// and removed from the pool and closed otherwise. A nil check disables health checks.
+func PoolHealthCheck(check func(*LedgerAvalanche) error) PoolOption {
+	return func(p *Pool) {
+		p.healthCheck = check
+	}
+}
+
+// pingApp checks that the app answers a status query
+func pingApp(ledger *LedgerAvalanche) error {
+	_, err := ledger.GetVersion()
+	return err
+}
+
+// NewPool shares ledgers, which are closed with the pool
+func NewPool(ledgers []*LedgerAvalanche, opts ...PoolOption) *Pool {
+	p := &Pool{
+		healthCheck: pingApp,
+		ledgers:     append([]*LedgerAvalanche{}, ledgers...),
+		idle:        append([]*LedgerAvalanche{}, ledgers...),
+		checkedOut:  make(map[*LedgerAvalanche]bool),
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ConnectPool connects to the Avalanche app on every device listed by ListLedgerDevices, or
+// ListUSBDevices when opts include WithUSBFilters, and shares them in a Pool. Each device is
+// pinned to its wallet ID: as the HID index of a device changes when others are plugged or
+// unplugged, a device reconnected after failing its health check must hold the same seed, or
+// it is removed from the pool.
+func ConnectPool(opts ...Option) (*Pool, error) {
+	var ledgers []*LedgerAvalanche
+	for idx := range listDevicesFor(opts) {
+		ledger, err := FindLedgerAvalancheAppOnDevice(idx, opts...)
+		if err == nil {
+			err = pinWalletID(ledger)
+		}
+		if err != nil {
+			closeAll(ledgers)
+			return nil, fmt.Errorf("device %d: %w", idx, err)
+		}
+		ledgers = append(ledgers, ledger)
+	}
+	return NewPool(ledgers), nil
+}
+
+// pinWalletID makes Reconnect fail with ErrWalletIDMismatch unless the device found holds the
+// seed of the device ledger is connected to. The ledger is closed on failure.
+func pinWalletID(ledger *LedgerAvalanche) error {
+	if ledger.expectedWalletID != nil {
+		return nil
+	}
+	walletID, err := ledger.GetWalletID()
+	if err != nil {
+		_ = ledger.Close()
+		return err
+	}
+	ledger.expectedWalletID = walletID
+	return nil
+}
+
+// Size returns the number of devices in the pool, idle or checked out
+func (p *Pool) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.ledgers)
+}
+
+// Idle returns the number of devices available for Checkout
+func (p *Pool) Idle() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.idle)
+}
+
+// Checkout takes a healthy device out of the pool, waiting for one to be checked in while all
+// are in use, until ctx is done. The device must be returned with Checkin.
+func (p *Pool) Checkout(ctx context.Context) (*LedgerAvalanche, error) {
+	for {
+		ledger, err := p.take(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if p.healthy(ledger) {
+			return ledger, nil
+		}
+		p.remove(ledger)
+	}
+}
+
+// take returns the device idle for the longest time, or the next one checked in
+func (p *Pool) take(ctx context.Context) (*LedgerAvalanche, error) {
+	p.mu.Lock()
+	if err := p.unavailable(); err != nil {
+		p.mu.Unlock()
+		return nil, err
+	}
+	if len(p.idle) > 0 {
+		ledger := p.idle[0]
+		p.idle = p.idle[1:]
+		p.checkedOut[ledger] = true
+		p.mu.Unlock()
+		return ledger, nil
+	}
+	waiter := make(chan *LedgerAvalanche, 1)
+	p.waiters = append(p.waiters, waiter)
+	p.mu.Unlock()
+
+	select {
+	case ledger, ok := <-waiter:
+		if !ok {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			return nil, p.unavailable()
+		}
+		return ledger, nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		queued := p.removeWaiter(waiter)
+		p.mu.Unlock()
+		// a device handed over meanwhile goes to the next waiter
+		if !queued {
+			if ledger, ok := <-waiter; ok {
+				_ = p.Checkin(ledger)
+			}
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// unavailable returns why no device may be checked out, if so. p.mu must be held.
+func (p *Pool) unavailable() error {
+	if p.closed {
+		return ErrPoolClosed
+	}
+	if len(p.ledgers) == 0 {
+		return ErrPoolEmpty
+	}
+	return nil
+}
+
+// removeWaiter dequeues waiter, reporting whether it was still queued. p.mu must be held.
+func (p *Pool) removeWaiter(waiter chan *LedgerAvalanche) bool {
+	for idx, queued := range p.waiters {
+		if queued == waiter {
+			p.waiters = append(p.waiters[:idx], p.waiters[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// healthy runs the health check on ledger. A ledger failing it is reconnected once if pinned to
+// its wallet ID, as another device may now be at its HID index.
+func (p *Pool) healthy(ledger *LedgerAvalanche) bool {
+	if p.healthCheck == nil || p.healthCheck(ledger) == nil {
+		return true
+	}
+	if ledger.expectedWalletID == nil {
+		return false
+	}
+	return ledger.Reconnect() == nil && p.healthCheck(ledger) == nil
+}
+
+// remove closes ledger and drops it from the pool. Waiters fail once no device is left.
+func (p *Pool) remove(ledger *LedgerAvalanche) {
+	_ = ledger.Close()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.checkedOut, ledger)
+	for idx, member := range p.ledgers {
+		if member == ledger {
+			p.ledgers = append(p.ledgers[:idx], p.ledgers[idx+1:]...)
+			break
+		}
+	}
+	if len(p.ledgers) == 0 {
+		p.releaseWaiters()
+	}
+}
+
+// releaseWaiters wakes up the waiters without a device. p.mu must be held.
+func (p *Pool) releaseWaiters() {
+	for _, waiter := range p.waiters {
+		close(waiter)
+	}
+	p.waiters = nil
+}
+
+// Checkin returns a device taken with Checkout to the pool, handing it to the first waiter if
+// any. After the pool is closed, the device is closed instead. It fails with ErrNotCheckedOut
+// for a device already checked in or that does not belong to the pool.
+func (p *Pool) Checkin(ledger *LedgerAvalanche) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.checkedOut[ledger] {
+		return ErrNotCheckedOut
+	}
+	if p.closed {
+		delete(p.checkedOut, ledger)
+		return ledger.Close()
+	}
+	if len(p.waiters) > 0 {
+		// the device stays checked out, by the waiter
+		waiter := p.waiters[0]
+		p.waiters = p.waiters[1:]
+		waiter <- ledger
+		return nil
+	}
+	delete(p.checkedOut, ledger)
+	p.idle = append(p.idle, ledger)
+	return nil
+}
+
+// Do runs fn with a device checked out of the pool, see Checkout
+func (p *Pool) Do(ctx context.Context, fn func(ledger *LedgerAvalanche) error) error {
+	ledger, err := p.Checkout(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = p.Checkin(ledger) }()
+	return fn(ledger)
+}
+
+// Close closes the idle devices and fails the waiters with ErrPoolClosed. Devices checked out
+// are closed when checked in.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	p.releaseWaiters()
+
+	var err error
+	for _, ledger := range p.idle {
+		if closeErr := ledger.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	p.idle = nil
+	return err
+}
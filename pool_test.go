@@ -0,0 +1,172 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PoolCheckout(t *testing.T) {
+	first, second := newMockLedger(&mockDevice{}), newMockLedger(&mockDevice{})
+	pool := NewPool([]*LedgerAvalanche{first, second}, PoolHealthCheck(nil))
+	assert.Equal(t, 2, pool.Size())
+
+	ledger, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, first, ledger)
+	assert.Equal(t, 1, pool.Idle())
+
+	other, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, second, other)
+
+	// no idle device: wait for one until the context is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = pool.Checkout(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.NoError(t, pool.Checkin(ledger))
+	require.NoError(t, pool.Checkin(other))
+	assert.Equal(t, 2, pool.Idle())
+
+	// the device idle for the longest time comes first
+	ledger, err = pool.Checkout(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, first, ledger)
+}
+
+func Test_PoolWaiters(t *testing.T) {
+	pool := NewPool([]*LedgerAvalanche{newMockLedger(&mockDevice{})}, PoolHealthCheck(nil))
+	held, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+
+	// waiters are served in the order they arrived
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for idx := 0; idx < 3; idx++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			assert.NoError(t, pool.Do(context.Background(), func(*LedgerAvalanche) error {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, idx)
+				return nil
+			}))
+		}(idx)
+		assert.Eventually(t, func() bool {
+			pool.mu.Lock()
+			defer pool.mu.Unlock()
+			return len(pool.waiters) == idx+1
+		}, time.Second, time.Millisecond)
+	}
+
+	require.NoError(t, pool.Checkin(held))
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2}, order)
+	assert.Equal(t, 1, pool.Idle())
+}
+
+func Test_PoolHealthCheck(t *testing.T) {
+	broken := &mockDevice{}
+	healthy := &mockDevice{handler: replies([]byte{0, 0, 6, 5})}
+	pool := NewPool([]*LedgerAvalanche{newMockLedger(broken), newMockLedger(healthy)})
+
+	// the broken device is not pinned to a wallet ID, so it is removed
+	ledger, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, healthy, ledger.api)
+	assert.True(t, broken.closed)
+	assert.Equal(t, 1, pool.Size())
+
+	failing := errors.New("unhealthy")
+	pool = NewPool([]*LedgerAvalanche{newMockLedger(&mockDevice{})},
+		PoolHealthCheck(func(*LedgerAvalanche) error { return failing }))
+	_, err = pool.Checkout(context.Background())
+	assert.ErrorIs(t, err, ErrPoolEmpty)
+}
+
+func Test_PoolPinsWalletID(t *testing.T) {
+	originalList := listDevices
+	listDevices = func() []DeviceInfo { return []DeviceInfo{{Path: "1-1"}} }
+	t.Cleanup(func() { listDevices = originalList })
+
+	walletID := []byte{1, 2, 3, 4, 5, 6}
+	calls := 0
+	first := &mockDevice{handler: func([]byte) ([]byte, error) {
+		calls++
+		switch {
+		case calls <= 2:
+			return []byte{0, 0, 6, 5}, nil
+		case calls == 3:
+			return walletID, nil
+		}
+		return nil, errors.New("hidapi: failed to write to device")
+	}}
+	// another device took the HID index of the first one
+	other := &mockDevice{handler: replies([]byte{0, 0, 6, 5}, []byte{0, 0, 6, 5}, []byte{6, 5, 4, 3, 2, 1})}
+	withDevices(t, first, other)
+
+	pool, err := ConnectPool()
+	require.NoError(t, err)
+	require.Equal(t, 1, pool.Size())
+
+	_, err = pool.Checkout(context.Background())
+	assert.ErrorIs(t, err, ErrPoolEmpty)
+	assert.True(t, other.closed)
+}
+
+func Test_PoolCheckin(t *testing.T) {
+	pool := NewPool([]*LedgerAvalanche{newMockLedger(&mockDevice{})}, PoolHealthCheck(nil))
+	ledger, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, pool.Checkin(ledger))
+	assert.ErrorIs(t, pool.Checkin(ledger), ErrNotCheckedOut)
+	assert.Equal(t, 1, pool.Idle())
+
+	assert.ErrorIs(t, pool.Checkin(newMockLedger(&mockDevice{})), ErrNotCheckedOut)
+	assert.Equal(t, 1, pool.Size())
+}
+
+func Test_PoolClose(t *testing.T) {
+	idle, busy := &mockDevice{}, &mockDevice{}
+	pool := NewPool([]*LedgerAvalanche{newMockLedger(busy), newMockLedger(idle)}, PoolHealthCheck(nil))
+	ledger, err := pool.Checkout(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, pool.Close())
+	assert.True(t, idle.closed)
+	assert.False(t, busy.closed)
+
+	_, err = pool.Checkout(context.Background())
+	assert.ErrorIs(t, err, ErrPoolClosed)
+
+	require.NoError(t, pool.Checkin(ledger))
+	assert.True(t, busy.closed)
+}
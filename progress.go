@@ -0,0 +1,62 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+// SignState is a stage of a signing flow
+type SignState int
+
+const (
+	// SignUploading is reported before each chunk of the payload is sent to the device
+	SignUploading SignState = iota
+	// SignAwaitingConfirmation is reported when the device is about to show the operation
+	// for the user to review, e.g. to prompt the user to check the device
+	SignAwaitingConfirmation
+	// SignCollectingSignatures is reported before each signature is requested
+	SignCollectingSignatures
+)
+
+func (s SignState) String() string {
+	switch s {
+	case SignUploading:
+		return "uploading"
+	case SignAwaitingConfirmation:
+		return "awaiting-confirmation"
+	case SignCollectingSignatures:
+		return "collecting-signatures"
+	default:
+		return "unknown"
+	}
+}
+
+// Progress reports the advance of a signing flow. Step counts from 1 to Steps within the
+// state: the chunks being uploaded or the signatures being collected. Both are 0 while
+// awaiting the confirmation.
+type Progress struct {
+	State SignState
+	Step  int
+	Steps int
+}
+
+// ProgressFunc receives the progress of signing flows, see WithProgressFunc
+type ProgressFunc func(Progress)
+
+// reportProgress calls the progress function of the ledger, if any
+func (ledger *LedgerAvalanche) reportProgress(state SignState, step, steps int) {
+	if ledger.progress != nil {
+		ledger.progress(Progress{State: state, Step: step, Steps: steps})
+	}
+}
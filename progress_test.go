@@ -0,0 +1,67 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignProgress(t *testing.T) {
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[1] == INS_SIGN_HASH {
+			return make([]byte, SIGNATURE_LEN), nil
+		}
+		return []byte{}, nil
+	}}
+
+	var reported []Progress
+	ledger := newMockLedger(device, WithProgressFunc(func(p Progress) {
+		reported = append(reported, p)
+	}))
+
+	// 19 bytes of change path header and 600 of transaction make 3 chunks
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, make([]byte, 600), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Progress{
+		{SignUploading, 1, 3},
+		{SignUploading, 2, 3},
+		{SignUploading, 3, 3},
+		{SignAwaitingConfirmation, 0, 0},
+		{SignCollectingSignatures, 1, 2},
+		{SignCollectingSignatures, 2, 2},
+	}, reported)
+}
+
+func Test_SignHashProgress(t *testing.T) {
+	var reported []Progress
+	ledger := newMockLedger(&mockDevice{handler: replies([]byte{}, make([]byte, SIGNATURE_LEN))}, AllowBlindSigning(true), WithProgressFunc(func(p Progress) {
+		reported = append(reported, p)
+	}))
+
+	_, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	require.NoError(t, err)
+
+	assert.Equal(t, []Progress{
+		{SignAwaitingConfirmation, 0, 0},
+		{SignCollectingSignatures, 1, 1},
+	}, reported)
+	assert.Equal(t, "awaiting-confirmation", reported[0].State.String())
+}
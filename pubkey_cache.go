@@ -0,0 +1,88 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"sync"
+	"time"
+)
+
+// pubKeyCacheKey identifies a public key request
+type pubKeyCacheKey struct {
+	path    string
+	hrp     string
+	chainID string
+}
+
+type pubKeyCacheEntry struct {
+	publicKey []byte
+	hash      []byte
+	expires   time.Time
+}
+
+// pubKeyCache memoizes the public keys returned by the device, see WithPubKeyCache
+type pubKeyCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[pubKeyCacheKey]pubKeyCacheEntry
+	now     func() time.Time
+}
+
+func newPubKeyCache(ttl time.Duration) *pubKeyCache {
+	return &pubKeyCache{ttl: ttl, entries: map[pubKeyCacheKey]pubKeyCacheEntry{}, now: time.Now}
+}
+
+// get returns a copy of the cached public key and hash of key, if not expired
+func (c *pubKeyCache) get(key pubKeyCacheKey) (publicKey []byte, hash []byte, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, nil, false
+	}
+	return append([]byte{}, entry.publicKey...), append([]byte{}, entry.hash...), true
+}
+
+func (c *pubKeyCache) put(key pubKeyCacheKey, publicKey []byte, hash []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[key] = pubKeyCacheEntry{
+		publicKey: append([]byte{}, publicKey...),
+		hash:      append([]byte{}, hash...),
+		expires:   c.now().Add(c.ttl),
+	}
+}
+
+func (c *pubKeyCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = map[pubKeyCacheKey]pubKeyCacheEntry{}
+}
+
+// ClearPubKeyCache forgets the public keys memoized since WithPubKeyCache was set. The cache is
+// also cleared by Reconnect, as another device may have been plugged in.
+func (ledger *LedgerAvalanche) ClearPubKeyCache() {
+	if ledger.pubKeyCache != nil {
+		ledger.pubKeyCache.clear()
+	}
+}
@@ -0,0 +1,106 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pubKeyDevice() *mockDevice {
+	publicKey, _ := hex.DecodeString("035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56")
+	response := append(append([]byte{33}, publicKey...), AddressHash(publicKey)...)
+	return &mockDevice{handler: func([]byte) ([]byte, error) {
+		return response, nil
+	}}
+}
+
+func Test_PubKeyCache(t *testing.T) {
+	device := pubKeyDevice()
+	ledger := newMockLedger(device, WithPubKeyCache(0))
+
+	first, err := ledger.GetAddress("m/44'/9000'/0'/0/0", "avax", "", false)
+	require.NoError(t, err)
+	second, err := ledger.GetAddress("m/44'/9000'/0'/0/0", "avax", "", false)
+	require.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Len(t, device.sent, 1)
+
+	// another hrp, chain or path is another entry
+	_, err = ledger.GetAddress("m/44'/9000'/0'/0/0", "fuji", "", false)
+	require.NoError(t, err)
+	_, err = ledger.GetAddress("m/44'/9000'/0'/0/1", "avax", "", false)
+	require.NoError(t, err)
+	assert.Len(t, device.sent, 3)
+
+	// showing the address always reaches the device
+	_, err = ledger.GetAddress("m/44'/9000'/0'/0/0", "avax", "", true)
+	require.NoError(t, err)
+	assert.Len(t, device.sent, 4)
+
+	ledger.ClearPubKeyCache()
+	_, err = ledger.GetAddress("m/44'/9000'/0'/0/0", "avax", "", false)
+	require.NoError(t, err)
+	assert.Len(t, device.sent, 5)
+}
+
+func Test_PubKeyCacheExpires(t *testing.T) {
+	device := pubKeyDevice()
+	ledger := newMockLedger(device, WithPubKeyCache(time.Minute))
+	now := time.Now()
+	ledger.pubKeyCache.now = func() time.Time { return now }
+
+	_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+	require.NoError(t, err)
+	now = now.Add(59 * time.Second)
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+	require.NoError(t, err)
+	assert.Len(t, device.sent, 1)
+
+	now = now.Add(time.Second)
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+	require.NoError(t, err)
+	assert.Len(t, device.sent, 2)
+}
+
+func Test_PubKeyCacheReturnsCopies(t *testing.T) {
+	ledger := newMockLedger(pubKeyDevice(), WithPubKeyCache(0))
+
+	publicKey, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+	require.NoError(t, err)
+	publicKey[0] = 0xff
+
+	cached, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x03), cached[0])
+}
+
+func Test_PubKeyCacheDisabled(t *testing.T) {
+	device := pubKeyDevice()
+	ledger := newMockLedger(device)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+		require.NoError(t, err)
+	}
+	assert.Len(t, device.sent, 2)
+	ledger.ClearPubKeyCache()
+}
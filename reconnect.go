@@ -0,0 +1,308 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/zondax/ledger-go"
+)
+
+// ErrSignNotRetried is returned by AutoReconnect when the device is disconnected while signing.
+// The device is reconnected but, as the user may have already approved it, the signature is not
+// requested again: the caller should decide whether to sign again.
+var ErrSignNotRetried = errors.New("device disconnected while signing, the signature was not requested again")
+
+// AutoReconnect wraps a LedgerAvalanche and transparently reconnects to the Avalanche app
+// when an operation fails with ErrDeviceDisconnected, e.g. after the OS suspends and resumes.
+// By default the wallet ID of the reconnected device must match the original one, so it never
+// silently signs with a different device.
+type AutoReconnect struct {
+	ledger      *LedgerAvalanche
+	connect     func() (*LedgerAvalanche, error)
+	maxAttempts int
+	backoff     time.Duration
+	pinWalletID bool
+	walletID    []byte
+	ledgerOpts  []Option
+}
+
+// ReconnectOption configures an AutoReconnect
+type ReconnectOption func(*AutoReconnect)
+
+// ReconnectAttempts sets how many times an operation is reconnected and retried (default 3)
+func ReconnectAttempts(attempts int) ReconnectOption {
+	return func(a *AutoReconnect) {
+		a.maxAttempts = attempts
+	}
+}
+
+// WithRetry sets how many times an operation is reconnected and retried, waiting backoff
+// before the first reconnection and doubling the wait on every further attempt
+func WithRetry(maxAttempts int, backoff time.Duration) ReconnectOption {
+	return func(a *AutoReconnect) {
+		a.maxAttempts = maxAttempts
+		a.backoff = backoff
+	}
+}
+
+// PinWalletID sets whether the wallet ID of a reconnected device must match the original one (default true)
+func PinWalletID(pin bool) ReconnectOption {
+	return func(a *AutoReconnect) {
+		a.pinWalletID = pin
+	}
+}
+
+// WithLedgerOptions sets the options of the ledgers connected to, e.g. AllowBlindSigning
+func WithLedgerOptions(opts ...Option) ReconnectOption {
+	return func(a *AutoReconnect) {
+		a.ledgerOpts = opts
+	}
+}
+
+// NewAutoReconnectLedger finds the Avalanche app and wraps it in an AutoReconnect
+func NewAutoReconnectLedger(opts ...ReconnectOption) (*AutoReconnect, error) {
+	a := &AutoReconnect{
+		maxAttempts: 3,
+		pinWalletID: true,
+	}
+	a.connect = func() (*LedgerAvalanche, error) { return FindLedgerAvalancheApp(a.ledgerOpts...) }
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	ledger, err := a.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	if a.pinWalletID {
+		a.walletID, err = ledger.GetWalletID()
+		if err != nil {
+			ledger.Close()
+			return nil, err
+		}
+	}
+
+	a.ledger = ledger
+	return a, nil
+}
+
+// Ledger returns the current connection
+func (a *AutoReconnect) Ledger() *LedgerAvalanche {
+	return a.ledger
+}
+
+// Do runs fn with the current connection, reconnecting and running it again when it fails
+// because the device was disconnected. fn should be idempotent, see DoOnce.
+func (a *AutoReconnect) Do(fn func(ledger *LedgerAvalanche) error) error {
+	err := fn(a.ledger)
+	for attempt := 0; attempt < a.maxAttempts && errors.Is(err, ErrDeviceDisconnected); attempt++ {
+		if err = a.reconnect(attempt); err != nil {
+			continue
+		}
+		err = fn(a.ledger)
+	}
+	return err
+}
+
+// DoOnce runs fn with the current connection. When it fails because the device was
+// disconnected, the device is reconnected for the next operation but fn is not run again
+// and ErrSignNotRetried is returned.
+func (a *AutoReconnect) DoOnce(fn func(ledger *LedgerAvalanche) error) error {
+	err := fn(a.ledger)
+	if !errors.Is(err, ErrDeviceDisconnected) {
+		return err
+	}
+
+	for attempt := 0; attempt < a.maxAttempts; attempt++ {
+		if a.reconnect(attempt) == nil {
+			break
+		}
+	}
+	return fmt.Errorf("%w (%v)", ErrSignNotRetried, err)
+}
+
+func (a *AutoReconnect) reconnect(attempt int) error {
+	_ = a.ledger.Close()
+
+	if a.backoff > 0 {
+		time.Sleep(a.backoff << uint(attempt))
+	}
+
+	ledger, err := a.connect()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDeviceDisconnected, err)
+	}
+
+	if a.pinWalletID {
+		walletID, err := ledger.GetWalletID()
+		if err != nil {
+			ledger.Close()
+			return err
+		}
+		if !bytes.Equal(walletID, a.walletID) {
+			ledger.Close()
+			return ErrWalletIDMismatch
+		}
+	}
+
+	a.ledger = ledger
+	return nil
+}
+
+// Close closes the current connection
+func (a *AutoReconnect) Close() error {
+	return a.ledger.Close()
+}
+
+// GetVersion returns the current version of the Avalanche user app
+func (a *AutoReconnect) GetVersion() (version *VersionInfo, err error) {
+	err = a.Do(func(ledger *LedgerAvalanche) (err error) {
+		version, err = ledger.GetVersion()
+		return err
+	})
+	return version, err
+}
+
+// GetPubKey returns the pubkey and hash
+func (a *AutoReconnect) GetPubKey(path string, show bool, hrp string, chainid string) (publicKey []byte, hash []byte, err error) {
+	err = a.Do(func(ledger *LedgerAvalanche) (err error) {
+		publicKey, hash, err = ledger.GetPubKey(path, show, hrp, chainid)
+		return err
+	})
+	return publicKey, hash, err
+}
+
+// Sign signs a transaction, see LedgerAvalanche.Sign. It is not retried when the device
+// is disconnected, ErrSignNotRetried is returned instead.
+func (a *AutoReconnect) Sign(pathPrefix string, signingPaths []string, message []byte, changePaths []string) (response *ResponseSign, err error) {
+	err = a.DoOnce(func(ledger *LedgerAvalanche) (err error) {
+		response, err = ledger.Sign(pathPrefix, signingPaths, message, changePaths)
+		return err
+	})
+	return response, err
+}
+
+// SignHash signs a hash, see LedgerAvalanche.SignHash. It is not retried when the device
+// is disconnected, ErrSignNotRetried is returned instead.
+func (a *AutoReconnect) SignHash(pathPrefix string, signingPaths []string, hash []byte) (response *ResponseSign, err error) {
+	err = a.DoOnce(func(ledger *LedgerAvalanche) (err error) {
+		response, err = ledger.SignHash(pathPrefix, signingPaths, hash)
+		return err
+	})
+	return response, err
+}
+
+// Reconnect closes the connection and connects again to the Avalanche app on the same HID device,
+// e.g. after the device slept or was unplugged, keeping the options of the ledger. The app found
+// is checked as on connection, and the device is used only once the checks pass. It fails with
+// ErrBusy while signing and with ErrReconnectNotSupported unless the ledger was connected by
+// FindLedgerAvalancheApp. See AutoReconnect to reconnect and retry operations automatically.
+func (ledger *LedgerAvalanche) Reconnect() error {
+	if !ledger.reconnectable {
+		return ErrReconnectNotSupported
+	}
+	if ledger.signing.Load() {
+		return ErrBusy
+	}
+
+	ledger.mu.Lock()
+	defer ledger.mu.Unlock()
+
+	_ = ledger.api.Close()
+	device, err := ledger.connect(ledger.deviceIndex)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDeviceDisconnected, err)
+	}
+
+	// open closes the device when the checks fail
+	probe, err := ledger.probe(device).open()
+	if err != nil {
+		return err
+	}
+	ledger.api = device
+
+	ledger.ClearPubKeyCache()
+
+	// exchanges abandoned on the previous connection are gone with it
+	ledger.state.Lock()
+	ledger.version = probe.version
+	ledger.pending = nil
+	ledger.desynced = false
+	ledger.state.Unlock()
+	return nil
+}
+
+// probe returns a ledger exchanging with device under the options of ledger, to check the app
+// running on device before using it
+func (ledger *LedgerAvalanche) probe(device ledger_go.LedgerDevice) *LedgerAvalanche {
+	probe := &LedgerAvalanche{
+		api:               device,
+		serializer:        ledger.serializer,
+		errorTranslator:   ledger.currentErrorTranslator(),
+		apduLogger:        ledger.currentAPDULogger(),
+		middlewares:       ledger.middlewares,
+		exchangeTimeout:   ledger.exchangeTimeout,
+		requireReleaseApp: ledger.requireReleaseApp,
+		minVersion:        ledger.minVersion,
+		skipVersionCheck:  ledger.skipVersionCheck,
+		versionPolicy:     ledger.versionPolicy,
+		versionWarning:    ledger.versionWarning,
+		expectedWalletID:  ledger.expectedWalletID,
+	}
+	probe.transport = probe.buildTransport()
+	return probe
+}
+
+// startKeepAlive pings the app every keepAliveInterval until Close is called
+func (ledger *LedgerAvalanche) startKeepAlive() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	var once sync.Once
+	ledger.stopKeepAlive = func() {
+		once.Do(func() {
+			close(stop)
+			<-stopped
+		})
+	}
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(ledger.keepAliveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				ledger.keepAlive()
+			}
+		}
+	}()
+}
+
+// keepAlive pings the app, reconnecting when the device went away
+func (ledger *LedgerAvalanche) keepAlive() {
+	if _, err := ledger.GetVersion(); errors.Is(err, ErrDeviceDisconnected) {
+		_ = ledger.Reconnect()
+	}
+}
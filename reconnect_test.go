@@ -0,0 +1,215 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zondax/ledger-go"
+)
+
+func withConnector(devices ...*mockDevice) ReconnectOption {
+	return func(a *AutoReconnect) {
+		a.connect = func() (*LedgerAvalanche, error) {
+			if len(devices) == 0 {
+				return nil, errors.New("LedgerHID device (idx 0) not found")
+			}
+			device := devices[0]
+			devices = devices[1:]
+			return newMockLedger(device, a.ledgerOpts...), nil
+		}
+	}
+}
+
+// disconnectOnce answers the wallet ID request, then fails as if the device was unplugged
+func disconnectOnce(walletID []byte) *mockDevice {
+	calls := 0
+	return &mockDevice{handler: func([]byte) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			return walletID, nil
+		}
+		return nil, errors.New("hidapi: failed to write to device")
+	}}
+}
+
+func Test_AutoReconnect(t *testing.T) {
+	walletID := []byte{1, 2, 3, 4, 5, 6}
+	first := disconnectOnce(walletID)
+	second := &mockDevice{handler: replies(walletID, []byte{0, 0, 6, 5})}
+
+	ledger, err := NewAutoReconnectLedger(withConnector(first, second))
+	require.NoError(t, err)
+
+	version, err := ledger.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, VersionInfo{0, 0, 6, 5}, *version)
+	assert.True(t, first.closed)
+	assert.Equal(t, second, ledger.Ledger().api)
+}
+
+func Test_AutoReconnectWalletIDMismatch(t *testing.T) {
+	first := disconnectOnce([]byte{1, 2, 3, 4, 5, 6})
+	second := &mockDevice{handler: replies([]byte{6, 5, 4, 3, 2, 1}, []byte{0, 0, 6, 5})}
+
+	ledger, err := NewAutoReconnectLedger(withConnector(first, second))
+	require.NoError(t, err)
+
+	_, err = ledger.GetVersion()
+	assert.ErrorIs(t, err, ErrWalletIDMismatch)
+	assert.True(t, second.closed)
+}
+
+func Test_AutoReconnectAttempts(t *testing.T) {
+	first := &mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, errors.New("hidapi: failed to write to device")
+	}}
+
+	ledger, err := NewAutoReconnectLedger(withConnector(first), PinWalletID(false), ReconnectAttempts(2))
+	require.NoError(t, err)
+
+	_, err = ledger.GetVersion()
+	assert.ErrorIs(t, err, ErrDeviceDisconnected)
+}
+
+func Test_AutoReconnectWithRetry(t *testing.T) {
+	walletID := []byte{1, 2, 3, 4, 5, 6}
+	first := disconnectOnce(walletID)
+	second := &mockDevice{handler: replies(walletID, []byte{0, 0, 6, 5})}
+
+	ledger, err := NewAutoReconnectLedger(withConnector(first, second), WithRetry(1, 10*time.Millisecond))
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = ledger.GetVersion()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+}
+
+func Test_AutoReconnectSignNotRetried(t *testing.T) {
+	walletID := []byte{1, 2, 3, 4, 5, 6}
+	first := disconnectOnce(walletID)
+	second := &mockDevice{handler: replies(walletID)}
+
+	ledger, err := NewAutoReconnectLedger(withConnector(first, second), WithLedgerOptions(AllowBlindSigning(true)))
+	require.NoError(t, err)
+
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, 32))
+	assert.ErrorIs(t, err, ErrSignNotRetried)
+
+	// reconnected for the next operation, without signing again
+	assert.True(t, first.closed)
+	assert.Equal(t, second, ledger.Ledger().api)
+	assert.Len(t, second.sent, 1)
+}
+
+// versionDevice answers every request with the version of the app
+func versionDevice() *mockDevice {
+	return &mockDevice{handler: func([]byte) ([]byte, error) {
+		return []byte{0, 0, 6, 5}, nil
+	}}
+}
+
+// sleepingDevice answers the version checks of FindLedgerAvalancheApp, then fails as if it slept
+func sleepingDevice() *mockDevice {
+	calls := 0
+	return &mockDevice{handler: func([]byte) ([]byte, error) {
+		calls++
+		if calls <= 2 {
+			return []byte{0, 0, 6, 5}, nil
+		}
+		return nil, errors.New("hidapi: failed to write to device")
+	}}
+}
+
+func withDevices(t *testing.T, devices ...*mockDevice) {
+	withConnectDevice(t, func(int) (ledger_go.LedgerDevice, error) {
+		if len(devices) == 0 {
+			return nil, errors.New("LedgerHID device (idx 0) not found")
+		}
+		device := devices[0]
+		devices = devices[1:]
+		return device, nil
+	})
+}
+
+func Test_Reconnect(t *testing.T) {
+	first, second := sleepingDevice(), versionDevice()
+	withDevices(t, first, second)
+
+	ledger, err := FindLedgerAvalancheApp()
+	require.NoError(t, err)
+
+	_, err = ledger.GetVersion()
+	require.ErrorIs(t, err, ErrDeviceDisconnected)
+
+	require.NoError(t, ledger.Reconnect())
+	assert.True(t, first.closed)
+	assert.Equal(t, second, ledger.api)
+
+	_, err = ledger.GetVersion()
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, ledger.Reconnect(), ErrDeviceDisconnected)
+}
+
+func Test_ReconnectChecksBeforeSwapping(t *testing.T) {
+	walletID := []byte{1, 2, 3, 4, 5, 6}
+	first := &mockDevice{handler: replies([]byte{0, 0, 6, 5}, []byte{0, 0, 6, 5}, walletID)}
+	other := &mockDevice{handler: replies([]byte{0, 0, 6, 5}, []byte{0, 0, 6, 5}, []byte{6, 5, 4, 3, 2, 1})}
+	debug := &mockDevice{handler: func([]byte) ([]byte, error) { return []byte{byte(AppModeDebug), 0, 6, 5}, nil }}
+	withDevices(t, first, other, debug)
+
+	ledger, err := FindLedgerAvalancheApp(WithExpectedWalletID(walletID))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, ledger.Reconnect(), ErrWalletIDMismatch)
+	assert.True(t, other.closed)
+	assert.Equal(t, first, ledger.api)
+
+	ledger.requireReleaseApp = true
+	assert.ErrorIs(t, ledger.Reconnect(), ErrNonReleaseApp)
+	assert.True(t, debug.closed)
+	assert.Equal(t, first, ledger.api)
+}
+
+func Test_ReconnectNotSupported(t *testing.T) {
+	ledger, err := FindLedgerAvalancheApp(WithDevice(versionDevice()))
+	require.NoError(t, err)
+	assert.ErrorIs(t, ledger.Reconnect(), ErrReconnectNotSupported)
+}
+
+func Test_KeepAlive(t *testing.T) {
+	first, second := sleepingDevice(), versionDevice()
+	withDevices(t, first, second)
+
+	ledger, err := FindLedgerAvalancheApp(WithKeepAlive(time.Millisecond))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		ledger.mu.Lock()
+		defer ledger.mu.Unlock()
+		return ledger.api == second
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, ledger.Close())
+	assert.True(t, second.closed)
+}
@@ -0,0 +1,162 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/zondax/ledger-go"
+)
+
+// ErrUnexpectedCommand is returned by a ReplayDevice when a command differs from the recorded one
+var ErrUnexpectedCommand = errors.New("unexpected command")
+
+// RecordedExchange is an APDU exchange of a fixture, with the command and response hex encoded.
+// Status is the status word answered by the device, Error the transport error if any.
+type RecordedExchange struct {
+	Command  string `json:"command"`
+	Response string `json:"response"`
+	Status   uint16 `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReplayDevice answers the commands of a recorded session, e.g. captured with RecordingDevice,
+// so flows can be tested without a device. Commands must be sent in the recorded order.
+type ReplayDevice struct {
+	mu        sync.Mutex
+	exchanges []RecordedExchange
+	next      int
+}
+
+// NewReplayDevice returns a device replaying exchanges
+func NewReplayDevice(exchanges []RecordedExchange) *ReplayDevice {
+	return &ReplayDevice{exchanges: exchanges}
+}
+
+// LoadReplayDevice returns a device replaying the JSON fixture at path, as saved by RecordingDevice
+func LoadReplayDevice(path string) (*ReplayDevice, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var exchanges []RecordedExchange
+	if err := json.Unmarshal(data, &exchanges); err != nil {
+		return nil, fmt.Errorf("fixture %s: %w", path, err)
+	}
+	return NewReplayDevice(exchanges), nil
+}
+
+// Exchange returns the recorded answer to command, reporting status words other than 0x9000
+// as ledger-go does
+func (d *ReplayDevice) Exchange(command []byte) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.next >= len(d.exchanges) {
+		return nil, fmt.Errorf("%w: %x, the recorded session is over", ErrUnexpectedCommand, command)
+	}
+	exchange := d.exchanges[d.next]
+	if hex.EncodeToString(command) != exchange.Command {
+		return nil, fmt.Errorf("%w: %x, expected %s at exchange %d", ErrUnexpectedCommand, command, exchange.Command, d.next)
+	}
+	d.next++
+
+	if exchange.Error != "" {
+		return nil, errors.New(exchange.Error)
+	}
+	response, err := hex.DecodeString(exchange.Response)
+	if err != nil {
+		return nil, err
+	}
+	if exchange.Status != 0x9000 {
+		return response, errors.New(ledger_go.ErrorMessage(exchange.Status))
+	}
+	return response, nil
+}
+
+// Remaining returns the number of recorded exchanges not replayed yet
+func (d *ReplayDevice) Remaining() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.exchanges) - d.next
+}
+
+func (d *ReplayDevice) Close() error {
+	return nil
+}
+
+// RecordingDevice records the exchanges with a device, to be saved as a fixture for ReplayDevice
+type RecordingDevice struct {
+	device ledger_go.LedgerDevice
+
+	mu        sync.Mutex
+	exchanges []RecordedExchange
+}
+
+// NewRecordingDevice returns a device recording the exchanges with device
+func NewRecordingDevice(device ledger_go.LedgerDevice) *RecordingDevice {
+	return &RecordingDevice{device: device}
+}
+
+// Exchange forwards command to the device and records its answer
+func (d *RecordingDevice) Exchange(command []byte) ([]byte, error) {
+	response, err := d.device.Exchange(command)
+
+	exchange := RecordedExchange{
+		Command:  hex.EncodeToString(command),
+		Response: hex.EncodeToString(response),
+		Status:   uint16(NoErrors),
+	}
+	if err != nil {
+		if code, ok := parseStatusWord(err); ok {
+			exchange.Status = uint16(code)
+		} else {
+			exchange.Error = err.Error()
+		}
+	}
+
+	d.mu.Lock()
+	d.exchanges = append(d.exchanges, exchange)
+	d.mu.Unlock()
+	return response, err
+}
+
+// Exchanges returns the exchanges recorded so far
+func (d *RecordingDevice) Exchanges() []RecordedExchange {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]RecordedExchange{}, d.exchanges...)
+}
+
+// Save writes the exchanges recorded so far as a JSON fixture, see LoadReplayDevice
+func (d *RecordingDevice) Save(path string) error {
+	data, err := json.MarshalIndent(d.Exchanges(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func (d *RecordingDevice) Close() error {
+	return d.device.Close()
+}
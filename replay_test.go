@@ -0,0 +1,95 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReplaySignSession(t *testing.T) {
+	device, err := LoadReplayDevice("testdata/sign_session.json")
+	require.NoError(t, err)
+	ledger := NewLedgerAvalanche(device)
+
+	version, err := ledger.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, VersionInfo{0, 0, 6, 5}, *version)
+
+	publicKey, _, err := ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+	require.NoError(t, err)
+
+	tx := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x22}
+	response, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0", "0/1"}, tx, []string{"1/0"})
+	require.NoError(t, err)
+	assert.Zero(t, device.Remaining())
+
+	hash, _ := ComputeSignHash(tx)
+	assert.True(t, VerifySignature(publicKey, hash, response.Signature["0/0"][:64]))
+}
+
+func Test_ReplayUnexpectedCommand(t *testing.T) {
+	device, err := LoadReplayDevice("testdata/sign_session.json")
+	require.NoError(t, err)
+	ledger := NewLedgerAvalanche(device)
+
+	// the replay fails as a transport would
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "avax", "")
+	assert.ErrorIs(t, err, ErrDeviceDisconnected)
+	assert.Contains(t, err.Error(), ErrUnexpectedCommand.Error())
+
+	_, err = device.Exchange([]byte{CLA, INS_WALLET_ID, 0, 0, 0})
+	assert.ErrorIs(t, err, ErrUnexpectedCommand)
+}
+
+func Test_RecordAndReplay(t *testing.T) {
+	recorder := NewRecordingDevice(&mockDevice{handler: func(command []byte) ([]byte, error) {
+		switch command[1] {
+		case INS_GET_VERSION:
+			return []byte{0, 0, 6, 5}, nil
+		case INS_WALLET_ID:
+			return nil, statusError(TransactionRejected)
+		}
+		return nil, errors.New("hidapi: failed to write to device")
+	}})
+	ledger := NewLedgerAvalanche(recorder)
+	_, _ = ledger.GetVersion()
+	_, _ = ledger.GetWalletID()
+	_, _, _ = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, recorder.Save(path))
+
+	device, err := LoadReplayDevice(path)
+	require.NoError(t, err)
+	ledger = NewLedgerAvalanche(device)
+
+	version, err := ledger.GetVersion()
+	require.NoError(t, err)
+	assert.Equal(t, VersionInfo{0, 0, 6, 5}, *version)
+
+	_, err = ledger.GetWalletID()
+	assert.True(t, isStatus(err, TransactionRejected))
+
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	assert.ErrorIs(t, err, ErrDeviceDisconnected)
+	assert.Zero(t, device.Remaining())
+}
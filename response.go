@@ -0,0 +1,168 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// responseDecoder reads the fields of a device response, failing with ErrMalformedResponse
+// instead of reading past its end
+type responseDecoder struct {
+	data   []byte
+	offset int
+}
+
+func newResponseDecoder(response []byte) *responseDecoder {
+	return &responseDecoder{data: response}
+}
+
+// remaining returns the number of bytes not read yet
+func (d *responseDecoder) remaining() int {
+	return len(d.data) - d.offset
+}
+
+// next reads the following n bytes
+func (d *responseDecoder) next(n int) ([]byte, error) {
+	if n < 0 || n > d.remaining() {
+		return nil, ErrMalformedResponse
+	}
+	b := d.data[d.offset : d.offset+n]
+	d.offset += n
+	return b, nil
+}
+
+func (d *responseDecoder) byte() (byte, error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *responseDecoder) uint32() (uint32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+// lengthPrefixed reads a [len | value] field
+func (d *responseDecoder) lengthPrefixed() ([]byte, error) {
+	n, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	return d.next(int(n))
+}
+
+// rest reads the bytes not read yet
+func (d *responseDecoder) rest() []byte {
+	b := d.data[d.offset:]
+	d.offset = len(d.data)
+	return b
+}
+
+// parseVersionResponse parses [appMode | major | minor | patch | deviceLocked | targetId],
+// where the fields after patch are optional
+func parseVersionResponse(response []byte) (version VersionInfo, locked bool, err error) {
+	d := newResponseDecoder(response)
+	fields, err := d.next(4)
+	if err != nil {
+		return VersionInfo{}, false, err
+	}
+	version = VersionInfo{AppMode: fields[0], Major: fields[1], Minor: fields[2], Patch: fields[3]}
+
+	if d.remaining() > 0 {
+		lockedFlag, _ := d.byte()
+		locked = lockedFlag != 0
+	}
+	return version, locked, nil
+}
+
+// parsePubKeyResponse parses [publicKeyLen | publicKey | hash]
+func parsePubKeyResponse(response []byte) (publicKey []byte, hash []byte, err error) {
+	d := newResponseDecoder(response)
+	if publicKey, err = d.lengthPrefixed(); err != nil {
+		return nil, nil, err
+	}
+	return publicKey, d.rest(), nil
+}
+
+// parseExtendedPubKeyResponse parses [publicKeyLen | publicKey | chainCode]
+func parseExtendedPubKeyResponse(response []byte) (publicKey []byte, chainCode []byte, err error) {
+	d := newResponseDecoder(response)
+	if publicKey, err = d.lengthPrefixed(); err != nil {
+		return nil, nil, err
+	}
+	if chainCode, err = d.next(chainCodeLen); err != nil {
+		return nil, nil, err
+	}
+	return publicKey, chainCode, nil
+}
+
+// parseAppInfoResponse parses [format | nameLen | name | versionLen | version | flagsLen | flags]
+func parseAppInfoResponse(response []byte) (*AppInfo, error) {
+	d := newResponseDecoder(response)
+	if format, err := d.byte(); err != nil || format != 1 {
+		return nil, ErrMalformedResponse
+	}
+
+	values := make([][]byte, 3)
+	for i := range values {
+		value, err := d.lengthPrefixed()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	return &AppInfo{
+		Name:    string(values[0]),
+		Version: string(values[1]),
+		Flags:   append([]byte{}, values[2]...),
+	}, nil
+}
+
+// parseDeviceInfoResponse parses
+// [targetId | seVersionLen | seVersion | flagsLen | flags | mcuVersionLen | mcuVersion]
+func parseDeviceInfoResponse(response []byte) (*FirmwareInfo, error) {
+	d := newResponseDecoder(response)
+	targetID, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, 3)
+	for i := range values {
+		value, err := d.lengthPrefixed()
+		if err != nil {
+			return nil, err
+		}
+		values[i] = append([]byte{}, value...)
+	}
+
+	return &FirmwareInfo{
+		TargetID:  targetID,
+		SEVersion: string(values[0]),
+		Flags:     values[1],
+		// the MCU version is NUL terminated
+		MCUVersion: string(bytes.TrimRight(values[2], "\x00")),
+	}, nil
+}
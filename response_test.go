@@ -0,0 +1,107 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zondax/ledger-avalanche-go/decode"
+)
+
+func Test_ResponseDecoder(t *testing.T) {
+	d := newResponseDecoder([]byte{2, 0xaa, 0xbb, 0xcc})
+
+	value, err := d.lengthPrefixed()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xaa, 0xbb}, value)
+
+	_, err = d.next(2)
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+	assert.Equal(t, []byte{0xcc}, d.rest())
+
+	_, err = d.byte()
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func Test_ParsePubKeyResponseTruncated(t *testing.T) {
+	_, _, err := parsePubKeyResponse([]byte{33, 2, 3})
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+
+	_, _, err = parsePubKeyResponse(nil)
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func FuzzParseVersionResponse(f *testing.F) {
+	f.Add([]byte{0, 0, 6, 5, 0, 0x33, 0, 0, 4})
+	f.Add([]byte{0, 0, 6})
+	f.Fuzz(func(t *testing.T, response []byte) {
+		_, _, err := parseVersionResponse(response)
+		if len(response) >= 4 {
+			assert.NoError(t, err)
+		}
+	})
+}
+
+func FuzzParsePubKeyResponse(f *testing.F) {
+	f.Add(append(append([]byte{33}, make([]byte, 33)...), make([]byte, 20)...))
+	f.Add([]byte{0xff, 1, 2})
+	f.Fuzz(func(t *testing.T, response []byte) {
+		publicKey, hash, err := parsePubKeyResponse(response)
+		if err == nil {
+			assert.Equal(t, len(response), 1+len(publicKey)+len(hash))
+		}
+	})
+}
+
+func FuzzParseExtendedPubKeyResponse(f *testing.F) {
+	f.Add(append(append([]byte{33}, make([]byte, 33)...), make([]byte, chainCodeLen)...))
+	f.Add([]byte{33, 2})
+	f.Fuzz(func(t *testing.T, response []byte) {
+		_, chainCode, err := parseExtendedPubKeyResponse(response)
+		if err == nil {
+			assert.Len(t, chainCode, chainCodeLen)
+		}
+	})
+}
+
+func FuzzParseAppInfoResponse(f *testing.F) {
+	f.Add([]byte{1, 9, 'A', 'v', 'a', 'l', 'a', 'n', 'c', 'h', 'e', 5, '0', '.', '6', '.', '5', 2, 0x84, 0x01})
+	f.Add([]byte{1, 0xff})
+	f.Fuzz(func(t *testing.T, response []byte) {
+		_, _ = parseAppInfoResponse(response)
+	})
+}
+
+func FuzzParseDeviceInfoResponse(f *testing.F) {
+	f.Add([]byte{0x33, 0, 0, 4, 5, '2', '.', '1', '.', '0', 4, 0x80, 0, 0, 0, 4, '4', '.', '0', 0})
+	f.Add([]byte{0x33, 0, 0})
+	f.Fuzz(func(t *testing.T, response []byte) {
+		_, _ = parseDeviceInfoResponse(response)
+	})
+}
+
+func FuzzOutputAddresses(f *testing.F) {
+	f.Add(baseTx(make([]byte, 20)))
+	f.Add([]byte{0, 0, 0, 0, 0, 34, 0xff})
+	f.Fuzz(func(t *testing.T, unsignedTx []byte) {
+		_, _ = OutputAddresses(unsignedTx, decode.PChain)
+		_, _, _ = CrossChainIDs(unsignedTx, decode.PChain)
+	})
+}
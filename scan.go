@@ -0,0 +1,89 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	externalChain = 0
+	changeChain   = 1
+)
+
+// ScanAccount runs the BIP44 gap limit discovery over the external (0/i) and change (1/i) chains
+// of accountPath (e.g "m/44'/9000'/0'"). Addresses are derived on each chain until gapLimit
+// consecutive addresses have no activity according to hasActivity, which is called with the
+// bech32 encoded address. All derived addresses are returned, including the trailing inactive ones.
+func (ledger *LedgerAvalanche) ScanAccount(accountPath string, hrp, chainid string, gapLimit int, hasActivity func(addr string) bool) (external, change []AddressResponse, err error) {
+	if gapLimit <= 0 {
+		return nil, nil, errors.New("gap limit should be greater than 0")
+	}
+
+	external, err = ledger.scanChain(accountPath, externalChain, hrp, chainid, gapLimit, hasActivity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	change, err = ledger.scanChain(accountPath, changeChain, hrp, chainid, gapLimit, hasActivity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return external, change, nil
+}
+
+func (ledger *LedgerAvalanche) scanChain(accountPath string, chain int, hrp, chainid string, gapLimit int, hasActivity func(addr string) bool) ([]AddressResponse, error) {
+	addressHrp := hrp
+	if addressHrp == "" {
+		addressHrp = DefaultHRP
+	}
+
+	var addresses []AddressResponse
+	for index, inactive := 0, 0; inactive < gapLimit; index++ {
+		path := fmt.Sprintf("%s/%d/%d", accountPath, chain, index)
+
+		publicKey, hash, err := ledger.GetPubKey(path, false, hrp, chainid)
+		if err != nil {
+			return nil, err
+		}
+		if len(hash) < 20 {
+			return nil, ErrMalformedResponse
+		}
+
+		address, err := encodeBech32(addressHrp, hash[:20])
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, AddressResponse{
+			Path:      path,
+			PublicKey: publicKey,
+			Hash:      hash[:20],
+			Address:   address,
+		})
+
+		if hasActivity(address) {
+			inactive = 0
+		} else {
+			inactive++
+		}
+	}
+
+	return addresses, nil
+}
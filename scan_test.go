@@ -0,0 +1,72 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pubKeyByPath answers GET_ADDR with a hash made of the change and index of the requested path
+func pubKeyByPath(apdu []byte) ([]byte, error) {
+	change := binary.BigEndian.Uint32(apdu[len(apdu)-8:])
+	index := binary.BigEndian.Uint32(apdu[len(apdu)-4:])
+
+	hash := make([]byte, 20)
+	hash[0] = byte(change)
+	hash[1] = byte(index)
+
+	response := append([]byte{33}, bytes.Repeat([]byte{0x02}, 33)...)
+	return append(response, hash...), nil
+}
+
+func Test_ScanAccount(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: pubKeyByPath})
+
+	active := map[string]bool{}
+	for _, index := range []byte{0, 1, 3} {
+		hash := make([]byte, 20)
+		hash[1] = index
+		address, _ := encodeBech32("avax", hash)
+		active[address] = true
+	}
+
+	external, change, err := ledger.ScanAccount("m/44'/9000'/0'", "", "", 2, func(addr string) bool {
+		return active[addr]
+	})
+	require.NoError(t, err)
+
+	require.Len(t, external, 6)
+	assert.Equal(t, "m/44'/9000'/0'/0/0", external[0].Path)
+	assert.Equal(t, "m/44'/9000'/0'/0/5", external[5].Path)
+	assert.True(t, active[external[3].Address])
+
+	require.Len(t, change, 2)
+	assert.Equal(t, "m/44'/9000'/0'/1/1", change[1].Path)
+	assert.Equal(t, byte(1), change[1].Hash[0])
+}
+
+func Test_ScanAccountInvalidGap(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: pubKeyByPath})
+
+	_, _, err := ledger.ScanAccount("m/44'/9000'/0'", "", "", 0, func(string) bool { return false })
+	assert.Error(t, err)
+}
@@ -0,0 +1,212 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package serialize encodes derivation paths, HRPs and chain IDs in the wire format of the
+// Avalanche app, and decodes them back.
+package serialize
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mr-tron/base58"
+)
+
+const (
+	// Hardened is added to the child number of hardened path components
+	Hardened = 0x80000000
+	// MaxPathDepth is the deepest path accepted, as in the Ledger apps
+	MaxPathDepth = 10
+	// MaxHrpLength is the longest HRP allowed by BIP-173
+	MaxHrpLength = 83
+	// ChainIDLength is the length of a decoded chain ID
+	ChainIDLength = 32
+)
+
+// ErrInvalidPathComponent is returned when a component of a BIP32 path is not a valid child number
+var ErrInvalidPathComponent = errors.New("invalid path component")
+
+// ErrMalformed is returned when decoding a buffer not produced by this package
+var ErrMalformed = errors.New("malformed serialized value")
+
+// ParsePath parses a BIP32 path of any depth up to MaxPathDepth (e.g "m/44'/9000'/0'/0/3")
+// into its child numbers. Hardened components are marked with ', h or H.
+// Invalid components are reported with ErrInvalidPathComponent.
+func ParsePath(path string) ([]uint32, error) {
+	pathArray := strings.Split(path, "/")
+	if pathArray[0] != "m" {
+		return nil, errors.New(`Path should start with "m" (e.g "m/44\'/5757\'/5\'/0/3")`)
+	}
+	pathArray = pathArray[1:]
+
+	if len(pathArray) == 0 || len(pathArray) > MaxPathDepth {
+		return nil, fmt.Errorf("Invalid path: depth should be between 1 and %d. (e.g \"m/44'/5757'/5'/0/3\")", MaxPathDepth)
+	}
+
+	components := make([]uint32, len(pathArray))
+	for i, child := range pathArray {
+		var value uint32
+		if strings.HasSuffix(child, "'") || strings.HasSuffix(child, "h") || strings.HasSuffix(child, "H") {
+			value = Hardened
+			child = child[:len(child)-1]
+		}
+
+		childNumber, err := strconv.ParseUint(child, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w %d: %q is not a number. (e.g \"m/44'/5757'/5'/0/3\")", ErrInvalidPathComponent, i+1, pathArray[i])
+		}
+		if childNumber >= Hardened {
+			return nil, fmt.Errorf("%w %d: %q is bigger or equal to 0x80000000", ErrInvalidPathComponent, i+1, pathArray[i])
+		}
+
+		components[i] = value + uint32(childNumber)
+	}
+
+	return components, nil
+}
+
+// FormatPath formats child numbers as a BIP32 path, marking hardened components with '
+func FormatPath(components []uint32) string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, value := range components {
+		if value >= Hardened {
+			fmt.Fprintf(&sb, "/%d'", value-Hardened)
+		} else {
+			fmt.Fprintf(&sb, "/%d", value)
+		}
+	}
+	return sb.String()
+}
+
+// Path serializes a BIP32 path as [depth | child numbers, big endian]
+func Path(path string) ([]byte, error) {
+	components, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return serializeComponents(components), nil
+}
+
+func serializeComponents(components []uint32) []byte {
+	buf := make([]byte, 1+len(components)*4)
+	buf[0] = byte(len(components)) // first byte is the path length
+
+	for i, value := range components {
+		binary.BigEndian.PutUint32(buf[1+4*i:1+4*(i+1)], value)
+	}
+	return buf
+}
+
+// DecodePath returns the path serialized by Path or PathSuffix
+func DecodePath(buf []byte) (string, error) {
+	if len(buf) == 0 || len(buf) != 1+4*int(buf[0]) {
+		return "", ErrMalformed
+	}
+
+	components := make([]uint32, buf[0])
+	for i := range components {
+		components[i] = binary.BigEndian.Uint32(buf[1+4*i:])
+	}
+	return FormatPath(components), nil
+}
+
+// PathSuffix serializes the non-hardened [change / index] suffix of a signing path (e.g "0/3")
+func PathSuffix(path string) ([]byte, error) {
+	if strings.HasPrefix(path, "m") {
+		return nil, errors.New(`Path suffix do not start with "m" (e.g "0/3")`)
+	}
+
+	pathArray := strings.Split(path, "/")
+	if len(pathArray) != 2 {
+		return nil, errors.New(`Invalid path suffix. (e.g "0/3")`)
+	}
+
+	components := make([]uint32, len(pathArray))
+	for i, child := range pathArray {
+		if strings.HasSuffix(child, "'") {
+			return nil, errors.New(`Invalid hardened path suffix. (e.g "0/3")`)
+		}
+		childNumber, err := strconv.Atoi(child)
+		if err != nil {
+			return nil, errors.New(`Invalid path: ` + child + ` is not a number. (e.g "0/3")`)
+		}
+		if childNumber < 0 || childNumber >= Hardened {
+			return nil, errors.New(`Incorrect child value (bigger or equal to 0x80000000)`)
+		}
+		components[i] = uint32(childNumber)
+	}
+
+	return serializeComponents(components), nil
+}
+
+// ChainID serializes a CB58 chain ID as [length | chain ID]. The empty chain ID, which selects
+// the P-chain, is serialized with a zero length. Errors wrap ErrInvalidChainID.
+func ChainID(chainID string) ([]byte, error) {
+	if chainID == "" {
+		return []byte{0}, nil
+	}
+
+	decoded, err := base58.Decode(chainID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChainID, err)
+	}
+
+	if len(decoded) == ChainIDLength+4 {
+		// chop checksum off
+		decoded = decoded[:ChainIDLength]
+	} else if len(decoded) != ChainIDLength {
+		return nil, fmt.Errorf("%w: ChainID was not 32 bytes long (encoded with base58)", ErrInvalidChainID)
+	}
+
+	return append([]byte{byte(len(decoded))}, decoded...), nil
+}
+
+// Hrp serializes an HRP as [length | hrp]. As in BIP-173, it must be at most MaxHrpLength
+// characters in the [33, 126] range, not mixing cases. The empty HRP, which selects the app
+// default, is serialized with a zero length. Errors wrap ErrInvalidHrp.
+func Hrp(hrp string) ([]byte, error) {
+	if hrp == "" {
+		return []byte{0}, nil
+	}
+	if len(hrp) > MaxHrpLength {
+		return nil, fmt.Errorf("%w: the HRP must be at most %d characters long", ErrInvalidHrp, MaxHrpLength)
+	}
+
+	bufHrp := make([]byte, 0, len(hrp))
+	for _, c := range hrp {
+		if c < 33 || c > 126 {
+			return nil, fmt.Errorf("%w: all characters in the HRP must be in the [33, 126] range", ErrInvalidHrp)
+		}
+		bufHrp = append(bufHrp, byte(c))
+	}
+	if strings.ToLower(hrp) != hrp && strings.ToUpper(hrp) != hrp {
+		return nil, fmt.Errorf("%w: the HRP must not mix upper and lower case", ErrInvalidHrp)
+	}
+
+	return append([]byte{byte(len(bufHrp))}, bufHrp...), nil
+}
+
+// DecodeHrp returns the HRP serialized by Hrp
+func DecodeHrp(buf []byte) (string, error) {
+	if len(buf) == 0 || len(buf) != 1+int(buf[0]) {
+		return "", ErrMalformed
+	}
+	return string(buf[1:]), nil
+}
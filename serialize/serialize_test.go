@@ -0,0 +1,158 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package serialize
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pathComponents generates paths of 1 to MaxPathDepth child numbers
+type pathComponents []uint32
+
+func (pathComponents) Generate(r *rand.Rand, _ int) reflect.Value {
+	components := make(pathComponents, 1+r.Intn(MaxPathDepth))
+	for i := range components {
+		components[i] = r.Uint32()
+	}
+	return reflect.ValueOf(components)
+}
+
+// bech32Hrp generates valid HRPs of 1 to MaxHrpLength characters
+type bech32Hrp string
+
+func (bech32Hrp) Generate(r *rand.Rand, _ int) reflect.Value {
+	hrp := make([]byte, 1+r.Intn(MaxHrpLength))
+	for i := range hrp {
+		// the [33, 126] range, lower cased
+		c := byte(33 + r.Intn(94))
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		hrp[i] = c
+	}
+	return reflect.ValueOf(bech32Hrp(hrp))
+}
+
+func Test_PathRoundTrip(t *testing.T) {
+	roundTrip := func(components pathComponents) bool {
+		path := FormatPath(components)
+		serialized, err := Path(path)
+		if err != nil || len(serialized) != 1+4*len(components) {
+			return false
+		}
+		decoded, err := DecodePath(serialized)
+		return err == nil && decoded == path
+	}
+	require.NoError(t, quick.Check(roundTrip, nil))
+}
+
+func Test_HrpRoundTrip(t *testing.T) {
+	roundTrip := func(hrp bech32Hrp) bool {
+		serialized, err := Hrp(string(hrp))
+		if err != nil {
+			return false
+		}
+		decoded, err := DecodeHrp(serialized)
+		return err == nil && decoded == string(hrp)
+	}
+	require.NoError(t, quick.Check(roundTrip, nil))
+}
+
+func Test_PathBoundaries(t *testing.T) {
+	serialized, err := Path("m/2147483647'/2147483647")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{2, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}, serialized)
+
+	_, err = Path("m/2147483648")
+	assert.ErrorIs(t, err, ErrInvalidPathComponent)
+	_, err = Path("m/-1")
+	assert.ErrorIs(t, err, ErrInvalidPathComponent)
+
+	_, err = Path("m")
+	assert.Error(t, err)
+	_, err = Path("m" + strings.Repeat("/0", MaxPathDepth))
+	assert.NoError(t, err)
+	_, err = Path("m" + strings.Repeat("/0", MaxPathDepth+1))
+	assert.Error(t, err)
+}
+
+func Test_PathSuffix(t *testing.T) {
+	serialized, err := PathSuffix("1/2147483647")
+	require.NoError(t, err)
+	decoded, err := DecodePath(serialized)
+	require.NoError(t, err)
+	assert.Equal(t, "m/1/2147483647", decoded)
+
+	for _, invalid := range []string{"m/0/1", "0", "0/1/2", "0'/1", "0/2147483648", "0/-1", "a/1"} {
+		_, err = PathSuffix(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_HrpValidation(t *testing.T) {
+	serialized, err := Hrp("")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0}, serialized)
+
+	_, err = Hrp(strings.Repeat("a", MaxHrpLength))
+	assert.NoError(t, err)
+	_, err = Hrp("AVAX")
+	assert.NoError(t, err)
+
+	for _, invalid := range []string{
+		strings.Repeat("a", MaxHrpLength+1),
+		"av ax",
+		"avax\x7f",
+		"Avax",
+		"avaxé",
+	} {
+		_, err = Hrp(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_ChainID(t *testing.T) {
+	serialized, err := ChainID("2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByM")
+	require.NoError(t, err)
+	assert.Len(t, serialized, 1+ChainIDLength)
+	assert.Equal(t, byte(ChainIDLength), serialized[0])
+
+	serialized, err = ChainID("")
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0}, serialized)
+
+	_, err = ChainID("2oYMBNV4")
+	assert.Error(t, err)
+}
+
+func Test_DecodeMalformed(t *testing.T) {
+	for _, buf := range [][]byte{nil, {1}, {1, 0, 0, 0}, {0, 0}} {
+		_, err := DecodePath(buf)
+		assert.ErrorIs(t, err, ErrMalformed, buf)
+	}
+	for _, buf := range [][]byte{nil, {2, 'a'}} {
+		_, err := DecodeHrp(buf)
+		assert.ErrorIs(t, err, ErrMalformed, buf)
+	}
+}
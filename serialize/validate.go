@@ -0,0 +1,123 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package serialize
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/mr-tron/base58"
+)
+
+// ErrInvalidHrp is returned when an HRP cannot be serialized, or is refused by ValidateHrp
+var ErrInvalidHrp = errors.New("invalid HRP")
+
+// ErrInvalidChainID is returned when a chain ID cannot be serialized, or is refused by
+// ValidateChainID
+var ErrInvalidChainID = errors.New("invalid chain ID")
+
+// PChainID is the chain ID of the P-chain, the same on every network. The empty chain ID also
+// selects it.
+const PChainID = "11111111111111111111111111111111LpoYY"
+
+// primaryNetworkChains maps the HRP of each network to the chain IDs of its X- and C-chains,
+// by alias
+var primaryNetworkChains = map[string]map[string]string{
+	"avax": {
+		"X": "2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByM",
+		"C": "2q9e4r6Mu3U68nU1fYjgbR6JvwrRx36CohpAX5UQxse55x1Q5",
+	},
+	"fuji": {
+		"X": "2JVSBoinj9C2J33VntvzYtVJNZdN2NKiwwKjcumHUWEb5DbBrm",
+		"C": "yH8D7ThNJkxmtkuv2jgBa4P1Rn3Qpr4pPr7QYNfcdoS6k6HWp",
+	},
+}
+
+// ResolveChainAlias returns the chain ID of the alias "X" or "C" on the network of hrp (mainnet
+// when hrp is empty), the empty chain ID for "P", or chainID itself when it is not an alias.
+// The X- and C-chain aliases of a network without known chain IDs fail with ErrInvalidChainID.
+func ResolveChainAlias(chainID, hrp string) (string, error) {
+	switch chainID {
+	case "P":
+		return "", nil
+	case "X", "C":
+	default:
+		return chainID, nil
+	}
+
+	if hrp == "" {
+		hrp = "avax"
+	}
+	chains, ok := primaryNetworkChains[hrp]
+	if !ok {
+		return "", fmt.Errorf("%w: the %s-chain of HRP %q is unknown", ErrInvalidChainID, chainID, hrp)
+	}
+	return chains[chainID], nil
+}
+
+// ChainAlias returns the alias ("P", "X" or "C") of a primary network chain ID of a known
+// network, or chainID itself. The empty chain ID is the P-chain.
+func ChainAlias(chainID string) string {
+	if chainID == "" || chainID == PChainID {
+		return "P"
+	}
+	for _, chains := range primaryNetworkChains {
+		for alias, id := range chains {
+			if id == chainID {
+				return alias
+			}
+		}
+	}
+	return chainID
+}
+
+// ValidateHrp checks that hrp is empty or made of lowercase letters and digits, as the HRPs of
+// Avalanche addresses are. Hrp accepts any HRP allowed by BIP-173.
+func ValidateHrp(hrp string) error {
+	if len(hrp) > MaxHrpLength {
+		return fmt.Errorf("%w: longer than %d characters", ErrInvalidHrp, MaxHrpLength)
+	}
+	for _, c := range hrp {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return fmt.Errorf("%w: %q is not a lowercase letter or digit", ErrInvalidHrp, c)
+		}
+	}
+	return nil
+}
+
+// ValidateChainID checks that chainID is empty or a CB58 chain ID with a valid checksum. ChainID
+// also accepts chain IDs encoded without checksum. Aliases must be resolved first, see
+// ResolveChainAlias.
+func ValidateChainID(chainID string) error {
+	if chainID == "" {
+		return nil
+	}
+	decoded, err := base58.Decode(chainID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChainID, err)
+	}
+	if len(decoded) != ChainIDLength+4 {
+		return fmt.Errorf("%w: not a CB58 encoded %d-byte chain ID", ErrInvalidChainID, ChainIDLength)
+	}
+	checksum := sha256.Sum256(decoded[:ChainIDLength])
+	if !bytes.Equal(checksum[len(checksum)-4:], decoded[ChainIDLength:]) {
+		return fmt.Errorf("%w: wrong checksum", ErrInvalidChainID)
+	}
+	return nil
+}
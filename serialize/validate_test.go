@@ -0,0 +1,98 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package serialize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ValidateHrp(t *testing.T) {
+	for _, valid := range []string{"", "avax", "fuji", "local", "custom1"} {
+		assert.NoError(t, ValidateHrp(valid), valid)
+	}
+	for _, invalid := range []string{"AVAX", "av-ax", "avax!", "avaxé", strings.Repeat("a", MaxHrpLength+1)} {
+		assert.ErrorIs(t, ValidateHrp(invalid), ErrInvalidHrp, invalid)
+	}
+}
+
+func Test_ValidateChainID(t *testing.T) {
+	for _, valid := range []string{
+		"",
+		"11111111111111111111111111111111LpoYY",
+		"2JVSBoinj9C2J33VntvzYtVJNZdN2NKiwwKjcumHUWEb5DbBrm",
+	} {
+		assert.NoError(t, ValidateChainID(valid), valid)
+	}
+	for _, invalid := range []string{
+		"p",
+		// aliases are resolved first, see ResolveChainAlias
+		"X",
+		"2oYMBNV4",
+		// valid base58 of 32 bytes, without checksum
+		"3qbR1eZRqXUWroWKKYhbDmR3FfqTHfqSU8zZSxtANzYh",
+		// last character changed
+		"2oYMBNV4eNHyqk2fjjV5nVQLDbtmNJzq5s3qs3Lo6ftnC6FByN",
+		"0OIl",
+	} {
+		assert.ErrorIs(t, ValidateChainID(invalid), ErrInvalidChainID, invalid)
+	}
+}
+
+func Test_ChainIDAliases(t *testing.T) {
+	for hrp, chains := range primaryNetworkChains {
+		for alias, chainID := range chains {
+			require.NoError(t, ValidateChainID(chainID), alias)
+
+			resolved, err := ResolveChainAlias(alias, hrp)
+			require.NoError(t, err)
+			assert.Equal(t, chainID, resolved, hrp+" "+alias)
+			assert.Equal(t, alias, ChainAlias(chainID))
+		}
+	}
+	require.NoError(t, ValidateChainID(PChainID))
+	assert.Equal(t, "P", ChainAlias(PChainID))
+	assert.Equal(t, "P", ChainAlias(""))
+
+	resolved, err := ResolveChainAlias("P", "fuji")
+	require.NoError(t, err)
+	assert.Equal(t, "", resolved)
+
+	resolved, err = ResolveChainAlias("X", "")
+	require.NoError(t, err)
+	assert.Equal(t, primaryNetworkChains["avax"]["X"], resolved)
+
+	_, err = ResolveChainAlias("C", "custom")
+	assert.ErrorIs(t, err, ErrInvalidChainID)
+
+	resolved, err = ResolveChainAlias("2oYMBNV4", "custom")
+	require.NoError(t, err)
+	assert.Equal(t, "2oYMBNV4", resolved)
+	assert.Equal(t, "unknown", ChainAlias("unknown"))
+}
+
+func Test_SerializeErrorsAreTyped(t *testing.T) {
+	_, err := ChainID("2oYMBNV4")
+	assert.ErrorIs(t, err, ErrInvalidChainID)
+	_, err = ChainID("0OIl")
+	assert.ErrorIs(t, err, ErrInvalidChainID)
+	_, err = Hrp("Avax")
+	assert.ErrorIs(t, err, ErrInvalidHrp)
+}
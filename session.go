@@ -0,0 +1,92 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"time"
+)
+
+// signingFlowKey marks the context of the signing flow holding the lock of a LedgerAvalanche
+type signingFlowKey struct{}
+
+// beginSigning takes the device for a signing flow, which spans several exchanges that must not
+// be interleaved with other operations. It fails with ErrBusy when a signing flow is already in
+// progress, otherwise it waits for the current exchange to complete. The returned context must
+// be used for the exchanges of the flow, and release called once it is over.
+func (ledger *LedgerAvalanche) beginSigning(ctx context.Context) (context.Context, func(), error) {
+	if !ledger.signing.CompareAndSwap(false, true) {
+		return nil, nil, ErrBusy
+	}
+	ledger.mu.Lock()
+
+	release := func() {
+		ledger.mu.Unlock()
+		ledger.signing.Store(false)
+	}
+	if err := ledger.waitPending(ctx); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return context.WithValue(ctx, signingFlowKey{}, ledger), release, nil
+}
+
+// lockExchange takes the device for a single exchange, unless ctx belongs to the signing flow
+// already holding it. It fails with ErrBusy while a signing flow is in progress.
+func (ledger *LedgerAvalanche) lockExchange(ctx context.Context) (func(), error) {
+	if ctx.Value(signingFlowKey{}) == ledger {
+		return func() {}, nil
+	}
+	if ledger.signing.Load() {
+		return nil, ErrBusy
+	}
+
+	ledger.mu.Lock()
+	if err := ledger.waitPending(ctx); err != nil {
+		ledger.mu.Unlock()
+		return nil, err
+	}
+	return ledger.mu.Unlock, nil
+}
+
+// waitPending waits for an exchange abandoned by a signing flow, which does not hold ledger.mu
+// once the flow is released, to complete before the device is used again. It gives up with
+// ErrExchangeTimeout after the exchange timeout, or once ctx is done.
+func (ledger *LedgerAvalanche) waitPending(ctx context.Context) error {
+	ledger.state.Lock()
+	pending := ledger.pending
+	ledger.state.Unlock()
+	if pending == nil {
+		return nil
+	}
+
+	var timeout <-chan time.Time
+	if ledger.exchangeTimeout > 0 {
+		timer := time.NewTimer(ledger.exchangeTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-pending:
+		return nil
+	case <-timeout:
+		return ErrExchangeTimeout
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,122 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ErrBusyWhileSigning(t *testing.T) {
+	reviewing := make(chan struct{})
+	approve := make(chan struct{})
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[1] == INS_SIGN_HASH && apdu[2] == FIRST_MESSAGE {
+			close(reviewing)
+			<-approve
+			return []byte{}, nil
+		}
+		return make([]byte, SIGNATURE_LEN), nil
+	}}
+	ledger := newMockLedger(device, AllowBlindSigning(true))
+
+	signed := make(chan error)
+	go func() {
+		_, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+		signed <- err
+	}()
+	<-reviewing
+
+	_, err := ledger.GetVersion()
+	assert.ErrorIs(t, err, ErrBusy)
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/1"}, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, ErrBusy)
+
+	close(approve)
+	require.NoError(t, <-signed)
+
+	_, err = ledger.GetWalletID()
+	assert.NoError(t, err)
+}
+
+func Test_ExchangesAreSerialized(t *testing.T) {
+	var inFlight, overlaps int32
+	device := &mockDevice{handler: func([]byte) ([]byte, error) {
+		if atomic.AddInt32(&inFlight, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return []byte{0, 0, 6, 5}, nil
+	}}
+	ledger := newMockLedger(device)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ledger.GetVersion()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Zero(t, atomic.LoadInt32(&overlaps))
+	assert.Len(t, device.sent, 8)
+}
+
+func Test_ExchangeWaitsForAbandonedFlowExchange(t *testing.T) {
+	hang := make(chan struct{})
+	var inFlight, overlaps int32
+	device := &mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if atomic.AddInt32(&inFlight, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		defer atomic.AddInt32(&inFlight, -1)
+		if apdu[1] == INS_SIGN {
+			<-hang
+			return []byte{}, nil
+		}
+		return []byte{0, 0, 6, 5}, nil
+	}}
+	ledger := newMockLedger(device, WithExchangeTimeout(10*time.Millisecond))
+
+	_, err := ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte{1, 2, 3}, nil)
+	require.ErrorIs(t, err, ErrExchangeTimeout)
+
+	// the signing flow is over but its exchange is still in progress
+	sentCount := func() int {
+		device.mu.Lock()
+		defer device.mu.Unlock()
+		return len(device.sent)
+	}
+	sent := sentCount()
+	_, err = ledger.GetVersion()
+	assert.ErrorIs(t, err, ErrExchangeTimeout)
+	assert.Equal(t, sent, sentCount())
+
+	close(hang)
+	_, err = ledger.GetVersion()
+	require.NoError(t, err)
+	assert.Zero(t, atomic.LoadInt32(&overlaps))
+}
@@ -0,0 +1,73 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"context"
+)
+
+// Proposed P2 bits of the payload chunks selecting how the device renders a transaction. The
+// app defines no such bits yet, so they stay unexported until it does and may change.
+const (
+	p2ExpertMode    = 0x01
+	p2DisplayMemo   = 0x02
+	p2DisplayRawHex = 0x04
+)
+
+// SignOptions controls how the device renders a transaction for review
+type SignOptions struct {
+	// ExpertMode shows the extended review screens, as if expert mode was enabled on the device
+	ExpertMode bool
+	// DisplayMemo shows the memo of the transaction
+	DisplayMemo bool
+	// DisplayRawHex shows the raw bytes of the transaction in hex along with its fields
+	DisplayRawHex bool
+}
+
+// p2 returns the P2 bits of the payload chunks selecting the options
+func (o SignOptions) p2() byte {
+	var p2 byte
+	if o.ExpertMode {
+		p2 |= p2ExpertMode
+	}
+	if o.DisplayMemo {
+		p2 |= p2DisplayMemo
+	}
+	if o.DisplayRawHex {
+		p2 |= p2DisplayRawHex
+	}
+	return p2
+}
+
+// signOptionsFeature is the rendering control of transactions with P2 bits. The app ignores the
+// P2 of payload chunks today, its review screens following the settings of the device only.
+var signOptionsFeature = appFeature{name: "sign display options"}
+
+// SignWithOptions works as Sign, forwarding opts to the app in the P2 bits of the payload chunks
+// so the caller controls how the transaction is rendered on the device. It fails with
+// ErrNotSupported when options are set and the app does not implement them, rather than
+// letting the user review a transaction rendered otherwise.
+func (ledger *LedgerAvalanche) SignWithOptions(pathPrefix string, signingPaths []string, message []byte, changePaths []string, opts SignOptions) (*ResponseSign, error) {
+	p2 := opts.p2()
+	if p2 != 0 {
+		if err := ledger.checkFeature(signOptionsFeature); err != nil {
+			return nil, err
+		}
+	}
+	return ledger.signStream(context.Background(), pathPrefix, signingPaths, changePaths, bytes.NewReader(message), len(message), p2)
+}
@@ -0,0 +1,65 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignOptionsP2(t *testing.T) {
+	assert.Equal(t, byte(0), SignOptions{}.p2())
+	assert.Equal(t, byte(p2ExpertMode|p2DisplayRawHex), SignOptions{ExpertMode: true, DisplayRawHex: true}.p2())
+	assert.Equal(t, byte(p2DisplayMemo), SignOptions{DisplayMemo: true}.p2())
+}
+
+func Test_SignWithOptions(t *testing.T) {
+	message := bytes.Repeat([]byte{0x01}, CHUNK_SIZE+1)
+
+	// no released app renders transactions with options
+	device := &mockDevice{handler: replies([]byte{0, 0, 6, 5})}
+	ledger := newMockLedger(device)
+	_, err := ledger.SignWithOptions("m/44'/9000'/0'", []string{"0/0"}, message, nil, SignOptions{DisplayMemo: true})
+	assert.ErrorIs(t, err, ErrNotSupported)
+	assert.Empty(t, device.sent)
+
+	device = &mockDevice{}
+	ledger = newMockLedger(device)
+	_, err = ledger.SignWithOptions("m/44'/9000'/0'", []string{"0/0"}, message, nil, SignOptions{})
+	require.NoError(t, err)
+
+	original := signOptionsFeature
+	signOptionsFeature = appFeature{name: original.name, since: &VersionInfo{0, 0, 6, 5}}
+	t.Cleanup(func() { signOptionsFeature = original })
+
+	device = &mockDevice{handler: replies([]byte{0, 0, 6, 5})}
+	ledger = newMockLedger(device)
+	_, err = ledger.SignWithOptions("m/44'/9000'/0'", []string{"0/0"}, message, nil, SignOptions{ExpertMode: true, DisplayMemo: true})
+	require.NoError(t, err)
+
+	var chunks int
+	for _, command := range device.sent {
+		if command[1] == INS_SIGN && (command[2] == PAYLOAD_ADD || command[2] == PAYLOAD_LAST) {
+			assert.Equal(t, byte(p2ExpertMode|p2DisplayMemo), command[3])
+			chunks++
+		}
+	}
+	assert.Equal(t, 2, chunks)
+}
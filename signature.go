@@ -0,0 +1,101 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+// Signature is a recoverable secp256k1 signature in the [r | s | v] layout returned by the
+// device, v being the recovery id. It is also the layout of avalanchego credentials.
+type Signature [SIGNATURE_LEN]byte
+
+// ParseSignature checks the length of a signature returned by the device
+func ParseSignature(signature []byte) (Signature, error) {
+	var sig Signature
+	if len(signature) != SIGNATURE_LEN {
+		return sig, fmt.Errorf("signature should be %d bytes long, got %d", SIGNATURE_LEN, len(signature))
+	}
+	copy(sig[:], signature)
+	return sig, nil
+}
+
+// R returns the r component
+func (sig Signature) R() []byte {
+	return append([]byte{}, sig[:32]...)
+}
+
+// S returns the s component
+func (sig Signature) S() []byte {
+	return append([]byte{}, sig[32:64]...)
+}
+
+// V returns the recovery id
+func (sig Signature) V() byte {
+	return sig[64]
+}
+
+// LowS returns the signature with s in the lower half of the curve order, see NormalizeLowS
+func (sig Signature) LowS() Signature {
+	var normalized Signature
+	copy(normalized[:], NormalizeLowS(sig[:]))
+	return normalized
+}
+
+// DER returns the DER encoding of r and s, without the recovery id
+func (sig Signature) DER() []byte {
+	var r, s btcec.ModNScalar
+	r.SetByteSlice(sig[:32])
+	s.SetByteSlice(sig[32:64])
+	return ecdsa.NewSignature(&r, &s).Serialize()
+}
+
+// Credential returns the signature in the [65]byte format of avalanchego credentials
+func (sig Signature) Credential() [SIGNATURE_LEN]byte {
+	return sig
+}
+
+// RecoverPublicKey returns the public key that made the signature over hash
+func (sig Signature) RecoverPublicKey(hash []byte) (*btcec.PublicKey, error) {
+	return RecoverPublicKey(sig[:], hash)
+}
+
+// Parsed returns the signature as a Signature
+func (p PathSignature) Parsed() (Signature, error) {
+	return ParseSignature(p.Signature)
+}
+
+// ForInputs returns the signature of each signing path in order, e.g. the signer of each input
+// of a transaction. Paths may repeat, when an address owns several inputs.
+func (response *ResponseSign) ForInputs(signingPaths []string) ([]Signature, error) {
+	signatures := make([]Signature, len(signingPaths))
+	for i, path := range signingPaths {
+		signature, ok := response.Signature[path]
+		if !ok {
+			return nil, fmt.Errorf("no signature for path %s (input %d)", path, i)
+		}
+
+		var err error
+		if signatures[i], err = ParseSignature(signature); err != nil {
+			return nil, err
+		}
+	}
+	return signatures, nil
+}
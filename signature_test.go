@@ -0,0 +1,82 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Signature(t *testing.T) {
+	publicKey, _ := hex.DecodeString("0339a36013301597daef41fbe593a02cc513d0b55527ec2df1050e2e8ff49c85c2")
+	raw, _ := hex.DecodeString("d6844ac6fc813791fcc9030566ae54b1b8f7a70d67fb3252d7a6096c8fe282153625aff848e6e787334f4bc7ee347a7be78857cec74d81ff61f594b261c731ff01")
+	hash := AvalancheMessageHash([]byte("Hello Avalanche!"))
+
+	sig, err := ParseSignature(raw)
+	require.NoError(t, err)
+	assert.Equal(t, raw[:32], sig.R())
+	assert.Equal(t, raw[32:64], sig.S())
+	assert.Equal(t, byte(1), sig.V())
+	credential := sig.Credential()
+	assert.Equal(t, raw, credential[:])
+
+	recovered, err := sig.RecoverPublicKey(hash)
+	require.NoError(t, err)
+	assert.Equal(t, publicKey, recovered.SerializeCompressed())
+
+	der, err := ecdsa.ParseDERSignature(sig.DER())
+	require.NoError(t, err)
+	assert.True(t, VerifySignature(publicKey, hash, raw[:64]))
+	assert.True(t, der.Verify(hash, recovered))
+
+	malleated, err := ParseSignature(highS(raw))
+	require.NoError(t, err)
+	assert.Equal(t, sig, malleated.LowS())
+	assert.Equal(t, sig, sig.LowS())
+
+	_, err = ParseSignature(raw[:64])
+	assert.Error(t, err)
+}
+
+func Test_PathSignatureParsed(t *testing.T) {
+	raw := make([]byte, SIGNATURE_LEN)
+	raw[64] = 1
+
+	sig, err := newPathSignature("0/0", raw, nil).Parsed()
+	require.NoError(t, err)
+	assert.Equal(t, byte(1), sig.V())
+}
+
+func Test_ForInputs(t *testing.T) {
+	first, second := make([]byte, SIGNATURE_LEN), make([]byte, SIGNATURE_LEN)
+	first[0], second[0] = 1, 2
+	response := &ResponseSign{Signature: map[string][]byte{"0/0": first, "0/1": second}}
+
+	signatures, err := response.ForInputs([]string{"0/1", "0/0", "0/1"})
+	require.NoError(t, err)
+	require.Len(t, signatures, 3)
+	assert.Equal(t, byte(2), signatures[0].R()[0])
+	assert.Equal(t, byte(1), signatures[1].R()[0])
+	assert.Equal(t, signatures[0], signatures[2])
+
+	_, err = response.ForInputs([]string{"0/2"})
+	assert.Error(t, err)
+}
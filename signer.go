@@ -0,0 +1,32 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+// AvalancheSigner is implemented by the Avalanche app clients, so code can work with a device,
+// an emulator or a software signer alike
+type AvalancheSigner interface {
+	GetVersion() (*VersionInfo, error)
+	GetPubKey(path string, show bool, hrp string, chainid string) (publicKey []byte, hash []byte, err error)
+	Sign(pathPrefix string, signingPaths []string, message []byte, changePaths []string) (*ResponseSign, error)
+	SignHash(pathPrefix string, signingPaths []string, hash []byte) (*ResponseSign, error)
+	Close() error
+}
+
+var (
+	_ AvalancheSigner = (*LedgerAvalanche)(nil)
+	_ AvalancheSigner = (*AutoReconnect)(nil)
+)
@@ -0,0 +1,128 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/zondax/ledger-go"
+)
+
+// SpeculosButton is a button of the device emulated by Speculos
+type SpeculosButton string
+
+const (
+	SpeculosLeft  SpeculosButton = "left"
+	SpeculosRight SpeculosButton = "right"
+	SpeculosBoth  SpeculosButton = "both"
+)
+
+// SpeculosDevice exchanges APDUs with a Speculos emulator through its REST API
+type SpeculosDevice struct {
+	url    string
+	client *http.Client
+}
+
+// NewSpeculosDevice connects to the REST API of a Speculos instance (by default on port 5000)
+func NewSpeculosDevice(host string, port int) *SpeculosDevice {
+	return &SpeculosDevice{
+		url:    fmt.Sprintf("http://%s:%d", host, port),
+		client: &http.Client{},
+	}
+}
+
+// FindLedgerAvalancheAppSpeculos finds the Avax user app running in a Speculos emulator.
+// Reviews must be approved with the button helpers of SpeculosDevice, from another goroutine.
+func FindLedgerAvalancheAppSpeculos(host string, port int, opts ...Option) (*LedgerAvalanche, error) {
+	return openApp(NewSpeculosDevice(host, port), opts...)
+}
+
+type speculosAPDU struct {
+	Data string `json:"data"`
+}
+
+func (d *SpeculosDevice) post(endpoint string, request interface{}, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.client.Post(d.url+endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("speculos %s: %s", endpoint, resp.Status)
+	}
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// Exchange sends an APDU and blocks until the emulated app answers, reporting status words
+// other than 0x9000 as ledger-go does
+func (d *SpeculosDevice) Exchange(command []byte) ([]byte, error) {
+	var response speculosAPDU
+	if err := d.post("/apdu", speculosAPDU{Data: hex.EncodeToString(command)}, &response); err != nil {
+		return nil, err
+	}
+
+	data, err := hex.DecodeString(response.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 {
+		return nil, errors.New("len(response) < 2")
+	}
+
+	swOffset := len(data) - 2
+	sw := binary.BigEndian.Uint16(data[swOffset:])
+	if sw != 0x9000 {
+		return data[:swOffset], errors.New(ledger_go.ErrorMessage(sw))
+	}
+	return data[:swOffset], nil
+}
+
+func (d *SpeculosDevice) Close() error {
+	d.client.CloseIdleConnections()
+	return nil
+}
+
+// PressButton presses and releases a button of the emulated device
+func (d *SpeculosDevice) PressButton(button SpeculosButton) error {
+	return d.post("/button/"+string(button), map[string]string{"action": "press-and-release"}, nil)
+}
+
+// Approve goes through a review by pressing the right button for each of the screens
+// before the approval one, then both buttons to approve
+func (d *SpeculosDevice) Approve(screens int) error {
+	for i := 0; i < screens; i++ {
+		if err := d.PressButton(SpeculosRight); err != nil {
+			return err
+		}
+	}
+	return d.PressButton(SpeculosBoth)
+}
@@ -0,0 +1,84 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSpeculosServer serves the Speculos REST API, answering APDUs with handler
+func newSpeculosServer(t *testing.T, handler func(apdu []byte) []byte, buttons *[]string) (string, int) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apdu", func(w http.ResponseWriter, r *http.Request) {
+		var request speculosAPDU
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		apdu, _ := hex.DecodeString(request.Data)
+		_ = json.NewEncoder(w).Encode(speculosAPDU{Data: hex.EncodeToString(handler(apdu))})
+	})
+	mux.HandleFunc("/button/", func(w http.ResponseWriter, r *http.Request) {
+		*buttons = append(*buttons, r.URL.Path)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return host, portNumber
+}
+
+func Test_FindLedgerAvalancheAppSpeculos(t *testing.T) {
+	var buttons []string
+	host, port := newSpeculosServer(t, func(apdu []byte) []byte {
+		if apdu[1] == INS_GET_VERSION {
+			return []byte{0, 0, 6, 5, 0, 0x90, 0x00}
+		}
+		return []byte{0x6a, 0x80}
+	}, &buttons)
+
+	app, err := FindLedgerAvalancheAppSpeculos(host, port)
+	require.NoError(t, err)
+	defer app.Close()
+	assert.Equal(t, VersionInfo{0, 0, 6, 5}, app.version)
+
+	_, err = app.GetWalletID()
+	assert.True(t, isStatus(err, DataIsInvalid))
+
+	device := NewSpeculosDevice(host, port)
+	require.NoError(t, device.Approve(2))
+	assert.Equal(t, []string{"/button/right", "/button/right", "/button/both"}, buttons)
+}
+
+func Test_FindLedgerAvalancheAppSpeculosWrongApp(t *testing.T) {
+	var buttons []string
+	host, port := newSpeculosServer(t, func([]byte) []byte {
+		return []byte{0x6e, 0x00}
+	}, &buttons)
+
+	_, err := FindLedgerAvalancheAppSpeculos(host, port)
+	assert.ErrorIs(t, err, ErrAppNotOpen)
+	assert.Contains(t, err.Error(), "are you sure the Avalanche app is open?")
+}
@@ -0,0 +1,94 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/zondax/ledger-go"
+)
+
+// DefaultTCPPort is the port on which Speculos serves APDUs over TCP
+const DefaultTCPPort = 9999
+
+// maxTCPResponseLen bounds the data of a response read over TCP: the longest an extended length
+// APDU may ask for
+const maxTCPResponseLen = 65536
+
+// TCPDevice exchanges APDUs over TCP with the Ledger framing used by Speculos and APDU bridges,
+// so the device can be attached to another machine than the one signing
+type TCPDevice struct {
+	conn net.Conn
+}
+
+// NewTCPDevice connects to an APDU server at host:port
+func NewTCPDevice(host string, port int) (*TCPDevice, error) {
+	conn, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	return &TCPDevice{conn: conn}, nil
+}
+
+// FindLedgerAvalancheAppTCP finds the Avax user app running in a device served at host:port
+func FindLedgerAvalancheAppTCP(host string, port int, opts ...Option) (*LedgerAvalanche, error) {
+	device, err := NewTCPDevice(host, port)
+	if err != nil {
+		return nil, err
+	}
+	return openApp(device, opts...)
+}
+
+// Exchange sends [length | APDU] and reads the [length | data | status word] answer,
+// reporting status words other than 0x9000 as ledger-go does
+func (d *TCPDevice) Exchange(command []byte) ([]byte, error) {
+	request := binary.BigEndian.AppendUint32(nil, uint32(len(command)))
+	if _, err := d.conn.Write(append(request, command...)); err != nil {
+		return nil, err
+	}
+
+	var header [4]byte
+	if _, err := io.ReadFull(d.conn, header[:]); err != nil {
+		return nil, err
+	}
+
+	// the length does not count the status word
+	length := binary.BigEndian.Uint32(header[:])
+	if length > maxTCPResponseLen {
+		return nil, fmt.Errorf("%w: response of %d bytes", ErrMalformedResponse, length)
+	}
+	data := make([]byte, int(length)+2)
+	if _, err := io.ReadFull(d.conn, data); err != nil {
+		return nil, err
+	}
+
+	swOffset := len(data) - 2
+	sw := binary.BigEndian.Uint16(data[swOffset:])
+	if sw != 0x9000 {
+		return data[:swOffset], errors.New(ledger_go.ErrorMessage(sw))
+	}
+	return data[:swOffset], nil
+}
+
+func (d *TCPDevice) Close() error {
+	return d.conn.Close()
+}
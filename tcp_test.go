@@ -0,0 +1,123 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveAPDUs answers the APDUs received on a local TCP port with handler, which returns the
+// response data and status word
+func serveAPDUs(t *testing.T, handler func(apdu []byte) ([]byte, uint16)) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var header [4]byte
+			if _, err := io.ReadFull(conn, header[:]); err != nil {
+				return
+			}
+			apdu := make([]byte, binary.BigEndian.Uint32(header[:]))
+			if _, err := io.ReadFull(conn, apdu); err != nil {
+				return
+			}
+
+			data, sw := handler(apdu)
+			response := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+			response = binary.BigEndian.AppendUint16(append(response, data...), sw)
+			if _, err := conn.Write(response); err != nil {
+				return
+			}
+		}
+	}()
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func Test_TCPDevice(t *testing.T) {
+	port := serveAPDUs(t, func(apdu []byte) ([]byte, uint16) {
+		switch apdu[1] {
+		case INS_GET_VERSION:
+			return []byte{0, 0, 6, 5}, 0x9000
+		case INS_WALLET_ID:
+			return []byte{1, 2, 3, 4, 5, 6}, 0x9000
+		}
+		return nil, uint16(TransactionRejected)
+	})
+
+	ledger, err := FindLedgerAvalancheAppTCP("127.0.0.1", port, AllowBlindSigning(true))
+	require.NoError(t, err)
+	defer ledger.Close()
+
+	walletID, err := ledger.GetWalletID()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6}, walletID)
+
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, ErrUserRejected)
+}
+
+func Test_TCPDeviceUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	_, err = FindLedgerAvalancheAppTCP("127.0.0.1", port)
+	assert.Error(t, err)
+}
+
+func Test_TCPDeviceHostileLength(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var header [4]byte
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		_, _ = io.CopyN(io.Discard, conn, int64(binary.BigEndian.Uint32(header[:])))
+		_, _ = conn.Write([]byte{0xff, 0xff, 0xff, 0xff, 0x90, 0x00})
+	}()
+
+	device, err := NewTCPDevice("127.0.0.1", listener.Addr().(*net.TCPAddr).Port)
+	require.NoError(t, err)
+	defer device.Close()
+
+	_, err = device.Exchange([]byte{CLA, INS_GET_VERSION, 0, 0, 0})
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
@@ -0,0 +1,147 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package testvectors pins the keys, addresses and signatures the mock package returns for the
+// mnemonic of the Zemu and Speculos test devices, so tests built on the mock notice when its
+// derivation or signing changes.
+//
+// The vectors were recorded from the mock itself and were not checked against the app or an
+// emulator: they are regression fixtures, not reference vectors, and matching them says nothing
+// about agreeing with a real device.
+package testvectors
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	ledger "github.com/zondax/ledger-avalanche-go"
+)
+
+// Mnemonic is the seed of the Zemu and Speculos test devices. Never use it to hold funds.
+const Mnemonic = "equip will roof matter pink blind book anxiety banner elbow sun young"
+
+// ErrVectorMismatch is returned by VerifyAgainstVectors when the signer disagrees with a vector
+var ErrVectorMismatch = errors.New("device does not match the test vectors")
+
+// KeyVector is the public key, hex encoded and compressed, and the P-chain mainnet address at Path
+type KeyVector struct {
+	Path      string
+	PublicKey string
+	Address   string
+}
+
+// SignatureVector is the signature of Message by the key at Path, signed as an Avalanche message
+// (see ledger.AvalancheMessageHash). Hash and Signature are hex encoded, Signature as [r | s | v].
+type SignatureVector struct {
+	Path      string
+	Message   string
+	Hash      string
+	Signature string
+}
+
+// Keys are derived from Mnemonic
+var Keys = []KeyVector{
+	{
+		Path:      "m/44'/9000'/0'/0/0",
+		PublicKey: "02c6f477ff8e7136de982f898f6bfe93136bbe8dada6c17d0cd369acce90036ac4",
+		Address:   "P-avax1tlq4m9js4ckqvz9umfz7tjxna3yysm79r2jz8e",
+	},
+	{
+		Path:      "m/44'/9000'/0'/0/1",
+		PublicKey: "03992f4acaba18833be19a5097b29a0c90e9defc487103376ae4d7def5879d377f",
+		Address:   "P-avax1xtaw4e3na8dd3njsqszk59w9cjevyt0mstpr4c",
+	},
+	{
+		Path:      "m/44'/9000'/0'/1/0",
+		PublicKey: "020805b5ed9c04f097327dceb7ecd2757ca0b0ce2602bd977aa27c3fe021c83c8f",
+		Address:   "P-avax1dxlnaacaznlskg49awd9hecn5cne6sa6ywtxvg",
+	},
+	{
+		Path:      "m/44'/9000'/1'/0/0",
+		PublicKey: "0325c880590757f61e03d57067258bb841483179523c0e12d1e1d2b4f6d59a47ba",
+		Address:   "P-avax1gyefy2uncfvqnv69x8e8se3mdrv82qcpvknpff",
+	},
+}
+
+// Signatures are made by the keys of Keys
+var Signatures = []SignatureVector{
+	{
+		Path:      "m/44'/9000'/0'/0/0",
+		Message:   "Hello Avalanche!",
+		Hash:      "84b9e445b89c349ee63379dbb4fc13cca69b30d286f9bd2ca5629a5e1e78fdfc",
+		Signature: "73212c5a2b914b26910d61861d404b74296abe9d1ab8f0c8c82aac23bb216e2a5591167fbc3b28b8b90e57cad88998520bbff7d0f25d1271465bb77d2d422d6f00",
+	},
+	{
+		Path:      "m/44'/9000'/0'/0/1",
+		Message:   "Sign in to example.com",
+		Hash:      "18fbd5931e9c15482e48735a82ff9cb7fda8768ae4e391ad0ea782dba5861d56",
+		Signature: "fdc569bc5412d4d48d792ccdbc7b2c3a8b5155a68d3887dc9e47cb4538bae7025c965191cab530c7f5529982d0fd988daba77f961b3d85558894d322c2a591d300",
+	},
+}
+
+// Signer is implemented by the mock ledger, and by *ledger.LedgerAvalanche
+type Signer interface {
+	GetPubKey(path string, show bool, hrp string, chainid string) (publicKey []byte, hash []byte, err error)
+	GetAddress(path string, hrp string, chainid string, show bool) (string, error)
+	SignMessage(path string, message []byte) (*ledger.ResponseSign, error)
+}
+
+// VerifyAgainstVectors checks that signer, e.g. a mock ledger holding Mnemonic, returns the keys
+// and addresses of Keys and signs the messages of Signatures with the keys of their path. The
+// signatures are verified rather than compared, so the high-S form is accepted too.
+func VerifyAgainstVectors(signer Signer) error {
+	publicKeys := make(map[string][]byte)
+	for _, vector := range Keys {
+		publicKey, _, err := signer.GetPubKey(vector.Path, false, "", "")
+		if err != nil {
+			return fmt.Errorf("%s: %w", vector.Path, err)
+		}
+		if hex.EncodeToString(publicKey) != vector.PublicKey {
+			return fmt.Errorf("%w: %s has public key %x, expected %s", ErrVectorMismatch, vector.Path, publicKey, vector.PublicKey)
+		}
+		publicKeys[vector.Path] = publicKey
+
+		address, err := signer.GetAddress(vector.Path, "", "", false)
+		if err != nil {
+			return fmt.Errorf("%s: %w", vector.Path, err)
+		}
+		if address != vector.Address {
+			return fmt.Errorf("%w: %s has address %s, expected %s", ErrVectorMismatch, vector.Path, address, vector.Address)
+		}
+	}
+
+	for _, vector := range Signatures {
+		response, err := signer.SignMessage(vector.Path, []byte(vector.Message))
+		if err != nil {
+			return fmt.Errorf("%s: %w", vector.Path, err)
+		}
+		if len(response.SignaturesOrdered) != 1 {
+			return fmt.Errorf("%w: %d signatures of %q", ErrVectorMismatch, len(response.SignaturesOrdered), vector.Message)
+		}
+
+		hash, _ := hex.DecodeString(vector.Hash)
+		if !bytes.Equal(response.Hash, hash) {
+			return fmt.Errorf("%w: %q hashed to %x, expected %s", ErrVectorMismatch, vector.Message, response.Hash, vector.Hash)
+		}
+		signature := response.SignaturesOrdered[0].Signature
+		if len(signature) != ledger.SIGNATURE_LEN || !ledger.VerifySignature(publicKeys[vector.Path], hash, signature[:64]) {
+			return fmt.Errorf("%w: signature %x of %q is not made by %s", ErrVectorMismatch, signature, vector.Message, vector.Path)
+		}
+	}
+	return nil
+}
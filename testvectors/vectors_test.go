@@ -0,0 +1,53 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package testvectors
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/mock"
+)
+
+func Test_VerifyAgainstVectors(t *testing.T) {
+	device, err := mock.NewMockLedgerFromMnemonic(Mnemonic)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyAgainstVectors(device))
+
+	other, err := mock.NewMockLedgerFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about")
+	require.NoError(t, err)
+	assert.ErrorIs(t, VerifyAgainstVectors(other), ErrVectorMismatch)
+}
+
+func Test_SignaturesAreConsistent(t *testing.T) {
+	publicKeys := make(map[string]string)
+	for _, vector := range Keys {
+		publicKeys[vector.Path] = vector.PublicKey
+	}
+
+	for _, vector := range Signatures {
+		hash, _ := hex.DecodeString(vector.Hash)
+		assert.Equal(t, hash, ledger.AvalancheMessageHash([]byte(vector.Message)), vector.Message)
+
+		publicKey, _ := hex.DecodeString(publicKeys[vector.Path])
+		signature, _ := hex.DecodeString(vector.Signature)
+		assert.True(t, ledger.VerifySignature(publicKey, hash, signature[:64]), vector.Message)
+	}
+}
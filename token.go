@@ -0,0 +1,93 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// TokenInfo describes an ERC20 token for clear signing, as distributed by Ledger: the device
+// only accepts descriptors signed by Ledger, so they cannot be built by the caller
+type TokenInfo struct {
+	Ticker   string
+	Address  []byte
+	Decimals uint32
+	ChainID  uint32
+	// Signature is Ledger's signature over the other fields
+	Signature []byte
+}
+
+// ParseTokenInfo parses a signed token descriptor:
+// [tickerLen | ticker | address | decimals | chainID | signature]
+func ParseTokenInfo(descriptor []byte) (TokenInfo, error) {
+	if len(descriptor) < 1 || len(descriptor) < 1+int(descriptor[0])+20+4+4+1 {
+		return TokenInfo{}, errors.New("token descriptor is too short")
+	}
+
+	offset := 1 + int(descriptor[0])
+	info := TokenInfo{
+		Ticker:    string(descriptor[1:offset]),
+		Address:   append([]byte{}, descriptor[offset:offset+20]...),
+		Decimals:  binary.BigEndian.Uint32(descriptor[offset+20:]),
+		ChainID:   binary.BigEndian.Uint32(descriptor[offset+24:]),
+		Signature: append([]byte{}, descriptor[offset+28:]...),
+	}
+	return info, nil
+}
+
+// Serialize returns the descriptor sent to the device
+func (info TokenInfo) Serialize() ([]byte, error) {
+	if len(info.Ticker) > 0xFF {
+		return nil, fmt.Errorf("ticker %q is too long", info.Ticker)
+	}
+	if len(info.Address) != 20 {
+		return nil, fmt.Errorf("token address should be 20 bytes, got %d", len(info.Address))
+	}
+
+	descriptor := append([]byte{byte(len(info.Ticker))}, info.Ticker...)
+	descriptor = append(descriptor, info.Address...)
+	descriptor = binary.BigEndian.AppendUint32(descriptor, info.Decimals)
+	descriptor = binary.BigEndian.AppendUint32(descriptor, info.ChainID)
+	return append(descriptor, info.Signature...), nil
+}
+
+// ProvideTokenInfo sends the descriptor of a token to the device, which uses it to show the
+// next EVM transaction transferring that token. See SignEVMTransactionWithTokens, which provides
+// the descriptors and signs in the same session.
+func (ledger *LedgerAvalanche) ProvideTokenInfo(info TokenInfo) error {
+	return ledger.provideTokenInfo(context.Background(), info)
+}
+
+func (ledger *LedgerAvalanche) provideTokenInfo(ctx context.Context, info TokenInfo) error {
+	descriptor, err := info.Serialize()
+	if err != nil {
+		return err
+	}
+
+	message, err := buildAPDU(CLA_ETH, INS_PROVIDE_ERC20_TOKEN_INFO, 0, 0, descriptor)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ledger.exchangeContext(ctx, message, ledger.exchangeTimeout, ErrExchangeTimeout); err != nil {
+		return fmt.Errorf("token %s not accepted: %w", info.Ticker, err)
+	}
+	return nil
+}
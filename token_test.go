@@ -0,0 +1,77 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTokenInfo() TokenInfo {
+	return TokenInfo{
+		Ticker:    "USDC",
+		Address:   bytes.Repeat([]byte{0xB9}, 20),
+		Decimals:  6,
+		ChainID:   43114,
+		Signature: bytes.Repeat([]byte{0x30}, 70),
+	}
+}
+
+func Test_TokenInfo(t *testing.T) {
+	descriptor, err := testTokenInfo().Serialize()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{4, 'U', 'S', 'D', 'C'}, descriptor[:5])
+	assert.Len(t, descriptor, 5+20+4+4+70)
+
+	parsed, err := ParseTokenInfo(descriptor)
+	require.NoError(t, err)
+	assert.Equal(t, testTokenInfo(), parsed)
+
+	_, err = ParseTokenInfo(descriptor[:20])
+	assert.Error(t, err)
+
+	invalid := testTokenInfo()
+	invalid.Address = invalid.Address[:19]
+	_, err = invalid.Serialize()
+	assert.Error(t, err)
+}
+
+func Test_SignEVMTransactionWithTokens(t *testing.T) {
+	signature := append([]byte{0x1b}, make([]byte, 64)...)
+	device := &mockDevice{handler: replies([]byte{}, signature)}
+	ledger := newMockLedger(device)
+
+	_, err := ledger.SignEVMTransactionWithTokens("m/44'/60'/0'/0/0", []byte{0xc0}, []TokenInfo{testTokenInfo()})
+	require.NoError(t, err)
+
+	descriptor, _ := testTokenInfo().Serialize()
+	require.Len(t, device.sent, 2)
+	assert.Equal(t, append([]byte{CLA_ETH, INS_PROVIDE_ERC20_TOKEN_INFO, 0, 0, byte(len(descriptor))}, descriptor...), device.sent[0])
+	assert.Equal(t, byte(INS_SIGN_EVM_TX), device.sent[1][1])
+}
+
+func Test_ProvideTokenInfoRejected(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{handler: func([]byte) ([]byte, error) {
+		return nil, statusError(DataIsInvalid)
+	}})
+
+	err := ledger.ProvideTokenInfo(testTokenInfo())
+	assert.True(t, isStatus(err, DataIsInvalid))
+}
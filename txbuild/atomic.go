@@ -0,0 +1,201 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package txbuild
+
+import (
+	"fmt"
+
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/avax"
+	"github.com/zondax/ledger-avalanche-go/decode"
+)
+
+// Type IDs of the atomic transactions of the X-chain (avm) and C-chain (coreth) codecs
+const (
+	cChainImportTxID = 0
+	cChainExportTxID = 1
+	xChainImportTxID = 3
+	xChainExportTxID = 4
+)
+
+// EVMAddressLength is the length of C-chain account addresses
+const EVMAddressLength = 20
+
+// Chain is the chain an atomic transaction is issued on
+type Chain uint8
+
+const (
+	// XChain issues avm import and export transactions, spending and creating UTXOs
+	XChain Chain = iota
+	// CChain issues coreth import and export transactions, debiting and crediting accounts
+	CChain
+)
+
+// EVMAccount is a C-chain account debited by an export. Owner is the address of its key in the
+// keychain, Address its Ethereum address.
+type EVMAccount struct {
+	Address [EVMAddressLength]byte
+	Nonce   uint64
+	Owner   avax.ShortID
+}
+
+// ExportParams describes an export of Amount of AVAX from the Chain with ID BlockchainID to the
+// chain with ID DestinationChainID, where To can import it
+type ExportParams struct {
+	Chain              Chain
+	NetworkID          uint32
+	AVAXAssetID        [IDLength]byte
+	BlockchainID       [IDLength]byte
+	DestinationChainID [IDLength]byte
+	Amount             uint64
+	To                 avax.ShortID
+	Fee                uint64
+	// UTXOs are spent in order until they cover Amount and Fee, on the X-chain
+	UTXOs []UTXO
+	// ChangeAddress receives the change, on the X-chain
+	ChangeAddress avax.ShortID
+	// From is debited Amount and Fee, on the C-chain
+	From EVMAccount
+}
+
+// ImportParams describes an import to the Chain with ID BlockchainID of the UTXOs exported from
+// the chain with ID SourceChainID, less Fee
+type ImportParams struct {
+	Chain         Chain
+	NetworkID     uint32
+	AVAXAssetID   [IDLength]byte
+	BlockchainID  [IDLength]byte
+	SourceChainID [IDLength]byte
+	// UTXOs are the atomic UTXOs to import, all of AVAX
+	UTXOs []UTXO
+	Fee   uint64
+	// To receives the imported AVAX, on the X-chain
+	To avax.ShortID
+	// ToAccount receives the imported AVAX, on the C-chain
+	ToAccount [EVMAddressLength]byte
+}
+
+// NewExportTx builds an X-chain or C-chain export transaction
+func NewExportTx(params ExportParams) (*UnsignedTx, error) {
+	if params.Amount == 0 {
+		return nil, fmt.Errorf("%w: zero export", ErrInvalidAmount)
+	}
+	exported := []Output{{AssetID: params.AVAXAssetID, Amount: params.Amount, Owner: NewOwner(params.To)}}
+
+	var p packer
+	p.uint16(CodecVersion)
+	switch params.Chain {
+	case XChain:
+		utxos, change, err := selectUTXOs(params.UTXOs, params.AVAXAssetID, params.Amount, params.Fee)
+		if err != nil {
+			return nil, err
+		}
+
+		// [base tx | destination chain | exported outputs]
+		p.uint32(xChainExportTxID)
+		packBaseTx(&p, params.NetworkID, params.BlockchainID, changeOutputs(params.AVAXAssetID, change, params.ChangeAddress), utxos)
+		p.fixed(params.DestinationChainID[:])
+		packOutputs(&p, exported)
+		return &UnsignedTx{Bytes: p.buf, Chain: decode.XChain, Inputs: inputsOf(utxos)}, nil
+
+	case CChain:
+		debit := params.Amount + params.Fee
+		if debit < params.Amount {
+			return nil, fmt.Errorf("%w: amount and fee overflow", ErrInvalidAmount)
+		}
+
+		// [network ID | blockchain ID | destination chain | inputs | exported outputs], the inputs
+		// being [address | amount | asset ID | nonce]
+		p.uint32(cChainExportTxID)
+		p.uint32(params.NetworkID)
+		p.fixed(params.BlockchainID[:])
+		p.fixed(params.DestinationChainID[:])
+		p.uint32(1)
+		p.fixed(params.From.Address[:])
+		p.uint64(debit)
+		p.fixed(params.AVAXAssetID[:])
+		p.uint64(params.From.Nonce)
+		packOutputs(&p, exported)
+		return &UnsignedTx{Bytes: p.buf, Chain: decode.CChain, Inputs: []avax.Input{{Signers: []avax.ShortID{params.From.Owner}}}}, nil
+	}
+	return nil, fmt.Errorf("unknown chain %d", params.Chain)
+}
+
+// NewImportTx builds an X-chain or C-chain import transaction
+func NewImportTx(params ImportParams) (*UnsignedTx, error) {
+	utxos := append([]UTXO{}, params.UTXOs...)
+	var imported uint64
+	for _, utxo := range utxos {
+		if utxo.AssetID != params.AVAXAssetID {
+			return nil, fmt.Errorf("%w: UTXO %d of another asset", ErrInvalidAmount, utxo.OutputIndex)
+		}
+		if imported+utxo.Amount < imported {
+			return nil, fmt.Errorf("%w: UTXO amounts overflow", ErrInvalidAmount)
+		}
+		imported += utxo.Amount
+	}
+	if imported <= params.Fee {
+		return nil, fmt.Errorf("%w: have %d, need more than %d", ErrInsufficientFunds, imported, params.Fee)
+	}
+	sortUTXOs(utxos)
+
+	var p packer
+	p.uint16(CodecVersion)
+	switch params.Chain {
+	case XChain:
+		// [base tx | source chain | imported inputs]
+		p.uint32(xChainImportTxID)
+		output := Output{AssetID: params.AVAXAssetID, Amount: imported - params.Fee, Owner: NewOwner(params.To)}
+		packBaseTx(&p, params.NetworkID, params.BlockchainID, []Output{output}, nil)
+		p.fixed(params.SourceChainID[:])
+		packInputs(&p, utxos)
+		return &UnsignedTx{Bytes: p.buf, Chain: decode.XChain, Inputs: inputsOf(utxos)}, nil
+
+	case CChain:
+		// [network ID | blockchain ID | source chain | imported inputs | outputs], the outputs
+		// being [address | amount | asset ID]
+		p.uint32(cChainImportTxID)
+		p.uint32(params.NetworkID)
+		p.fixed(params.BlockchainID[:])
+		p.fixed(params.SourceChainID[:])
+		packInputs(&p, utxos)
+		p.uint32(1)
+		p.fixed(params.ToAccount[:])
+		p.uint64(imported - params.Fee)
+		p.fixed(params.AVAXAssetID[:])
+		return &UnsignedTx{Bytes: p.buf, Chain: decode.CChain, Inputs: inputsOf(utxos)}, nil
+	}
+	return nil, fmt.Errorf("unknown chain %d", params.Chain)
+}
+
+// ExportAVAX builds the export transaction of params and signs it with the addresses of keychain
+func ExportAVAX(device *ledger.LedgerAvalanche, keychain avax.Keychain, params ExportParams) ([]byte, error) {
+	tx, err := NewExportTx(params)
+	if err != nil {
+		return nil, err
+	}
+	return Sign(device, tx, keychain)
+}
+
+// ImportAVAX builds the import transaction of params and signs it with the addresses of keychain
+func ImportAVAX(device *ledger.LedgerAvalanche, keychain avax.Keychain, params ImportParams) ([]byte, error) {
+	tx, err := NewImportTx(params)
+	if err != nil {
+		return nil, err
+	}
+	return Sign(device, tx, keychain)
+}
@@ -0,0 +1,162 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package txbuild
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/avax"
+	"github.com/zondax/ledger-avalanche-go/decode"
+	"github.com/zondax/ledger-avalanche-go/mock"
+)
+
+func Test_NewExportTxXChain(t *testing.T) {
+	tx, err := NewExportTx(ExportParams{
+		Chain:              XChain,
+		NetworkID:          5,
+		AVAXAssetID:        testAssetID,
+		BlockchainID:       [IDLength]byte{0x0b},
+		DestinationChainID: [IDLength]byte{0x0c},
+		Amount:             7,
+		To:                 avax.ShortID{0x01},
+		Fee:                1,
+		UTXOs:              []UTXO{{TxID: [IDLength]byte{0x02}, AssetID: testAssetID, Amount: 10, Owner: avax.ShortID{0x0f}}},
+		ChangeAddress:      avax.ShortID{0x0e},
+	})
+	require.NoError(t, err)
+
+	expected := "0000" + u32(4) +
+		u32(5) + id(0x0b) + u32(1) + output(2, 0x0e) + u32(1) + input(0x02, 0, 10) + u32(0) +
+		id(0x0c) + u32(1) + output(7, 0x01)
+	assert.Equal(t, expected, hex.EncodeToString(tx.Bytes))
+	assert.Equal(t, []avax.Input{{Signers: []avax.ShortID{{0x0f}}}}, tx.Inputs)
+	assert.Equal(t, decode.XChain, tx.Chain)
+}
+
+func Test_NewExportTxCChain(t *testing.T) {
+	tx, err := NewExportTx(ExportParams{
+		Chain:              CChain,
+		NetworkID:          5,
+		AVAXAssetID:        testAssetID,
+		BlockchainID:       [IDLength]byte{0x0c},
+		DestinationChainID: [IDLength]byte{0x0b},
+		Amount:             7,
+		To:                 avax.ShortID{0x01},
+		Fee:                1,
+		From:               EVMAccount{Address: [EVMAddressLength]byte{0xee}, Nonce: 3, Owner: avax.ShortID{0x0f}},
+	})
+	require.NoError(t, err)
+
+	expected := "0000" + u32(1) + u32(5) + id(0x0c) + id(0x0b) +
+		u32(1) + shortID(0xee) + u64(8) + id(0xaa) + u64(3) +
+		u32(1) + output(7, 0x01)
+	assert.Equal(t, expected, hex.EncodeToString(tx.Bytes))
+	assert.Equal(t, []avax.Input{{Signers: []avax.ShortID{{0x0f}}}}, tx.Inputs)
+	assert.Equal(t, decode.CChain, tx.Chain)
+}
+
+func Test_NewImportTx(t *testing.T) {
+	params := ImportParams{
+		Chain:         XChain,
+		NetworkID:     5,
+		AVAXAssetID:   testAssetID,
+		BlockchainID:  [IDLength]byte{0x0b},
+		SourceChainID: [IDLength]byte{0x0c},
+		UTXOs: []UTXO{
+			{TxID: [IDLength]byte{0x02}, AssetID: testAssetID, Amount: 5, Owner: avax.ShortID{0x0f}},
+			{TxID: [IDLength]byte{0x01}, AssetID: testAssetID, Amount: 3, Owner: avax.ShortID{0x0f}},
+		},
+		Fee:       1,
+		To:        avax.ShortID{0x01},
+		ToAccount: [EVMAddressLength]byte{0xee},
+	}
+
+	tx, err := NewImportTx(params)
+	require.NoError(t, err)
+	imported := u32(2) + input(0x01, 0, 3) + input(0x02, 0, 5)
+	assert.Equal(t, "0000"+u32(3)+u32(5)+id(0x0b)+u32(1)+output(7, 0x01)+u32(0)+u32(0)+id(0x0c)+imported,
+		hex.EncodeToString(tx.Bytes))
+	assert.Len(t, tx.Inputs, 2)
+
+	params.Chain = CChain
+	tx, err = NewImportTx(params)
+	require.NoError(t, err)
+	assert.Equal(t, "0000"+u32(0)+u32(5)+id(0x0b)+id(0x0c)+imported+u32(1)+shortID(0xee)+u64(7)+id(0xaa),
+		hex.EncodeToString(tx.Bytes))
+	assert.Len(t, tx.Inputs, 2)
+	assert.Equal(t, decode.CChain, tx.Chain)
+}
+
+func Test_AtomicTxErrors(t *testing.T) {
+	_, err := NewExportTx(ExportParams{Chain: XChain, AVAXAssetID: testAssetID, Amount: 10,
+		UTXOs: []UTXO{{AssetID: testAssetID, Amount: 10}}, Fee: 1})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = NewExportTx(ExportParams{Chain: CChain})
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = NewImportTx(ImportParams{AVAXAssetID: testAssetID, UTXOs: []UTXO{{AssetID: testAssetID, Amount: 1}}, Fee: 1})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = NewImportTx(ImportParams{AVAXAssetID: testAssetID, UTXOs: []UTXO{{Amount: 10}}})
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = NewExportTx(ExportParams{Chain: 7, Amount: 1})
+	assert.Error(t, err)
+}
+
+func Test_ExportAVAX(t *testing.T) {
+	device, err := mock.NewMockLedgerFromMnemonic(testMnemonic)
+	require.NoError(t, err)
+	keychain, err := avax.NewKeychain(device.LedgerAvalanche, "m/44'/9000'/0'", 0, []uint32{0})
+	require.NoError(t, err)
+
+	var owner avax.ShortID
+	for address := range keychain {
+		owner = address
+	}
+	params := ExportParams{
+		Chain:       CChain,
+		AVAXAssetID: testAssetID,
+		Amount:      7,
+		To:          owner,
+		From:        EVMAccount{Owner: owner},
+	}
+	signed, err := ExportAVAX(device.LedgerAvalanche, keychain, params)
+	require.NoError(t, err)
+
+	tx, err := NewExportTx(params)
+	require.NoError(t, err)
+	require.Equal(t, tx.Bytes, signed[:len(tx.Bytes)])
+	credential := hex.EncodeToString(signed[len(tx.Bytes):])
+	assert.True(t, strings.HasPrefix(credential, u32(1)+u32(9)+u32(1)), credential)
+
+	publicKey, _, err := device.GetPubKey(keychain[owner], false, "", "")
+	require.NoError(t, err)
+	hash := sha256.Sum256(tx.Bytes)
+	assert.True(t, ledger.VerifySignature(publicKey, hash[:], signed[len(tx.Bytes)+12:len(tx.Bytes)+12+64]))
+
+	_, err = ImportAVAX(device.LedgerAvalanche, keychain, ImportParams{AVAXAssetID: testAssetID,
+		UTXOs: []UTXO{{AssetID: testAssetID, Amount: 5, Owner: avax.ShortID{0x01}}}})
+	assert.ErrorIs(t, err, avax.ErrUnknownSigner)
+}
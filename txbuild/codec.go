@@ -0,0 +1,228 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+// Package txbuild builds unsigned P-chain staking transactions and X-chain and C-chain atomic
+// transactions in the avalanchego codec format, to be signed with the Ledger, without depending
+// on avalanchego.
+package txbuild
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/zondax/ledger-avalanche-go/avax"
+)
+
+const (
+	// CodecVersion is the version of the avalanchego codec the transactions are serialized with
+	CodecVersion = 0
+	// IDLength is the length of transaction, asset, blockchain and subnet IDs
+	IDLength = 32
+)
+
+// Type IDs of the P-chain codec
+const (
+	secp256k1TransferInputID       = 5
+	secp256k1TransferOutputID      = 7
+	secp256k1CredentialID          = 9
+	secp256k1OutputOwnersID        = 11
+	addPermissionlessValidatorTxID = 25
+	addPermissionlessDelegatorTxID = 26
+	emptySignerID                  = 27
+	proofOfPossessionSignerID      = 28
+	signatureLength                = 65
+)
+
+// packer serializes values as the avalanchego codec does: big endian integers, and slices
+// prefixed with their uint32 length
+type packer struct {
+	buf []byte
+}
+
+func (p *packer) uint16(v uint16) {
+	p.buf = binary.BigEndian.AppendUint16(p.buf, v)
+}
+
+func (p *packer) uint32(v uint32) {
+	p.buf = binary.BigEndian.AppendUint32(p.buf, v)
+}
+
+func (p *packer) uint64(v uint64) {
+	p.buf = binary.BigEndian.AppendUint64(p.buf, v)
+}
+
+func (p *packer) fixed(b []byte) {
+	p.buf = append(p.buf, b...)
+}
+
+func (p *packer) bytes(b []byte) {
+	p.uint32(uint32(len(b)))
+	p.fixed(b)
+}
+
+// Owner is a secp256k1 owner of outputs or rewards: Threshold of Addresses can spend them
+// once Locktime is past
+type Owner struct {
+	Locktime  uint64
+	Threshold uint32
+	Addresses []avax.ShortID
+}
+
+// NewOwner returns the owner of a single address, without locktime
+func NewOwner(address avax.ShortID) Owner {
+	return Owner{Threshold: 1, Addresses: []avax.ShortID{address}}
+}
+
+// pack writes [locktime | threshold | addresses], the addresses sorted as the codec requires
+func (o Owner) pack(p *packer) {
+	addresses := append([]avax.ShortID{}, o.Addresses...)
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+
+	p.uint64(o.Locktime)
+	p.uint32(o.Threshold)
+	p.uint32(uint32(len(addresses)))
+	for _, address := range addresses {
+		p.fixed(address[:])
+	}
+}
+
+// Output is a secp256k1 transferable output
+type Output struct {
+	AssetID [IDLength]byte
+	Amount  uint64
+	Owner   Owner
+}
+
+func (o Output) pack(p *packer) {
+	p.fixed(o.AssetID[:])
+	p.uint32(secp256k1TransferOutputID)
+	p.uint64(o.Amount)
+	o.Owner.pack(p)
+}
+
+// packOutputs writes outputs sorted by their serialization, as the codec requires
+func packOutputs(p *packer, outputs []Output) {
+	serialized := make([][]byte, len(outputs))
+	for i, output := range outputs {
+		var out packer
+		output.pack(&out)
+		serialized[i] = out.buf
+	}
+	sort.Slice(serialized, func(i, j int) bool {
+		return bytes.Compare(serialized[i], serialized[j]) < 0
+	})
+
+	p.uint32(uint32(len(serialized)))
+	for _, output := range serialized {
+		p.fixed(output)
+	}
+}
+
+// UTXO is a secp256k1 output owned by a single address, without locktime, to be spent by a
+// transaction
+type UTXO struct {
+	TxID        [IDLength]byte
+	OutputIndex uint32
+	AssetID     [IDLength]byte
+	Amount      uint64
+	Owner       avax.ShortID
+}
+
+// sortUTXOs sorts utxos by transaction ID and output index, the order of the inputs
+func sortUTXOs(utxos []UTXO) {
+	sort.Slice(utxos, func(i, j int) bool {
+		if c := bytes.Compare(utxos[i].TxID[:], utxos[j].TxID[:]); c != 0 {
+			return c < 0
+		}
+		return utxos[i].OutputIndex < utxos[j].OutputIndex
+	})
+}
+
+// packInputs writes the inputs spending utxos, which must be sorted with sortUTXOs
+func packInputs(p *packer, utxos []UTXO) {
+	p.uint32(uint32(len(utxos)))
+	for _, utxo := range utxos {
+		p.fixed(utxo.TxID[:])
+		p.uint32(utxo.OutputIndex)
+		p.fixed(utxo.AssetID[:])
+		p.uint32(secp256k1TransferInputID)
+		p.uint64(utxo.Amount)
+		// a single signature, by the only owner
+		p.uint32(1)
+		p.uint32(0)
+	}
+}
+
+// inputsOf returns the signers of the inputs spending utxos
+func inputsOf(utxos []UTXO) []avax.Input {
+	inputs := make([]avax.Input, len(utxos))
+	for i, utxo := range utxos {
+		inputs[i] = avax.Input{Signers: []avax.ShortID{utxo.Owner}}
+	}
+	return inputs
+}
+
+// selectUTXOs returns the utxos of assetID covering amount and fee, sorted in input order, and
+// the change
+func selectUTXOs(utxos []UTXO, assetID [IDLength]byte, amount, fee uint64) ([]UTXO, uint64, error) {
+	required := amount + fee
+	if required < amount {
+		return nil, 0, fmt.Errorf("%w: amount and fee overflow", ErrInvalidAmount)
+	}
+
+	var selected []UTXO
+	var consumed uint64
+	for _, utxo := range utxos {
+		if consumed >= required {
+			break
+		}
+		if utxo.AssetID != assetID || utxo.Amount == 0 {
+			continue
+		}
+		if consumed+utxo.Amount < consumed {
+			return nil, 0, fmt.Errorf("%w: UTXO amounts overflow", ErrInvalidAmount)
+		}
+		selected = append(selected, utxo)
+		consumed += utxo.Amount
+	}
+	if consumed < required {
+		return nil, 0, fmt.Errorf("%w: have %d, need %d", ErrInsufficientFunds, consumed, required)
+	}
+
+	sortUTXOs(selected)
+	return selected, consumed - required, nil
+}
+
+// changeOutputs returns the output of change to address, if any
+func changeOutputs(assetID [IDLength]byte, change uint64, address avax.ShortID) []Output {
+	if change == 0 {
+		return nil
+	}
+	return []Output{{AssetID: assetID, Amount: change, Owner: NewOwner(address)}}
+}
+
+// packBaseTx writes [network ID | blockchain ID | outputs | inputs | memo], without memo
+func packBaseTx(p *packer, networkID uint32, blockchainID [IDLength]byte, outputs []Output, utxos []UTXO) {
+	p.uint32(networkID)
+	p.fixed(blockchainID[:])
+	packOutputs(p, outputs)
+	packInputs(p, utxos)
+	p.bytes(nil)
+}
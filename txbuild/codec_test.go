@@ -0,0 +1,61 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package txbuild
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zondax/ledger-avalanche-go/avax"
+)
+
+func Test_OwnerPack(t *testing.T) {
+	var p packer
+	Owner{Locktime: 1, Threshold: 2, Addresses: []avax.ShortID{{0x02}, {0x01}}}.pack(&p)
+
+	assert.Equal(t, "0000000000000001"+"00000002"+"00000002"+
+		"0100000000000000000000000000000000000000"+
+		"0200000000000000000000000000000000000000", hex.EncodeToString(p.buf))
+}
+
+func Test_PackOutputsSorted(t *testing.T) {
+	var p packer
+	packOutputs(&p, []Output{
+		{AssetID: [IDLength]byte{0x01}, Amount: 2, Owner: NewOwner(avax.ShortID{0x0a})},
+		{AssetID: [IDLength]byte{0x01}, Amount: 1, Owner: NewOwner(avax.ShortID{0x0b})},
+	})
+
+	assert.Len(t, p.buf, 4+2*(IDLength+4+8+8+4+4+20))
+	assert.Equal(t, "00000002", hex.EncodeToString(p.buf[:4]))
+	// the output of 1 comes first
+	assert.Equal(t, byte(1), p.buf[4+IDLength+4+7])
+}
+
+func Test_SortUTXOs(t *testing.T) {
+	utxos := []UTXO{
+		{TxID: [IDLength]byte{0x02}, OutputIndex: 0},
+		{TxID: [IDLength]byte{0x01}, OutputIndex: 1},
+		{TxID: [IDLength]byte{0x01}, OutputIndex: 0},
+	}
+	sortUTXOs(utxos)
+
+	assert.Equal(t, byte(0x01), utxos[0].TxID[0])
+	assert.Equal(t, uint32(0), utxos[0].OutputIndex)
+	assert.Equal(t, uint32(1), utxos[1].OutputIndex)
+	assert.Equal(t, byte(0x02), utxos[2].TxID[0])
+}
@@ -0,0 +1,28 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package txbuild
+
+import "errors"
+
+var (
+	// ErrInsufficientFunds is returned when the UTXOs do not cover the amount and the fee
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrInvalidAmount is returned when an amount is zero or amounts overflow
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrInvalidStake is returned when the staking period is invalid
+	ErrInvalidStake = errors.New("invalid stake")
+)
@@ -0,0 +1,153 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package txbuild
+
+import (
+	"fmt"
+	"time"
+
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/avax"
+	"github.com/zondax/ledger-avalanche-go/decode"
+)
+
+// StakeParams describes a stake on the primary network
+type StakeParams struct {
+	NetworkID   uint32
+	AVAXAssetID [IDLength]byte
+	NodeID      avax.ShortID
+	Amount      uint64
+	Start       time.Time
+	End         time.Time
+	// RewardsAddress receives the staking rewards
+	RewardsAddress avax.ShortID
+	// ChangeAddress receives the change, and the stake once the staking period is over
+	ChangeAddress avax.ShortID
+	Fee           uint64
+	// UTXOs are spent in order until they cover Amount and Fee
+	UTXOs []UTXO
+}
+
+// UnsignedTx is a serialized unsigned transaction, with what avax.SignUnsignedTx needs to sign it
+type UnsignedTx struct {
+	Bytes  []byte
+	Chain  decode.Chain
+	Inputs []avax.Input
+}
+
+// NewAddPermissionlessValidatorTx builds a transaction adding NodeID as a validator of the
+// primary network. pop registers the BLS key of the node, none is registered if it is nil.
+// delegationShares is the fee charged to delegators, in millionths of their rewards.
+func NewAddPermissionlessValidatorTx(params StakeParams, pop *ledger.BLSProofOfPossession, delegationShares uint32) (*UnsignedTx, error) {
+	var p packer
+	tx, err := params.pack(&p, addPermissionlessValidatorTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pop != nil {
+		p.uint32(proofOfPossessionSignerID)
+		p.fixed(pop.PublicKey[:])
+		p.fixed(pop.Signature[:])
+	} else {
+		p.uint32(emptySignerID)
+	}
+	packOutputs(&p, params.stakeOutputs())
+	rewardsOwner := NewOwner(params.RewardsAddress)
+	p.uint32(secp256k1OutputOwnersID)
+	rewardsOwner.pack(&p)
+	p.uint32(secp256k1OutputOwnersID)
+	rewardsOwner.pack(&p)
+	p.uint32(delegationShares)
+
+	tx.Bytes = p.buf
+	return tx, nil
+}
+
+// NewAddPermissionlessDelegatorTx builds a transaction delegating to the primary network
+// validator NodeID
+func NewAddPermissionlessDelegatorTx(params StakeParams) (*UnsignedTx, error) {
+	var p packer
+	tx, err := params.pack(&p, addPermissionlessDelegatorTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	packOutputs(&p, params.stakeOutputs())
+	rewardsOwner := NewOwner(params.RewardsAddress)
+	p.uint32(secp256k1OutputOwnersID)
+	rewardsOwner.pack(&p)
+
+	tx.Bytes = p.buf
+	return tx, nil
+}
+
+// pack writes the codec version and type ID header, then the fields shared by both staking
+// transactions: [base tx | validator | subnet]
+func (params StakeParams) pack(p *packer, typeID uint32) (*UnsignedTx, error) {
+	if params.Amount == 0 {
+		return nil, fmt.Errorf("%w: zero stake", ErrInvalidAmount)
+	}
+	if !params.End.After(params.Start) || params.Start.Unix() < 0 {
+		return nil, fmt.Errorf("%w: staking period %v to %v", ErrInvalidStake, params.Start, params.End)
+	}
+
+	utxos, change, err := selectUTXOs(params.UTXOs, params.AVAXAssetID, params.Amount, params.Fee)
+	if err != nil {
+		return nil, err
+	}
+
+	p.uint16(CodecVersion)
+	p.uint32(typeID)
+	// the P-chain ID is zero
+	packBaseTx(p, params.NetworkID, [IDLength]byte{}, changeOutputs(params.AVAXAssetID, change, params.ChangeAddress), utxos)
+
+	// [node ID | start | end | weight | subnet ID (the primary network)]
+	p.fixed(params.NodeID[:])
+	p.uint64(uint64(params.Start.Unix()))
+	p.uint64(uint64(params.End.Unix()))
+	p.uint64(params.Amount)
+	p.fixed(make([]byte, IDLength))
+
+	return &UnsignedTx{Chain: decode.PChain, Inputs: inputsOf(utxos)}, nil
+}
+
+// stakeOutputs returns the locked stake, returned to the change address
+func (params StakeParams) stakeOutputs() []Output {
+	return []Output{{AssetID: params.AVAXAssetID, Amount: params.Amount, Owner: NewOwner(params.ChangeAddress)}}
+}
+
+// Sign signs tx with the addresses of keychain and returns the signed transaction, ready to be
+// issued with platform.issueTx
+func Sign(device *ledger.LedgerAvalanche, tx *UnsignedTx, keychain avax.Keychain) ([]byte, error) {
+	credentials, err := avax.SignUnsignedTx(device, tx.Bytes, tx.Chain, tx.Inputs, keychain)
+	if err != nil {
+		return nil, err
+	}
+
+	// [unsigned tx | credentials], each [type ID | signatures]
+	p := packer{buf: append([]byte{}, tx.Bytes...)}
+	p.uint32(uint32(len(credentials)))
+	for _, credential := range credentials {
+		p.uint32(secp256k1CredentialID)
+		p.uint32(uint32(len(credential.Sigs)))
+		for _, sig := range credential.Sigs {
+			p.fixed(sig[:])
+		}
+	}
+	return p.buf, nil
+}
@@ -0,0 +1,176 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package txbuild
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ledger "github.com/zondax/ledger-avalanche-go"
+	"github.com/zondax/ledger-avalanche-go/avax"
+	"github.com/zondax/ledger-avalanche-go/decode"
+	"github.com/zondax/ledger-avalanche-go/mock"
+)
+
+const testMnemonic = "equip will roof matter pink blind book anxiety banner elbow sun young"
+
+var (
+	testAssetID = [IDLength]byte{0xaa}
+	testNodeID  = avax.ShortID{0x0d}
+	testStart   = time.Unix(0x10000000, 0)
+)
+
+// id, shortID, u32 and u64 return the hex serialization of IDs, of short IDs starting with b, and
+// of integers
+func id(b byte) string      { return hex.EncodeToString([]byte{b}) + strings.Repeat("00", IDLength-1) }
+func shortID(b byte) string { return hex.EncodeToString([]byte{b}) + strings.Repeat("00", 19) }
+func u32(v uint32) string   { return hex.EncodeToString(binary.BigEndian.AppendUint32(nil, v)) }
+func u64(v uint64) string   { return hex.EncodeToString(binary.BigEndian.AppendUint64(nil, v)) }
+
+// output and input return the hex serialization of an AVAX output to a single address, and of an
+// AVAX input
+func output(amount uint64, address byte) string {
+	return id(0xaa) + u32(7) + u64(amount) + u64(0) + u32(1) + u32(1) + shortID(address)
+}
+
+func input(txID byte, index uint32, amount uint64) string {
+	return id(txID) + u32(index) + id(0xaa) + u32(5) + u64(amount) + u32(1) + u32(0)
+}
+
+func testParams(utxos ...UTXO) StakeParams {
+	return StakeParams{
+		NetworkID:      5,
+		AVAXAssetID:    testAssetID,
+		NodeID:         testNodeID,
+		Amount:         25,
+		Start:          testStart,
+		End:            testStart.Add(time.Second),
+		RewardsAddress: avax.ShortID{0x0e},
+		ChangeAddress:  avax.ShortID{0x0c},
+		Fee:            1,
+		UTXOs:          utxos,
+	}
+}
+
+func Test_NewAddPermissionlessDelegatorTx(t *testing.T) {
+	owner := avax.ShortID{0x0f}
+	tx, err := NewAddPermissionlessDelegatorTx(testParams(
+		UTXO{TxID: [IDLength]byte{0x02}, AssetID: testAssetID, Amount: 20, Owner: owner},
+		UTXO{TxID: [IDLength]byte{0x01}, OutputIndex: 3, AssetID: testAssetID, Amount: 10, Owner: owner},
+		UTXO{TxID: [IDLength]byte{0x03}, AssetID: testAssetID, Amount: 50, Owner: owner},
+	))
+	require.NoError(t, err)
+
+	expected := "0000" + u32(26) +
+		// base tx: network, P-chain, change of 30-25-1, two inputs sorted by tx ID, no memo
+		u32(5) + strings.Repeat("00", IDLength) +
+		u32(1) + output(4, 0x0c) +
+		u32(2) + input(0x01, 3, 10) + input(0x02, 0, 20) +
+		u32(0) +
+		// validator, primary network
+		shortID(0x0d) + u64(0x10000000) + u64(0x10000001) + u64(25) + strings.Repeat("00", IDLength) +
+		// stake, rewards owner
+		u32(1) + output(25, 0x0c) +
+		u32(11) + u64(0) + u32(1) + u32(1) + shortID(0x0e)
+	assert.Equal(t, expected, hex.EncodeToString(tx.Bytes))
+
+	assert.Equal(t, []avax.Input{{Signers: []avax.ShortID{owner}}, {Signers: []avax.ShortID{owner}}}, tx.Inputs)
+	assert.Equal(t, decode.PChain, tx.Chain)
+}
+
+func Test_NewAddPermissionlessValidatorTx(t *testing.T) {
+	params := testParams(UTXO{AssetID: testAssetID, Amount: 26, Owner: avax.ShortID{0x0f}})
+
+	tx, err := NewAddPermissionlessValidatorTx(params, nil, 20000)
+	require.NoError(t, err)
+	// no change output, the empty signer, then the stake, both rewards owners and the shares
+	assert.Equal(t, "00000019", hex.EncodeToString(tx.Bytes[2:6]))
+	assert.Equal(t, "00000000", hex.EncodeToString(tx.Bytes[6+4+IDLength:6+4+IDLength+4]))
+	assert.Equal(t, "00004e20", hex.EncodeToString(tx.Bytes[len(tx.Bytes)-4:]))
+
+	pop := &ledger.BLSProofOfPossession{PublicKey: [ledger.BLSPublicKeyLength]byte{0x01}}
+	withPop, err := NewAddPermissionlessValidatorTx(params, pop, 20000)
+	require.NoError(t, err)
+	assert.Len(t, withPop.Bytes, len(tx.Bytes)+ledger.BLSPublicKeyLength+ledger.BLSSignatureLength)
+
+	signer := len(tx.Bytes) - 4 - 2*(4+8+4+4+20) - (4 + IDLength + 4 + 8 + 8 + 4 + 4 + 20) - 4
+	assert.Equal(t, "0000001b", hex.EncodeToString(tx.Bytes[signer:signer+4]))
+	assert.Equal(t, "0000001c01", hex.EncodeToString(withPop.Bytes[signer:signer+5]))
+}
+
+func Test_NewStakingTxErrors(t *testing.T) {
+	utxo := UTXO{AssetID: testAssetID, Amount: 100}
+
+	_, err := NewAddPermissionlessDelegatorTx(testParams(UTXO{AssetID: testAssetID, Amount: 25}))
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = NewAddPermissionlessDelegatorTx(testParams(UTXO{AssetID: [IDLength]byte{0x01}, Amount: 100}))
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+
+	params := testParams(utxo)
+	params.Amount = 0
+	_, err = NewAddPermissionlessDelegatorTx(params)
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	params = testParams(utxo)
+	params.End = params.Start
+	_, err = NewAddPermissionlessValidatorTx(params, nil, 0)
+	assert.ErrorIs(t, err, ErrInvalidStake)
+}
+
+func Test_Sign(t *testing.T) {
+	device, err := mock.NewMockLedgerFromMnemonic(testMnemonic)
+	require.NoError(t, err)
+	keychain, err := avax.NewKeychain(device.LedgerAvalanche, "m/44'/9000'/0'", 0, []uint32{0, 1})
+	require.NoError(t, err)
+
+	var owners []avax.ShortID
+	for address := range keychain {
+		owners = append(owners, address)
+	}
+	params := testParams(
+		UTXO{TxID: [IDLength]byte{0x01}, AssetID: testAssetID, Amount: 10, Owner: owners[0]},
+		UTXO{TxID: [IDLength]byte{0x02}, AssetID: testAssetID, Amount: 20, Owner: owners[1]},
+	)
+	params.ChangeAddress = owners[0]
+
+	tx, err := NewAddPermissionlessDelegatorTx(params)
+	require.NoError(t, err)
+	signed, err := Sign(device.LedgerAvalanche, tx, keychain)
+	require.NoError(t, err)
+
+	require.Equal(t, tx.Bytes, signed[:len(tx.Bytes)])
+	credentials := signed[len(tx.Bytes):]
+	require.Len(t, credentials, 4+2*(4+4+ledger.SIGNATURE_LEN))
+	assert.Equal(t, "00000002", hex.EncodeToString(credentials[:4]))
+
+	hash := sha256.Sum256(tx.Bytes)
+	for i, owner := range owners {
+		credential := credentials[4+i*(8+ledger.SIGNATURE_LEN):]
+		assert.Equal(t, "0000000900000001", hex.EncodeToString(credential[:8]))
+
+		publicKey, _, err := device.GetPubKey(keychain[owner], false, "", "")
+		require.NoError(t, err)
+		assert.True(t, ledger.VerifySignature(publicKey, hash[:], credential[8:8+64]), keychain[owner])
+	}
+}
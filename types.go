@@ -19,14 +19,22 @@ package ledger_avalanche_go
 import (
 	"fmt"
 	"github.com/zondax/ledger-go"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
-	CLA     = 0x80
-	CLA_ETH = 0xE0
+	CLA       = 0x80
+	CLA_ETH   = 0xE0
+	CLA_BOLOS = 0xB0
+	// CLA_DASHBOARD is answered by the dashboard, when no app is running
+	CLA_DASHBOARD = 0xE0
 
-	CHUNK_SIZE = 250
-	HASH_LEN   = 32
+	CHUNK_SIZE        = 250
+	HASH_LEN          = 32
+	SIGNATURE_LEN     = 65
+	MAX_APDU_DATA_LEN = 255
 
 	PAYLOAD_INIT = 0x00
 	PAYLOAD_ADD  = 0x01
@@ -47,6 +55,21 @@ const (
 	INS_SIGN                    = 0x05
 	INS_SIGN_MSG                = 0x06
 
+	INS_GET_APP_INFO    = 0x01
+	INS_GET_DEVICE_INFO = 0x01
+	// INS_OPEN_APP is sent with CLA_DASHBOARD, INS_QUIT_APP with CLA_BOLOS
+	INS_OPEN_APP = 0xD8
+	INS_QUIT_APP = 0xA7
+
+	// Ethereum instructions, sent with CLA_ETH
+	INS_SIGN_EVM_TX  = 0x04
+	INS_SIGN_EVM_MSG = 0x08
+
+	INS_PROVIDE_ERC20_TOKEN_INFO = 0x0A
+
+	P1_EVM_FIRST_CHUNK = 0x00
+	P1_EVM_MORE_CHUNKS = 0x80
+
 	userINSGetVersion       = 0
 	userINSSignSECP256K1    = 2
 	userINSGetAddrSecp256k1 = 4
@@ -54,8 +77,29 @@ const (
 	userMessageChunkSize = 250
 
 	HARDENED = 0x80000000
+	// MAX_BIP32_PATH is the deepest path accepted, as in the Ledger apps
+	MAX_BIP32_PATH = 10
+
+	AVAX_COIN_TYPE = 9000
+	EVM_COIN_TYPE  = 60
+
+	AVAX_MSG_PREFIX = "\x1AAvalanche Signed Message:\n"
+
+	DefaultHRP = "avax"
+)
+
+// PublicKeyFormat is the encoding of a secp256k1 public key
+type PublicKeyFormat int
+
+const (
+	// Compressed is the 33 bytes encoding
+	Compressed PublicKeyFormat = iota
+	// Uncompressed is the 65 bytes encoding, as used by EVM tooling
+	Uncompressed
 )
 
+// LedgerError is a status word returned by the device. Its String method gives the English
+// description of the known ones.
 type LedgerError int
 
 const (
@@ -67,26 +111,91 @@ const (
 	Timeout                     LedgerError = 14
 	NoErrors                    LedgerError = 0x9000
 	DeviceIsBusy                LedgerError = 0x9001
+	DeviceLocked                LedgerError = 0x5515
+	OpenAppRejected             LedgerError = 0x5501
+	AppNotInstalled             LedgerError = 0x6807
 	ErrorDerivingKeys           LedgerError = 0x6802
 	ExecutionError              LedgerError = 0x6400
 	WrongLength                 LedgerError = 0x6700
 	EmptyBuffer                 LedgerError = 0x6982
 	OutputBufferTooSmall        LedgerError = 0x6983
+	DataInvalidated             LedgerError = 0x6984
 	DataIsInvalid               LedgerError = 0x6a80
 	ConditionsNotSatisfied      LedgerError = 0x6985
 	TransactionRejected         LedgerError = 0x6986
 	BadKeyHandle                LedgerError = 0x6a81
 	InvalidP1P2                 LedgerError = 0x6b00
 	InstructionNotSupported     LedgerError = 0x6d00
+	ClaNotSupported             LedgerError = 0x6e00
 	AppDoesNotSeemToBeOpen      LedgerError = 0x6e01
 	UnknownError                LedgerError = 0x6f00
 	SignVerifyError             LedgerError = 0x6f01
+	AppNotRunning               LedgerError = 0x6511
+	DeviceInRecoveryMode        LedgerError = 0x662f
+	NotEnoughMemory             LedgerError = 0x6a84
+	IncorrectP1P2               LedgerError = 0x6a86
+	ReferencedDataNotFound      LedgerError = 0x6a88
+	UnknownAPDU                 LedgerError = 0x6d02
+	Halted                      LedgerError = 0x6faa
 )
 
-// LedgerAvalanche represents a connection to the Avax app in a Ledger device
+// LedgerAvalanche represents a connection to the Avax app in a Ledger device.
+// It is safe for concurrent use: exchanges are serialized, and operations started while
+// a transaction or message is being signed fail with ErrBusy.
 type LedgerAvalanche struct {
 	api     ledger_go.LedgerDevice
 	version VersionInfo
+
+	serializer          PathSerializer
+	errorTranslator     ErrorTranslator
+	apduLogger          APDULogger
+	middlewares         []Middleware
+	transport           Exchanger
+	auditLogger         AuditLogger
+	exchangeTimeout     time.Duration
+	confirmationTimeout time.Duration
+	enforceLowS         bool
+	maxSigningPaths     int
+	requireConfirmation bool
+	verifySignatures    bool
+	bindTxHash          bool
+	allowBlindSigning   bool
+	permissiveInputs    bool
+	requireReleaseApp   bool
+	pubKeyCache         *pubKeyCache
+	metrics             Metrics
+	extendedLength      bool
+	chunkSize           int
+	progress            ProgressFunc
+
+	minVersion       VersionInfo
+	skipVersionCheck bool
+	versionPolicy    VersionPolicy
+	versionWarning   VersionWarningFunc
+	commandVersions  map[command]VersionInfo
+	usbFilters       []USBFilter
+	bleScanner       BLEScanner
+	connectTimeout   time.Duration
+	connectAttempts  int
+	connectBackoff   time.Duration
+	expectedWalletID []byte
+
+	// deviceIndex is the HID device connected to, when reconnectable
+	deviceIndex       int
+	reconnectable     bool
+	keepAliveInterval time.Duration
+	stopKeepAlive     func()
+
+	// state guards version, pending, desynced, errorTranslator and apduLogger
+	state sync.Mutex
+	// pending is closed once an exchange abandoned after a timeout completes
+	pending  <-chan struct{}
+	desynced bool
+
+	// mu serializes the exchanges with the device, it is held for the whole signing flow
+	// while signing is set
+	mu      sync.Mutex
+	signing atomic.Bool
 }
 
 // VersionInfo contains app version information
@@ -101,12 +210,132 @@ func (c VersionInfo) String() string {
 	return fmt.Sprintf("%d.%d.%d", c.Major, c.Minor, c.Patch)
 }
 
+// Mode returns the kind of build of the app, as reported in AppMode
+func (c VersionInfo) Mode() AppMode {
+	return AppMode(c.AppMode)
+}
+
+// AppMode is the kind of build of the app, the first byte of the version response
+type AppMode uint8
+
+const (
+	// AppModeRelease is a production build
+	AppModeRelease AppMode = 0x00
+	// AppModeTest is a build with TESTING_ENABLED, used by the Zemu tests
+	AppModeTest AppMode = 0x01
+	// AppModeDebug is a build with debug output
+	AppModeDebug AppMode = 0x02
+)
+
+func (m AppMode) String() string {
+	switch m {
+	case AppModeRelease:
+		return "release"
+	case AppModeTest:
+		return "test"
+	case AppModeDebug:
+		return "debug"
+	}
+	return fmt.Sprintf("unknown mode 0x%02x", uint8(m))
+}
+
+// AddressResponse contains an address derived by the device
+type AddressResponse struct {
+	Path      string
+	PublicKey []byte
+	Hash      []byte
+	Address   string
+}
+
+// ExtendedPublicKey is a BIP-32 public key along with its chain code, from which the
+// public keys of its non-hardened children can be derived
+type ExtendedPublicKey struct {
+	Path      string
+	PublicKey []byte
+	ChainCode []byte
+}
+
+// AppInfo contains the information the device OS reports about the running app
+type AppInfo struct {
+	Name    string
+	Version string
+	Flags   []byte
+}
+
+// FirmwareInfo contains the information the dashboard reports about the device
+type FirmwareInfo struct {
+	TargetID   uint32
+	SEVersion  string
+	MCUVersion string
+	Flags      []byte
+}
+
+// Capabilities describes the settings of the running app that affect how it behaves.
+// The library can only report them; they are changed in the app settings on the device.
+type Capabilities struct {
+	// ExpertMode is set when the app shows the extended review screens
+	ExpertMode bool
+	// ExpertModeReported is false for older apps that do not report expert mode,
+	// in which case ExpertMode is meaningless
+	ExpertModeReported bool
+}
+
+// EVMSignature is a signature of an EVM transaction or message as returned by the device
+type EVMSignature struct {
+	V byte
+	R []byte
+	S []byte
+}
+
+// AppFingerprint identifies a build of the Avalanche app. It is made of the app name and
+// version string reported by the device OS (GetAppInfo) and the version reported by the
+// app itself (GetVersion), including its mode.
+type AppFingerprint struct {
+	Name       string
+	AppVersion string
+	Version    VersionInfo
+}
+
+// ErrVersionTooLow is returned when the app running on the device is older than required
+type ErrVersionTooLow struct {
+	Have VersionInfo
+	Want VersionInfo
+}
+
+// VersionRequiredError is the former version error.
+//
+// Deprecated: version checks return *ErrVersionTooLow, which errors.As still matches
+// with a *VersionRequiredError target.
 type VersionRequiredError struct {
 	Found    VersionInfo
 	Required VersionInfo
 }
 
+// ResponseSign contains the signatures collected from the device.
+// Signature indexes them by path suffix for lookup, while SignaturesOrdered holds the
+// same signatures in the exact order of the signing paths that were requested, so the
+// nth entry always corresponds to the nth signing path.
+//
+// Hash is the digest the device signed, as expected by VerifySignature.
+//
+// ReturnCode is the status word the device answered the last signing path with, NoErrors once
+// every signature was collected. When the device fails a signing path, the signatures collected
+// so far are returned along with the *APDUError, ReturnCode and ErrorMessage holding its status
+// word and description.
 type ResponseSign struct {
-	Hash      []byte
-	Signature map[string][]byte
+	Hash              []byte
+	Signature         map[string][]byte
+	SignaturesOrdered []PathSignature
+	ReturnCode        LedgerError
+	ErrorMessage      string
+}
+
+// PathSignature is a signature produced for a single signing path
+//
+// Signature is laid out as r || s || v, see Parsed for its components.
+type PathSignature struct {
+	Path      string
+	Signature []byte
+	// Hash is the digest the device signed for this path, for audit logging
+	Hash []byte
 }
@@ -0,0 +1,251 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/zondax/hid"
+	"github.com/zondax/ledger-go"
+)
+
+// ErrPermissionDenied is returned when a Ledger device was found but may not be opened by the
+// current user, typically on Linux without the Ledger udev rules installed. See PermissionError.
+var ErrPermissionDenied = errors.New("permission denied to open the ledger device")
+
+// LedgerUdevRulesURL points to the udev rules giving access to Ledger devices on Linux
+const LedgerUdevRulesURL = "https://github.com/LedgerHQ/udev-rules"
+
+// USBFilter selects the HID devices to connect to by USB vendor and product ID. A zero
+// ProductID matches any product of the vendor.
+type USBFilter struct {
+	VendorID  uint16
+	ProductID uint16
+}
+
+// DefaultUSBFilters selects the Ledger devices, as ledger-go does
+var DefaultUSBFilters = []USBFilter{{VendorID: ledger_go.VendorLedger}}
+
+// PermissionError reports a device that may not be opened, with what is needed to fix it
+type PermissionError struct {
+	Device DeviceInfo
+	// Node is the device file that may not be opened, e.g. /dev/bus/usb/001/004
+	Node string
+	// UdevRules grant access to the device when installed in /etc/udev/rules.d
+	UdevRules string
+}
+
+func (e *PermissionError) Error() string {
+	return fmt.Sprintf("%v: %s is not accessible, install udev rules (see %s) such as:\n%s",
+		ErrPermissionDenied, e.Node, LedgerUdevRulesURL, e.UdevRules)
+}
+
+func (e *PermissionError) Unwrap() error {
+	return ErrPermissionDenied
+}
+
+// UdevRules returns udev rules granting the plugdev group access to the devices selected by filters
+func UdevRules(filters ...USBFilter) string {
+	var rules strings.Builder
+	for _, f := range filters {
+		rules.WriteString(fmt.Sprintf(`SUBSYSTEMS=="usb", ATTRS{idVendor}=="%04x"`, f.VendorID))
+		if f.ProductID != 0 {
+			rules.WriteString(fmt.Sprintf(`, ATTRS{idProduct}=="%04x"`, f.ProductID))
+		}
+		rules.WriteString(`, MODE="0660", GROUP="plugdev", TAG+="uaccess"` + "\n")
+	}
+	return rules.String()
+}
+
+// enumerateHID lists the HID devices of a vendor and product, zero matching any
+var enumerateHID = hid.Enumerate
+
+// ListUSBDevices returns the connected devices selected by filters, in the order expected by
+// FindLedgerAvalancheAppOnDevice when WithUSBFilters(filters...) is set. Only the interfaces
+// ledger-go would connect to are listed.
+func ListUSBDevices(filters ...USBFilter) []DeviceInfo {
+	devices, _ := usbDevices(filters)
+	return devices
+}
+
+func usbDevices(filters []USBFilter) ([]DeviceInfo, []hid.DeviceInfo) {
+	var devices []DeviceInfo
+	var found []hid.DeviceInfo
+	seen := make(map[string]bool)
+	for _, f := range filters {
+		for _, d := range enumerateHID(f.VendorID, f.ProductID) {
+			if seen[d.Path] || !isLedgerInterface(d) {
+				continue
+			}
+			seen[d.Path] = true
+			devices = append(devices, newDeviceInfo(d))
+			found = append(found, d)
+		}
+	}
+	return devices, found
+}
+
+// isLedgerInterface matches the APDU interface of a device, also of models unknown to ledger-go
+func isLedgerInterface(d hid.DeviceInfo) bool {
+	if d.UsagePage == ledger_go.UsagePageLedgerNanoS {
+		return true
+	}
+	if _, known := ledgerProductIDs[d.ProductID]; known || d.UsagePage == 0 {
+		return d.Interface == 0
+	}
+	return false
+}
+
+// connectUSB connects to the HID device at index, among the Ledger devices or those selected
+// by the filters of the ledger. A device that may not be opened is reported as a PermissionError.
+func (ledger *LedgerAvalanche) connectUSB(index int) (ledger_go.LedgerDevice, error) {
+	filters := ledger.usbFilters
+	if len(filters) == 0 {
+		device, err := connectDevice(index)
+		if err != nil {
+			return nil, checkPermission(DefaultUSBFilters, ListLedgerDevices(), index, err)
+		}
+		return device, nil
+	}
+
+	devices, found := usbDevices(filters)
+	if index < 0 || index >= len(found) {
+		return nil, fmt.Errorf("USB device (idx %d) not found", index)
+	}
+	device, err := found[index].Open()
+	if err != nil {
+		return nil, checkPermission(filters, devices, index, err)
+	}
+	return newHIDDevice(device), nil
+}
+
+// openUSBNode opens a device file as hidapi does
+var openUSBNode = func(node string) error {
+	f, err := os.OpenFile(node, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// checkPermission returns a PermissionError if the device at index could not be opened for lack
+// of permissions, err otherwise. hidapi does not report why a device could not be opened, so the
+// device file is opened again on Linux to find out.
+func checkPermission(filters []USBFilter, devices []DeviceInfo, index int, err error) error {
+	if runtime.GOOS != "linux" || index < 0 || index >= len(devices) {
+		return err
+	}
+	node, ok := usbNode(devices[index].Path)
+	if !ok {
+		return err
+	}
+	if openErr := openUSBNode(node); !errors.Is(openErr, fs.ErrPermission) {
+		return err
+	}
+	return &PermissionError{Device: devices[index], Node: node, UdevRules: UdevRules(filters...)}
+}
+
+// usbNode returns the usbfs file of a device from its libusb hidapi path, "bus:address:interface"
+// in hexadecimal
+func usbNode(path string) (string, bool) {
+	var bus, address, iface int
+	if _, err := fmt.Sscanf(path, "%x:%x:%x", &bus, &address, &iface); err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("/dev/bus/usb/%03d/%03d", bus, address), true
+}
+
+// hidDevice exchanges APDUs with a HID device opened from a USBFilter, framed as ledger-go does
+type hidDevice struct {
+	device   *hid.Device
+	readOnce sync.Once
+	packets  chan []byte
+}
+
+func newHIDDevice(device *hid.Device) *hidDevice {
+	return &hidDevice{device: device, packets: make(chan []byte, 30)}
+}
+
+// read forwards the packets received until the device is closed, skipping the empty ones
+func (d *hidDevice) read() {
+	defer close(d.packets)
+	for {
+		buffer := make([]byte, ledger_go.PacketSize)
+		n, err := d.device.Read(buffer)
+		if err != nil {
+			return
+		}
+		if bytes.Count(buffer[:n], []byte{0}) == n {
+			continue
+		}
+		select {
+		case d.packets <- buffer[:n]:
+		default:
+			// nobody is waiting for a response
+		}
+	}
+}
+
+func (d *hidDevice) Exchange(command []byte) ([]byte, error) {
+	d.readOnce.Do(func() { go d.read() })
+	// drop the packets of a previous, abandoned exchange
+	for drained := false; !drained; {
+		select {
+		case <-d.packets:
+		default:
+			drained = true
+		}
+	}
+
+	packets, err := ledger_go.WrapCommandAPDU(ledger_go.Channel, command, ledger_go.PacketSize)
+	if err != nil {
+		return nil, err
+	}
+	for len(packets) > 0 {
+		n, err := d.device.Write(packets)
+		if err != nil {
+			return nil, err
+		}
+		packets = packets[n:]
+	}
+
+	response, err := ledger_go.UnwrapResponseAPDU(ledger_go.Channel, d.packets, ledger_go.PacketSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) < 2 {
+		return nil, fmt.Errorf("len(response) < 2")
+	}
+
+	swOffset := len(response) - 2
+	sw := uint16(response[swOffset])<<8 | uint16(response[swOffset+1])
+	if sw != 0x9000 {
+		return response[:swOffset], errors.New(ledger_go.ErrorMessage(sw))
+	}
+	return response[:swOffset], nil
+}
+
+func (d *hidDevice) Close() error {
+	return d.device.Close()
+}
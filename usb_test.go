@@ -0,0 +1,111 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"errors"
+	"io/fs"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zondax/hid"
+	"github.com/zondax/ledger-go"
+)
+
+func withUSB(t *testing.T, found []hid.DeviceInfo, openErr error) {
+	originalEnumerate, originalOpen := enumerateHID, openUSBNode
+	enumerateHID = func(vendorID uint16, productID uint16) []hid.DeviceInfo {
+		var matching []hid.DeviceInfo
+		for _, d := range found {
+			if d.VendorID == vendorID && (productID == 0 || d.ProductID == productID) {
+				matching = append(matching, d)
+			}
+		}
+		return matching
+	}
+	openUSBNode = func(string) error { return openErr }
+	t.Cleanup(func() { enumerateHID, openUSBNode = originalEnumerate, originalOpen })
+}
+
+func Test_UdevRules(t *testing.T) {
+	assert.Equal(t,
+		`SUBSYSTEMS=="usb", ATTRS{idVendor}=="2c97", MODE="0660", GROUP="plugdev", TAG+="uaccess"`+"\n"+
+			`SUBSYSTEMS=="usb", ATTRS{idVendor}=="1234", ATTRS{idProduct}=="0042", MODE="0660", GROUP="plugdev", TAG+="uaccess"`+"\n",
+		UdevRules(append(DefaultUSBFilters, USBFilter{VendorID: 0x1234, ProductID: 0x42})...))
+}
+
+func Test_ListUSBDevices(t *testing.T) {
+	withUSB(t, []hid.DeviceInfo{
+		{Path: "0001:0004:00", VendorID: ledger_go.VendorLedger, ProductID: 0x6011, UsagePage: ledger_go.UsagePageLedgerNanoS},
+		{Path: "0001:0004:01", VendorID: ledger_go.VendorLedger, ProductID: 0x6011, UsagePage: 0xf1d0, Interface: 1},
+		{Path: "0001:0005:00", VendorID: 0x1234, ProductID: 0x42},
+		{Path: "0001:0006:00", VendorID: 0x1234, ProductID: 0x43},
+	}, nil)
+
+	devices := ListUSBDevices(append(DefaultUSBFilters, USBFilter{VendorID: 0x1234, ProductID: 0x42}, USBFilter{VendorID: 0x1234})...)
+	require.Len(t, devices, 3)
+	assert.Equal(t, DeviceInfo{Path: "0001:0004:00", VendorID: ledger_go.VendorLedger, ProductID: 0x6011}, devices[0])
+	assert.Equal(t, "0001:0005:00", devices[1].Path)
+	assert.Equal(t, "0001:0006:00", devices[2].Path)
+
+	assert.Len(t, ListLedgerDevices(), 1)
+}
+
+func Test_UsbNode(t *testing.T) {
+	node, ok := usbNode("0001:000a:00")
+	require.True(t, ok)
+	assert.Equal(t, "/dev/bus/usb/001/010", node)
+
+	_, ok = usbNode("IOService:/nanos")
+	assert.False(t, ok)
+}
+
+func Test_ConnectPermissionDenied(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("permissions are only diagnosed on Linux")
+	}
+	withUSB(t, []hid.DeviceInfo{{Path: "0001:0004:00", VendorID: ledger_go.VendorLedger, ProductID: 0x4011}}, fs.ErrPermission)
+	openErr := errors.New("hidapi: failed to open device")
+	withConnectDevice(t, func(int) (ledger_go.LedgerDevice, error) { return nil, openErr })
+
+	_, err := FindLedgerAvalancheApp()
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	var permissionErr *PermissionError
+	require.ErrorAs(t, err, &permissionErr)
+	assert.Equal(t, "/dev/bus/usb/001/004", permissionErr.Node)
+	assert.Equal(t, "0001:0004:00", permissionErr.Device.Path)
+	assert.Contains(t, permissionErr.UdevRules, `ATTRS{idVendor}=="2c97"`)
+	assert.Contains(t, err.Error(), LedgerUdevRulesURL)
+
+	// the device file can be opened: the original error is kept
+	withUSB(t, []hid.DeviceInfo{{Path: "0001:0004:00", VendorID: ledger_go.VendorLedger, ProductID: 0x4011}}, nil)
+	_, err = FindLedgerAvalancheApp()
+	assert.ErrorIs(t, err, openErr)
+}
+
+func Test_ConnectWithUSBFilters(t *testing.T) {
+	withUSB(t, []hid.DeviceInfo{{Path: "0001:0004:00", VendorID: ledger_go.VendorLedger, ProductID: 0x4011}}, nil)
+	withConnectDevice(t, func(int) (ledger_go.LedgerDevice, error) {
+		t.Fatal("the filters are ignored")
+		return nil, nil
+	})
+
+	_, err := FindLedgerAvalancheApp(WithUSBFilters(USBFilter{VendorID: 0x1234}))
+	assert.ErrorContains(t, err, "not found")
+}
@@ -0,0 +1,74 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+// RecoverPublicKey returns the public key that made signature ([r | s | v], v being the
+// recovery id) over hash
+func RecoverPublicKey(signature, hash []byte) (*btcec.PublicKey, error) {
+	if len(signature) != SIGNATURE_LEN || signature[64] > 3 {
+		return nil, fmt.Errorf("invalid signature %x", signature)
+	}
+
+	// [27 + recovery id + 4 (compressed) | r | s]
+	compact := append([]byte{27 + signature[64] + 4}, signature[:64]...)
+	publicKey, _, err := ecdsa.RecoverCompact(compact, hash)
+	return publicKey, err
+}
+
+// verifyResponse checks that each signature of response was made by the key at its path under pathPrefix
+func (ledger *LedgerAvalanche) verifyResponse(ctx context.Context, pathPrefix string, response *ResponseSign) error {
+	for _, signature := range response.SignaturesOrdered {
+		path := pathPrefix + "/" + signature.Path
+		expected, err := ledger.retrievePubKey(ctx, path)
+		if err != nil {
+			return err
+		}
+
+		recovered, err := RecoverPublicKey(signature.Signature, signature.Hash)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrSignatureMismatch, path, err)
+		}
+		if expectedKey, err := btcec.ParsePubKey(expected); err != nil || !expectedKey.IsEqual(recovered) {
+			return fmt.Errorf("%w: %s", ErrSignatureMismatch, path)
+		}
+	}
+	return nil
+}
+
+// retrievePubKey returns the public key at path, never showing it on the device
+func (ledger *LedgerAvalanche) retrievePubKey(ctx context.Context, path string) ([]byte, error) {
+	message, err := ledger.pubKeyAPDU(INS_GET_ADDR, P1_ONLY_RETRIEVE, path, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := ledger.exchangeContext(ctx, message, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// [publicKeyLen | publicKey | hash]
+	return newResponseDecoder(response).lengthPrefixed()
+}
@@ -0,0 +1,83 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// signedBy answers SIGN_HASH with a signature of the hash by key, and GET_ADDR with publicKey
+func signedBy(key *btcec.PrivateKey, publicKey []byte) func([]byte) ([]byte, error) {
+	var hash []byte
+	return func(apdu []byte) ([]byte, error) {
+		switch {
+		case apdu[1] == INS_SIGN_HASH && apdu[2] == FIRST_MESSAGE:
+			hash = apdu[len(apdu)-HASH_LEN:]
+			return []byte{}, nil
+		case apdu[1] == INS_SIGN_HASH:
+			compact, _ := ecdsa.SignCompact(key, hash, true)
+			// [r | s | recovery id]
+			return append(compact[1:], compact[0]-27-4), nil
+		case apdu[1] == INS_GET_ADDR:
+			return append(append([]byte{byte(len(publicKey))}, publicKey...), AddressHash(publicKey)...), nil
+		}
+		return []byte{}, nil
+	}
+}
+
+func Test_SignatureVerification(t *testing.T) {
+	key, _ := btcec.NewPrivateKey()
+	device := &mockDevice{handler: signedBy(key, key.PubKey().SerializeCompressed())}
+	ledger := newMockLedger(device, WithSignatureVerification(), AllowBlindSigning(true))
+
+	response, err := ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	require.NoError(t, err)
+	assert.Len(t, response.SignaturesOrdered, 1)
+	assert.Equal(t, byte(INS_GET_ADDR), device.sent[len(device.sent)-1][1])
+	assert.Equal(t, byte(P1_ONLY_RETRIEVE), device.sent[len(device.sent)-1][2])
+
+	other, _ := btcec.NewPrivateKey()
+	ledger = newMockLedger(&mockDevice{handler: signedBy(key, other.PubKey().SerializeCompressed())}, WithSignatureVerification(), AllowBlindSigning(true))
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, ErrSignatureMismatch)
+
+	// a public key length past the end of the response
+	signer := signedBy(key, nil)
+	ledger = newMockLedger(&mockDevice{handler: func(apdu []byte) ([]byte, error) {
+		if apdu[1] == INS_GET_ADDR {
+			return []byte{33, 0x02}, nil
+		}
+		return signer(apdu)
+	}}, WithSignatureVerification(), AllowBlindSigning(true))
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func Test_RecoverPublicKeyInvalid(t *testing.T) {
+	_, err := RecoverPublicKey(make([]byte, 64), make([]byte, HASH_LEN))
+	assert.Error(t, err)
+
+	signature := make([]byte, SIGNATURE_LEN)
+	signature[64] = 4
+	_, err = RecoverPublicKey(signature, make([]byte, HASH_LEN))
+	assert.Error(t, err)
+}
@@ -0,0 +1,122 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"errors"
+)
+
+// VersionPolicy decides how the minimum version of the app is enforced
+type VersionPolicy int
+
+const (
+	// VersionPolicyStrict refuses to connect to an app older than the minimum version (default)
+	VersionPolicyStrict VersionPolicy = iota
+	// VersionPolicyWarnOnly connects to older apps, reporting the version error to the
+	// VersionWarningFunc instead
+	VersionPolicyWarnOnly
+	// VersionPolicyPerCommand connects to older apps as VersionPolicyWarnOnly, and checks the
+	// minimum version of each instruction before sending it, see WithCommandMinVersion.
+	// Signing instructions require the minimum version, read-only ones do not.
+	VersionPolicyPerCommand
+)
+
+func (p VersionPolicy) String() string {
+	switch p {
+	case VersionPolicyStrict:
+		return "strict"
+	case VersionPolicyWarnOnly:
+		return "warn-only"
+	case VersionPolicyPerCommand:
+		return "per-command"
+	default:
+		return "unknown"
+	}
+}
+
+// VersionWarningFunc receives the version error of an app accepted despite being older than
+// the minimum version, see WithVersionWarning
+type VersionWarningFunc func(err *ErrVersionTooLow)
+
+// command identifies an instruction by its class, the Avalanche and Ethereum apps reusing
+// the same instruction bytes
+type command struct {
+	cla, ins byte
+}
+
+// signingCommands require the minimum app version under VersionPolicyPerCommand
+var signingCommands = []command{
+	{CLA, INS_SIGN},
+	{CLA, INS_SIGN_MSG},
+	{CLA, INS_SIGN_HASH},
+	{CLA_ETH, INS_SIGN_EVM_TX},
+	{CLA_ETH, INS_SIGN_EVM_MSG},
+}
+
+// applyVersionPolicy returns err, the result of checking the minimum app version on connection,
+// unless the version policy accepts older apps
+func (ledger *LedgerAvalanche) applyVersionPolicy(err error) error {
+	var tooLow *ErrVersionTooLow
+	if ledger.versionPolicy == VersionPolicyStrict || !errors.As(err, &tooLow) {
+		return err
+	}
+	if ledger.versionWarning != nil {
+		ledger.versionWarning(tooLow)
+	}
+	return nil
+}
+
+// commandMinVersion returns the minimum app version required by instruction ins of class cla, if any
+func (ledger *LedgerAvalanche) commandMinVersion(cla, ins byte) (VersionInfo, bool) {
+	cmd := command{cla, ins}
+	if version, ok := ledger.commandVersions[cmd]; ok {
+		return version, true
+	}
+	for _, signing := range signingCommands {
+		if cmd == signing {
+			return ledger.minVersion, true
+		}
+	}
+	return VersionInfo{}, false
+}
+
+// checkCommandVersion fails with an *ErrVersionTooLow when the app is older than the minimum
+// version of instruction ins of class cla under VersionPolicyPerCommand. The version read on
+// connection is used when known.
+func (ledger *LedgerAvalanche) checkCommandVersion(ctx context.Context, cla, ins byte) error {
+	if ledger.versionPolicy != VersionPolicyPerCommand {
+		return nil
+	}
+	min, ok := ledger.commandMinVersion(cla, ins)
+	if !ok {
+		return nil
+	}
+
+	ledger.state.Lock()
+	version := ledger.version
+	ledger.state.Unlock()
+
+	if version == (VersionInfo{}) {
+		current, err := ledger.GetVersionContext(ctx)
+		if err != nil {
+			return err
+		}
+		version = *current
+	}
+	return CheckVersion(version, min)
+}
@@ -0,0 +1,103 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_VersionPolicyWarnOnly(t *testing.T) {
+	old := []byte{0, 0, 6, 0}
+	var warnings []*ErrVersionTooLow
+	device := &mockDevice{handler: replies(old, old)}
+
+	_, err := FindLedgerAvalancheApp(WithDevice(device), WithVersionPolicy(VersionPolicyWarnOnly),
+		WithVersionWarning(func(err *ErrVersionTooLow) { warnings = append(warnings, err) }))
+	require.NoError(t, err)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, VersionInfo{0, 0, 6, 0}, warnings[0].Have)
+	assert.Equal(t, MinAppVersion, warnings[0].Want)
+
+	device = &mockDevice{handler: replies(old, old)}
+	_, err = FindLedgerAvalancheApp(WithDevice(device))
+	var tooLow *ErrVersionTooLow
+	assert.ErrorAs(t, err, &tooLow)
+}
+
+func Test_VersionPolicyPerCommand(t *testing.T) {
+	old := []byte{0, 0, 6, 0}
+	pubKey := append(make([]byte, 33), make([]byte, 20)...)
+	device := &mockDevice{handler: replies(old, old, pubKey)}
+
+	ledger, err := FindLedgerAvalancheApp(WithDevice(device), WithVersionPolicy(VersionPolicyPerCommand))
+	require.NoError(t, err)
+
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	require.NoError(t, err)
+
+	sent := len(device.sent)
+	var tooLow *ErrVersionTooLow
+	_, err = ledger.Sign("m/44'/9000'/0'", []string{"0/0"}, []byte{1, 2, 3}, nil)
+	assert.ErrorAs(t, err, &tooLow)
+	_, err = ledger.SignHash("m/44'/9000'/0'", []string{"0/0"}, make([]byte, HASH_LEN))
+	assert.Error(t, err)
+	_, err = ledger.SignEVMTransaction("m/44'/60'/0'/0/0", []byte{0xc0})
+	assert.ErrorAs(t, err, &tooLow)
+	_, err = ledger.SignEVMMessage("m/44'/60'/0'/0/0", []byte("hello"))
+	assert.ErrorAs(t, err, &tooLow)
+	assert.Len(t, device.sent, sent)
+
+	// the minimum version of read-only instructions can be set as well
+	device = &mockDevice{handler: replies(old, old)}
+	ledger, err = FindLedgerAvalancheApp(WithDevice(device), WithVersionPolicy(VersionPolicyPerCommand),
+		WithCommandMinVersion(CLA, INS_GET_ADDR, VersionInfo{0, 0, 6, 1}))
+	require.NoError(t, err)
+	_, _, err = ledger.GetPubKey("m/44'/9000'/0'/0/0", false, "", "")
+	assert.ErrorAs(t, err, &tooLow)
+}
+
+func Test_CommandMinVersion(t *testing.T) {
+	ledger := newMockLedger(&mockDevice{}, WithMinAppVersion(VersionInfo{0, 0, 7, 0}),
+		WithCommandMinVersion(CLA, INS_SIGN_MSG, VersionInfo{0, 0, 8, 0}),
+		WithCommandMinVersion(CLA_ETH, INS_SIGN_EVM_TX, VersionInfo{0, 0, 9, 0}))
+
+	min, ok := ledger.commandMinVersion(CLA, INS_SIGN)
+	assert.True(t, ok)
+	assert.Equal(t, VersionInfo{0, 0, 7, 0}, min)
+
+	min, ok = ledger.commandMinVersion(CLA, INS_SIGN_MSG)
+	assert.True(t, ok)
+	assert.Equal(t, VersionInfo{0, 0, 8, 0}, min)
+
+	// INS_SIGN_EVM_TX and INS_SIGN_HASH share the same byte
+	min, ok = ledger.commandMinVersion(CLA_ETH, INS_SIGN_EVM_TX)
+	assert.True(t, ok)
+	assert.Equal(t, VersionInfo{0, 0, 9, 0}, min)
+	min, ok = ledger.commandMinVersion(CLA, INS_SIGN_HASH)
+	assert.True(t, ok)
+	assert.Equal(t, VersionInfo{0, 0, 7, 0}, min)
+
+	min, ok = ledger.commandMinVersion(CLA_ETH, INS_SIGN_EVM_MSG)
+	assert.True(t, ok)
+	assert.Equal(t, VersionInfo{0, 0, 7, 0}, min)
+
+	_, ok = ledger.commandMinVersion(CLA, INS_GET_ADDR)
+	assert.False(t, ok)
+}
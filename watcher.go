@@ -0,0 +1,217 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DeviceEventType is the kind of change reported by a DeviceWatcher
+type DeviceEventType int
+
+const (
+	// DeviceConnected is reported when a Ledger device is plugged in
+	DeviceConnected DeviceEventType = iota
+	// DeviceDisconnected is reported when a Ledger device is unplugged
+	DeviceDisconnected
+	// AppOpened is reported when the Avalanche app is found running on a device
+	AppOpened
+	// AppClosed is reported when the Avalanche app stops running on a device
+	AppClosed
+)
+
+func (t DeviceEventType) String() string {
+	switch t {
+	case DeviceConnected:
+		return "connected"
+	case DeviceDisconnected:
+		return "disconnected"
+	case AppOpened:
+		return "app opened"
+	case AppClosed:
+		return "app closed"
+	}
+	return fmt.Sprintf("unknown event %d", int(t))
+}
+
+// DeviceEvent is a change of a Ledger device seen by a DeviceWatcher
+type DeviceEvent struct {
+	Type   DeviceEventType
+	Device DeviceInfo
+}
+
+// listDevices returns the connected Ledger devices, replaced by tests
+var listDevices = ListLedgerDevices
+
+// DeviceWatcher reports Ledger devices being plugged and unplugged, and the Avalanche app being
+// opened and closed on them. The HID library offers no hotplug notifications, so devices are
+// listed every interval.
+//
+// Devices without the app are probed by connecting to them by path. A device that already runs
+// the app is not probed again, so it does not compete with the connection of a wallet: opening or
+// closing an app makes a device reconnect over USB, which is seen as it being unplugged and
+// plugged. A device staying in the dashboard is probed less and less often, up to every
+// maxProbeSkip+1 intervals, as the app opening on it shows up as a new device anyway.
+type DeviceWatcher struct {
+	events   chan DeviceEvent
+	stop     chan struct{}
+	done     chan struct{}
+	interval time.Duration
+	once     sync.Once
+
+	// devices holds the connected devices by path
+	devices map[string]*watchedDevice
+}
+
+// maxProbeSkip is the most polls skipped between two probes of a device without the app
+const maxProbeSkip = 16
+
+// watchedDevice is a connected device and whether the app runs on it
+type watchedDevice struct {
+	info    DeviceInfo
+	appOpen bool
+	// skip is the number of polls between two probes, grown after every failed probe
+	skip      int
+	nextProbe int
+}
+
+// NewDeviceWatcher starts watching the Ledger devices every interval. Devices connected when it
+// starts are reported as DeviceConnected, and AppOpened if the app runs on them.
+func NewDeviceWatcher(interval time.Duration) *DeviceWatcher {
+	w := &DeviceWatcher{
+		events:   make(chan DeviceEvent, 16),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		interval: interval,
+		devices:  map[string]*watchedDevice{},
+	}
+	go w.run()
+	return w
+}
+
+// Events returns the channel of the changes seen, closed once the watcher is closed
+func (w *DeviceWatcher) Events() <-chan DeviceEvent {
+	return w.events
+}
+
+// Close stops watching the devices
+func (w *DeviceWatcher) Close() {
+	w.once.Do(func() { close(w.stop) })
+	<-w.done
+}
+
+func (w *DeviceWatcher) run() {
+	defer close(w.done)
+	defer close(w.events)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		if !w.poll() {
+			return
+		}
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll compares the connected devices with the previous poll, returning false once closed
+func (w *DeviceWatcher) poll() bool {
+	devices := listDevices()
+
+	connected := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		connected[device.Path] = true
+	}
+	for path, watched := range w.devices {
+		if connected[path] {
+			continue
+		}
+		delete(w.devices, path)
+		if watched.appOpen && !w.send(DeviceEvent{Type: AppClosed, Device: watched.info}) {
+			return false
+		}
+		if !w.send(DeviceEvent{Type: DeviceDisconnected, Device: watched.info}) {
+			return false
+		}
+	}
+
+	for _, device := range devices {
+		watched, known := w.devices[device.Path]
+		if !known {
+			watched = &watchedDevice{info: device}
+			w.devices[device.Path] = watched
+			if !w.send(DeviceEvent{Type: DeviceConnected, Device: device}) {
+				return false
+			}
+		}
+		if watched.appOpen || !w.probe(watched) {
+			continue
+		}
+		watched.appOpen = true
+		if !w.send(DeviceEvent{Type: AppOpened, Device: device}) {
+			return false
+		}
+	}
+	return true
+}
+
+// send delivers event unless the watcher is closed first
+func (w *DeviceWatcher) send(event DeviceEvent) bool {
+	select {
+	case w.events <- event:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
+
+// probe reports whether the Avalanche app answers on a device, unless it is skipped on this poll
+func (w *DeviceWatcher) probe(watched *watchedDevice) bool {
+	if watched.nextProbe > 0 {
+		watched.nextProbe--
+		return false
+	}
+	if probeApp(watched.info.Path) {
+		return true
+	}
+
+	watched.skip = watched.skip*2 + 1
+	if watched.skip > maxProbeSkip {
+		watched.skip = maxProbeSkip
+	}
+	watched.nextProbe = watched.skip
+	return false
+}
+
+// probeApp reports whether the Avalanche app answers on the device at path
+func probeApp(path string) bool {
+	ledger, err := ConnectByPath(path, SkipVersionCheck())
+	if err != nil {
+		return false
+	}
+	defer ledger.Close()
+
+	_, err = ledger.GetVersion()
+	return err == nil
+}
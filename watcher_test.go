@@ -0,0 +1,144 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zondax/ledger-go"
+)
+
+// fakeDevices replaces the listed devices and the connections to them
+type fakeDevices struct {
+	mu      sync.Mutex
+	devices []DeviceInfo
+	appOpen map[string]bool
+	probes  map[string]int
+}
+
+func withFakeDevices(t *testing.T) *fakeDevices {
+	fake := &fakeDevices{appOpen: map[string]bool{}, probes: map[string]int{}}
+
+	originalList := listDevices
+	listDevices = func() []DeviceInfo {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return append([]DeviceInfo{}, fake.devices...)
+	}
+	t.Cleanup(func() { listDevices = originalList })
+
+	withConnectDevice(t, func(index int) (ledger_go.LedgerDevice, error) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		appOpen := index < len(fake.devices) && fake.appOpen[fake.devices[index].Path]
+		if index < len(fake.devices) {
+			fake.probes[fake.devices[index].Path]++
+		}
+		return &mockDevice{handler: func([]byte) ([]byte, error) {
+			if !appOpen {
+				return nil, statusError(ClaNotSupported)
+			}
+			return []byte{0, 0, 6, 5}, nil
+		}}, nil
+	})
+	return fake
+}
+
+func (f *fakeDevices) set(appOpen map[string]bool, devices ...DeviceInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.devices = devices
+	f.appOpen = appOpen
+}
+
+func nextEvent(t *testing.T, w *DeviceWatcher) DeviceEvent {
+	select {
+	case event, ok := <-w.Events():
+		require.True(t, ok, "events closed")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return DeviceEvent{}
+}
+
+func Test_DeviceWatcher(t *testing.T) {
+	fake := withFakeDevices(t)
+	nanoS := DeviceInfo{Path: "usb-1", Product: "Nano S"}
+	nanoX := DeviceInfo{Path: "usb-2", Product: "Nano X"}
+	fake.set(nil, nanoS)
+
+	w := NewDeviceWatcher(time.Millisecond)
+	defer w.Close()
+
+	assert.Equal(t, DeviceEvent{Type: DeviceConnected, Device: nanoS}, nextEvent(t, w))
+
+	// opening the app makes the device reconnect
+	fake.set(nil)
+	assert.Equal(t, DeviceEvent{Type: DeviceDisconnected, Device: nanoS}, nextEvent(t, w))
+	fake.set(map[string]bool{"usb-1": true}, nanoS, nanoX)
+	assert.Equal(t, DeviceEvent{Type: DeviceConnected, Device: nanoS}, nextEvent(t, w))
+	assert.Equal(t, DeviceEvent{Type: AppOpened, Device: nanoS}, nextEvent(t, w))
+	assert.Equal(t, DeviceEvent{Type: DeviceConnected, Device: nanoX}, nextEvent(t, w))
+
+	fake.set(nil, nanoX)
+	assert.Equal(t, DeviceEvent{Type: AppClosed, Device: nanoS}, nextEvent(t, w))
+	assert.Equal(t, DeviceEvent{Type: DeviceDisconnected, Device: nanoS}, nextEvent(t, w))
+}
+
+func Test_DeviceWatcherProbeBackoff(t *testing.T) {
+	fake := withFakeDevices(t)
+	nanoS := DeviceInfo{Path: "usb-1"}
+	nanoX := DeviceInfo{Path: "usb-2"}
+	fake.set(map[string]bool{"usb-2": true}, nanoS)
+
+	w := &DeviceWatcher{events: make(chan DeviceEvent, 16), stop: make(chan struct{}), devices: map[string]*watchedDevice{}}
+	for i := 0; i < 60; i++ {
+		require.True(t, w.poll())
+	}
+	// probed on polls 0, 2, 6, 14, 30 and 47
+	assert.Equal(t, 6, fake.probes["usb-1"])
+
+	// the device probed is found by path, whatever its index
+	fake.set(map[string]bool{"usb-2": true}, nanoX, nanoS)
+	require.True(t, w.poll())
+	assert.Equal(t, 1, fake.probes["usb-2"])
+	assert.True(t, w.devices["usb-2"].appOpen)
+}
+
+func Test_DeviceWatcherClose(t *testing.T) {
+	fake := withFakeDevices(t)
+	fake.set(nil, DeviceInfo{Path: "usb-1"})
+
+	w := NewDeviceWatcher(time.Millisecond)
+	w.Close()
+	w.Close()
+
+	for range w.Events() {
+	}
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+}
+
+func Test_DeviceEventTypeString(t *testing.T) {
+	assert.Equal(t, "app opened", AppOpened.String())
+	assert.Equal(t, "unknown event 9", DeviceEventType(9).String())
+}
@@ -0,0 +1,72 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"sync"
+)
+
+// findApp connects to the Avalanche app for WithLedger
+var findApp = FindLedgerAvalancheApp
+
+// WithLedger finds the Avalanche app, runs fn with it and closes the connection afterwards,
+// even if fn panics. Cancelling ctx closes the device right away, which makes a pending
+// operation fail; in that case the context error is returned.
+func WithLedger(ctx context.Context, fn func(*LedgerAvalanche) error) (rerr error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ledger, err := findApp()
+	if err != nil {
+		return err
+	}
+
+	var once sync.Once
+	var closeErr error
+	closeLedger := func() {
+		once.Do(func() {
+			closeErr = ledger.Close()
+		})
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			closeLedger()
+		case <-stop:
+		}
+	}()
+
+	defer func() {
+		close(stop)
+		closeLedger()
+		if rerr == nil {
+			rerr = closeErr
+		}
+	}()
+
+	if err := fn(ledger); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+	return nil
+}
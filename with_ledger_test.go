@@ -0,0 +1,79 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withMockApp(t *testing.T, device *mockDevice) {
+	previous := findApp
+	findApp = func(opts ...Option) (*LedgerAvalanche, error) {
+		return newMockLedger(device, opts...), nil
+	}
+	t.Cleanup(func() { findApp = previous })
+}
+
+func Test_WithLedger(t *testing.T) {
+	device := &mockDevice{handler: replies([]byte{0, 0, 6, 5})}
+	withMockApp(t, device)
+
+	err := WithLedger(context.Background(), func(ledger *LedgerAvalanche) error {
+		_, err := ledger.GetVersion()
+		return err
+	})
+	assert.NoError(t, err)
+	assert.True(t, device.closed)
+}
+
+func Test_WithLedgerPanic(t *testing.T) {
+	device := &mockDevice{}
+	withMockApp(t, device)
+
+	assert.Panics(t, func() {
+		_ = WithLedger(context.Background(), func(*LedgerAvalanche) error {
+			panic("boom")
+		})
+	})
+	assert.True(t, device.closed)
+}
+
+func Test_WithLedgerCancelled(t *testing.T) {
+	device := &mockDevice{}
+	withMockApp(t, device)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := WithLedger(ctx, func(*LedgerAvalanche) error {
+		// Wait for the device to be closed by the cancellation
+		for {
+			device.mu.Lock()
+			closed := device.closed
+			device.mu.Unlock()
+			if closed {
+				return ErrDeviceDisconnected
+			}
+			time.Sleep(time.Millisecond)
+		}
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
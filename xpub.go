@@ -0,0 +1,139 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 ZondaX AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const chainCodeLen = 32
+
+// GetExtendedPubKey returns the public key and chain code at path, e.g. the external chain
+// of an account ("m/44'/9000'/0'/0"), so its addresses can be derived with DeriveAddresses
+// without further device interaction
+func (ledger *LedgerAvalanche) GetExtendedPubKey(path string, hrp string, chainid string) (_ *ExtendedPublicKey, err error) {
+	defer ledger.observe(OperationGetExtendedPubKey, time.Now(), &err)
+
+	message, err := ledger.pubKeyAPDU(INS_GET_EXTENDED_PUBLIC_KEY, P1_ONLY_RETRIEVE, path, hrp, chainid)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := ledger.exchange(message)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, chainCode, err := parseExtendedPubKeyResponse(response)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := btcec.ParsePubKey(publicKey); err != nil {
+		return nil, ErrMalformedResponse
+	}
+
+	return &ExtendedPublicKey{
+		Path:      path,
+		PublicKey: append([]byte{}, publicKey...),
+		ChainCode: append([]byte{}, chainCode...),
+	}, nil
+}
+
+// Child derives the extended public key of the non-hardened child index (BIP-32 CKDpub)
+func (xpub *ExtendedPublicKey) Child(index uint32) (*ExtendedPublicKey, error) {
+	if index >= HARDENED {
+		return nil, errors.New("hardened children cannot be derived from a public key")
+	}
+
+	parent, err := btcec.ParsePubKey(xpub.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, xpub.ChainCode)
+	mac.Write(parent.SerializeCompressed())
+	_ = binary.Write(mac, binary.BigEndian, index)
+	i := mac.Sum(nil)
+
+	var tweak btcec.ModNScalar
+	if tweak.SetByteSlice(i[:32]) {
+		return nil, errors.New("invalid child key")
+	}
+
+	var tweakPoint, parentPoint, child btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&tweak, &tweakPoint)
+	parent.AsJacobian(&parentPoint)
+	btcec.AddNonConst(&tweakPoint, &parentPoint, &child)
+	if (child.X.IsZero() && child.Y.IsZero()) || child.Z.IsZero() {
+		return nil, errors.New("invalid child key")
+	}
+	child.ToAffine()
+
+	return &ExtendedPublicKey{
+		Path:      fmt.Sprintf("%s/%d", xpub.Path, index),
+		PublicKey: btcec.NewPublicKey(&child.X, &child.Y).SerializeCompressed(),
+		ChainCode: i[32:],
+	}, nil
+}
+
+// DeriveAddresses derives count addresses of xpub starting at index start, as GetPubKey would
+// return them for xpub.Path/index. The addresses are encoded with hrp, DefaultHRP if empty.
+func DeriveAddresses(xpub *ExtendedPublicKey, hrp string, start, count uint32) ([]AddressResponse, error) {
+	if hrp == "" {
+		hrp = DefaultHRP
+	}
+
+	addresses := make([]AddressResponse, 0, count)
+	for index := start; index < start+count; index++ {
+		child, err := xpub.Child(index)
+		if err != nil {
+			return nil, err
+		}
+
+		hash := AddressHash(child.PublicKey)
+		address, err := encodeBech32(hrp, hash)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, AddressResponse{
+			Path:      child.Path,
+			PublicKey: child.PublicKey,
+			Hash:      hash,
+			Address:   address,
+		})
+	}
+	return addresses, nil
+}
+
+// AddressHash returns the 20-byte address of a public key: ripemd160(sha256(publicKey))
+func AddressHash(publicKey []byte) []byte {
+	sha := sha256.Sum256(publicKey)
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)
+}
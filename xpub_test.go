@@ -0,0 +1,73 @@
+/*******************************************************************************
+*   (c) 2018 - 2022 Zondax AG
+*
+*  Licensed under the Apache License, Version 2.0 (the "License");
+*  you may not use this file except in compliance with the License.
+*  You may obtain a copy of the License at
+*
+*      http://www.apache.org/licenses/LICENSE-2.0
+*
+*  Unless required by applicable law or agreed to in writing, software
+*  distributed under the License is distributed on an "AS IS" BASIS,
+*  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+*  See the License for the specific language governing permissions and
+*  limitations under the License.
+// ********************************************************************************/
+
+package ledger_avalanche_go
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// BIP-32 test vector 1, chain m/0'
+func testVectorXPub() *ExtendedPublicKey {
+	publicKey, _ := hex.DecodeString("035a784662a4a20a65bf6aab9ae98a6c068a81c52e4b032c0fb5400c706cfccc56")
+	chainCode, _ := hex.DecodeString("47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141")
+	return &ExtendedPublicKey{Path: "m/0'", PublicKey: publicKey, ChainCode: chainCode}
+}
+
+func Test_ExtendedPublicKeyChild(t *testing.T) {
+	child, err := testVectorXPub().Child(1)
+	require.NoError(t, err)
+	assert.Equal(t, "m/0'/1", child.Path)
+	assert.Equal(t, "03501e454bf00751f24b1b489aa925215d66af2234e3891c3b21a52bedb3cd711c", hex.EncodeToString(child.PublicKey))
+	assert.Equal(t, "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19", hex.EncodeToString(child.ChainCode))
+
+	_, err = testVectorXPub().Child(HARDENED)
+	assert.Error(t, err)
+}
+
+func Test_GetExtendedPubKey(t *testing.T) {
+	xpub := testVectorXPub()
+	response := append(append([]byte{33}, xpub.PublicKey...), xpub.ChainCode...)
+	device := &mockDevice{handler: replies(response)}
+	ledger := newMockLedger(device)
+
+	found, err := ledger.GetExtendedPubKey("m/44'/9000'/0'", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, xpub.PublicKey, found.PublicKey)
+	assert.Equal(t, xpub.ChainCode, found.ChainCode)
+	assert.Equal(t, byte(INS_GET_EXTENDED_PUBLIC_KEY), device.sent[0][1])
+
+	ledger = newMockLedger(&mockDevice{handler: replies(response[:40])})
+	_, err = ledger.GetExtendedPubKey("m/44'/9000'/0'", "", "")
+	assert.ErrorIs(t, err, ErrMalformedResponse)
+}
+
+func Test_DeriveAddresses(t *testing.T) {
+	addresses, err := DeriveAddresses(testVectorXPub(), "", 1, 2)
+	require.NoError(t, err)
+	require.Len(t, addresses, 2)
+
+	assert.Equal(t, "m/0'/1", addresses[0].Path)
+	assert.Equal(t, "m/0'/2", addresses[1].Path)
+	assert.Equal(t, AddressHash(addresses[0].PublicKey), addresses[0].Hash)
+
+	address, _ := encodeBech32(DefaultHRP, addresses[0].Hash)
+	assert.Equal(t, address, addresses[0].Address)
+}